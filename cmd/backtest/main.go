@@ -0,0 +1,163 @@
+// Command backtest replays historical transactions against a candidate
+// scoring config and reports how the decisions would have changed.
+//
+//	go run ./cmd/backtest -config candidate.json -since 2026-01-01
+//	go run ./cmd/backtest -config candidate.json -source csv -file history.csv
+//	go run ./cmd/backtest -config candidate.json -model model.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/backtest"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/cheemx5395/fraud-detection-lite/internal/service"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+type options struct {
+	config   string
+	model    string
+	source   string
+	file     string
+	since    string
+	until    string
+	timezone string
+	rules    bool
+	json     bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.config, "config", "", "candidate scoring config (JSON, as in the scoring config API)")
+	flag.StringVar(&opts.model, "model", "", "model file scored as the MODEL_SCORE factor")
+	flag.StringVar(&opts.source, "source", "db", "where to read transactions from: db or csv")
+	flag.StringVar(&opts.file, "file", "", "CSV file to read with -source csv")
+	flag.StringVar(&opts.since, "since", "", "replay transactions created at or after this date (YYYY-MM-DD or RFC3339, UTC)")
+	flag.StringVar(&opts.until, "until", "", "replay transactions created before this date (YYYY-MM-DD or RFC3339, UTC)")
+	flag.StringVar(&opts.timezone, "timezone", "UTC", "timezone of UTC timestamps in the CSV file")
+	flag.BoolVar(&opts.rules, "rules", false, "apply the enabled fraud rules from the database")
+	flag.BoolVar(&opts.json, "json", false, "write the report as JSON")
+	flag.Parse()
+
+	if err := run(context.Background(), opts); err != nil {
+		fmt.Fprintln(os.Stderr, "backtest:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options) error {
+	if opts.config == "" {
+		return fmt.Errorf("-config is required")
+	}
+	// registered first, the candidate config may weight the model
+	if opts.model != "" {
+		if err := helpers.RegisterModelFile(opts.model); err != nil {
+			return fmt.Errorf("%s: %w", opts.model, err)
+		}
+	}
+	cfg, err := loadConfig(opts.config)
+	if err != nil {
+		return err
+	}
+
+	// the environment may be set without a .env file
+	_ = godotenv.Load()
+
+	var queries *repository.Queries
+	if opts.source == "db" || opts.rules {
+		DB, db, err := repository.InitializeDatabase(ctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		queries = DB
+	}
+
+	var txns []backtest.Transaction
+	switch opts.source {
+	case "db":
+		txns, err = readDB(ctx, queries, opts.since, opts.until)
+	case "csv":
+		txns, err = readCSV(opts.file, opts.timezone)
+	default:
+		err = fmt.Errorf("unknown -source %q, want db or csv", opts.source)
+	}
+	if err != nil {
+		return err
+	}
+
+	var compiled []specs.CompiledRule
+	if opts.rules {
+		logger, _ := zap.NewProduction()
+		defer logger.Sync()
+		compiled = service.NewRuleService(queries, logger).ActiveRules(ctx)
+		if compiled == nil {
+			return fmt.Errorf("failed to load fraud rules")
+		}
+	}
+
+	report := backtest.Run(ctx, cfg, compiled, txns)
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return report.WriteText(os.Stdout)
+}
+
+func loadConfig(path string) (*specs.ScoringConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, err := backtest.LoadConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func readDB(ctx context.Context, queries *repository.Queries, since string, until string) ([]backtest.Transaction, error) {
+	from, err := backtest.ParseDate(since, time.Unix(0, 0).UTC())
+	if err != nil {
+		return nil, fmt.Errorf("-since: %w", err)
+	}
+	to, err := backtest.ParseDate(until, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("-until: %w", err)
+	}
+	return backtest.ReadDB(ctx, queries, from, to)
+}
+
+func readCSV(path string, timezone string) ([]backtest.Transaction, error) {
+	if path == "" {
+		return nil, fmt.Errorf("-file is required with -source csv")
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("-timezone: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	txns, err := backtest.ReadCSV(f, loc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return txns, nil
+}
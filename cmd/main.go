@@ -10,6 +10,10 @@ import (
 
 	"github.com/cheemx5395/fraud-detection-lite/internal/api"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/geoip"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/notifier"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/velocity"
 	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 	"github.com/cheemx5395/fraud-detection-lite/internal/service"
 	"github.com/cheemx5395/fraud-detection-lite/internal/worker"
@@ -49,12 +53,29 @@ func main() {
 	logger.Info("Connected to Redis")
 	defer RD.Close()
 
+	// optional model-based risk factor
+	if path := os.Getenv("MODEL_PATH"); path != "" {
+		if err := helpers.RegisterModelFile(path); err != nil {
+			logger.Error("Model not loaded, scoring without it", zap.String("path", path), zap.Error(err))
+		} else {
+			logger.Info("Loaded fraud model", zap.String("path", path))
+		}
+	}
+
 	// Initialize Services
-	txnService := service.NewTransactionService(DB, db, logger)
+	configService := service.NewScoringConfigService(DB, db, logger)
+	mfaNotifier := notifier.New(os.Getenv("MFA_NOTIFIER"), os.Getenv("MFA_NOTIFIER_FILE"), logger)
+	mfaService := service.NewMFAService(DB, db, RD, mfaNotifier, logger)
+	velocityStore := velocity.New(RD, DB, logger)
+	locator := geoip.New(os.Getenv("GEOIP_DB_PATH"), logger)
+	listService := service.NewListService(DB, RD, logger)
+	ruleService := service.NewRuleService(DB, logger)
+	txnService := service.NewTransactionService(DB, db, configService, mfaService, velocityStore, locator, listService, ruleService, logger)
 	userService := service.NewUserService(DB, RD, logger)
+	caseService := service.NewCaseService(DB, db, logger)
 
 	// Initializing Router
-	router := api.NewRouter(DB, RD, txnService, userService, logger)
+	router := api.NewRouter(DB, RD, txnService, userService, configService, mfaService, caseService, listService, ruleService, logger)
 
 	// CORS middleware
 	corsOptions := cors.New(constants.CorsOptions)
@@ -72,9 +93,14 @@ func main() {
 	}()
 
 	// cron-job to update profile behavior added
-	updater := worker.NewProfileUpdater(DB)
+	updater := worker.NewProfileUpdater(DB, txnService)
 	cronInstance := updater.Start(ctx)
 
+	// background workers for bulk upload jobs, resuming any interrupted ones
+	jobCtx, cancelJobs := context.WithCancel(ctx)
+	bulkJobs := worker.NewBulkJobPool(txnService, constants.BulkJobWorkers, constants.BulkJobPollInterval)
+	bulkJobs.Start(jobCtx)
+
 	// Graceful shutdown
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt)
@@ -86,6 +112,9 @@ func main() {
 	ctx = cronInstance.Stop()
 	<-ctx.Done()
 
+	cancelJobs()
+	bulkJobs.Wait()
+
 	tc, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	server.Shutdown(tc)
 	cancel()
@@ -0,0 +1,158 @@
+// Command train fits a logistic regression model on labeled transactions and
+// writes it to a model file, to be scored as the MODEL_SCORE factor through
+// MODEL_PATH or backtested with cmd/backtest -model.
+//
+//	go run ./cmd/train -out model.json -since 2026-01-01
+//	go run ./cmd/train -out model.json -include-allowed -epochs 5000
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/backtest"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/model"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/joho/godotenv"
+)
+
+type options struct {
+	out            string
+	since          string
+	until          string
+	includeAllowed bool
+	fit            model.FitOptions
+}
+
+func main() {
+	opts := options{fit: model.DefaultFitOptions()}
+	flag.StringVar(&opts.out, "out", "", "model file to write")
+	flag.StringVar(&opts.since, "since", "", "train on transactions created at or after this date (YYYY-MM-DD or RFC3339, UTC)")
+	flag.StringVar(&opts.until, "until", "", "train on transactions created before this date (YYYY-MM-DD or RFC3339, UTC)")
+	flag.BoolVar(&opts.includeAllowed, "include-allowed", false, "train on unlabeled allowed transactions as legitimate")
+	flag.IntVar(&opts.fit.Epochs, "epochs", opts.fit.Epochs, "gradient descent steps")
+	flag.Float64Var(&opts.fit.LearningRate, "learning-rate", opts.fit.LearningRate, "gradient descent learning rate")
+	flag.Float64Var(&opts.fit.L2, "l2", opts.fit.L2, "L2 penalty on the weights")
+	flag.BoolVar(&opts.fit.Balanced, "balanced", opts.fit.Balanced, "weigh fraud and legitimate transactions equally")
+	flag.Parse()
+
+	if err := run(context.Background(), opts); err != nil {
+		fmt.Fprintln(os.Stderr, "train:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options) error {
+	if opts.out == "" {
+		return fmt.Errorf("-out is required")
+	}
+	since, err := backtest.ParseDate(opts.since, time.Unix(0, 0).UTC())
+	if err != nil {
+		return fmt.Errorf("-since: %w", err)
+	}
+	until, err := backtest.ParseDate(opts.until, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("-until: %w", err)
+	}
+
+	// the environment may be set without a .env file
+	_ = godotenv.Load()
+
+	queries, db, err := repository.InitializeDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	txns, err := backtest.ReadDB(ctx, queries, since, until)
+	if err != nil {
+		return err
+	}
+
+	// features are built from the history replayed in memory, as the
+	// backtest sees it, so training sees what scoring saw at the time
+	var x [][]float64
+	var y []bool
+	backtest.Observe(txns, func(t backtest.Transaction, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory) {
+		fraud, ok := label(t, opts.includeAllowed)
+		if !ok {
+			return
+		}
+		x = append(x, helpers.ModelFeatures(txn, profile, history))
+		y = append(y, fraud)
+	})
+
+	features := helpers.ModelFeatureNames()
+	m, err := model.FitLogistic(features, x, y, opts.fit)
+	if err != nil {
+		return fmt.Errorf("%d training transactions: %w", len(x), err)
+	}
+
+	if err := write(opts.out, m); err != nil {
+		return err
+	}
+	summarize(m, x, y)
+	return nil
+}
+
+// label tells fraud (true) from legitimate transactions; unlabeled ones are
+// skipped unless they were allowed and includeAllowed is set
+func label(t backtest.Transaction, includeAllowed bool) (bool, bool) {
+	switch {
+	case t.Label == repository.FraudLabelCONFIRMEDFRAUD:
+		return true, true
+	case t.Label == repository.FraudLabelFALSEPOSITIVE:
+		return false, true
+	case includeAllowed && t.Label == "" && t.Decision == repository.TransactionDecisionALLOW:
+		return false, true
+	}
+	return false, false
+}
+
+func write(path string, m *model.Model) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// summarize prints the fit on the training transactions, predicting fraud
+// over a probability of 0.5
+func summarize(m *model.Model, x [][]float64, y []bool) {
+	metrics := specs.LabelMetrics{}
+	positives := 0
+	for i, row := range x {
+		decision := repository.TransactionDecisionALLOW
+		if m.Predict(row).Probability > 0.5 {
+			decision = repository.TransactionDecisionBLOCK
+		}
+		fraudLabel := repository.FraudLabelFALSEPOSITIVE
+		if y[i] {
+			fraudLabel = repository.FraudLabelCONFIRMEDFRAUD
+			positives++
+		}
+		metrics.Add(fraudLabel, decision, 1)
+	}
+	metrics.Finish()
+
+	fmt.Printf("trained on %d transactions, %d fraud\n", len(x), positives)
+	fmt.Printf("log loss %.4f, precision %.3f, recall %.3f\n", m.LogLoss(x, y), metrics.Precision, metrics.Recall)
+	fmt.Println("weights (per standard deviation):")
+	for i, name := range m.Features {
+		fmt.Printf("  %-26s %+.4f\n", name, m.Weights[i])
+	}
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/middleware"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/gorilla/mux"
+)
+
+type caseServiceInterface interface {
+	ListCases(ctx context.Context, req specs.ListFraudCasesRequest) ([]specs.FraudCaseResponse, error)
+	GetCase(ctx context.Context, caseID int32) (specs.FraudCaseDetailResponse, error)
+	AssignCase(ctx context.Context, actorID int32, caseID int32, req specs.AssignFraudCaseRequest) (specs.FraudCaseResponse, error)
+	ResolveCase(ctx context.Context, actorID int32, caseID int32, req specs.ResolveFraudCaseRequest) (specs.FraudCaseResponse, error)
+}
+
+// GetCases returns an HTTP handler that lists the review queue
+func GetCases(s caseServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		req := specs.ListFraudCasesRequest{
+			Status: q.Get("status"),
+			Limit:  constants.DefaultTransactionsLimit,
+			Offset: constants.DefaultTransactionsOffset,
+		}
+
+		if l := q.Get("limit"); l != "" {
+			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+				req.Limit = int32(parsed)
+			}
+		}
+
+		if o := q.Get("offset"); o != "" {
+			if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+				req.Offset = int32(parsed)
+			}
+		}
+
+		if a := q.Get("assigned_to"); a != "" {
+			parsed, err := strconv.ParseInt(a, 10, 32)
+			if err != nil {
+				middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+				return
+			}
+			assignedTo := int32(parsed)
+			req.AssignedTo = &assignedTo
+		}
+
+		if err := req.Validate(); err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.ListCases(r.Context(), req)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// GetCase returns an HTTP handler that shows a case with its transaction and history
+func GetCase(s caseServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		res, err := s.GetCase(r.Context(), int32(caseID))
+		if err != nil {
+			writeCaseError(w, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// AssignCase returns an HTTP handler that assigns a case to an analyst
+func AssignCase(s caseServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		caseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		req, err := decodeAssignFraudCaseRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.AssignCase(r.Context(), actorID, int32(caseID), req)
+		if err != nil {
+			writeCaseError(w, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// ResolveCase returns an HTTP handler that records the disposition of a case
+func ResolveCase(s caseServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		caseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		req, err := decodeResolveFraudCaseRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := req.Validate(); err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.ResolveCase(r.Context(), actorID, int32(caseID), req)
+		if err != nil {
+			writeCaseError(w, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+func writeCaseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pkgerrors.ErrCaseNotFound), errors.Is(err, pkgerrors.ErrUserNotFound):
+		middleware.ErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, pkgerrors.ErrCaseAlreadyResolved):
+		middleware.ErrorResponse(w, http.StatusConflict, err)
+	case errors.Is(err, pkgerrors.ErrInvalidAssignee):
+		middleware.ErrorResponse(w, http.StatusBadRequest, err)
+	default:
+		middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+	}
+}
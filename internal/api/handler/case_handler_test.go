@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newCaseRequest(t *testing.T, method string, target string, caseID string, body string) *http.Request {
+	t.Helper()
+	os.Setenv("JWT_SECRET", "testsecret")
+	token, _ := helpers.MakeJWT(9, "Analyst", "analyst@example.com", "ANALYST", "testsecret", time.Hour)
+
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if caseID != "" {
+		req = mux.SetURLVars(req, map[string]string{"id": caseID})
+	}
+	return req
+}
+
+func TestGetCases(t *testing.T) {
+	t.Run("filters are passed through", func(t *testing.T) {
+		mockService := new(MockCaseService)
+		w := httptest.NewRecorder()
+
+		assignedTo := int32(9)
+		mockService.On("ListCases", mock.Anything, specs.ListFraudCasesRequest{
+			Status:     "ASSIGNED",
+			AssignedTo: &assignedTo,
+			Limit:      5,
+			Offset:     10,
+		}).Return([]specs.FraudCaseResponse{{ID: 1, Status: repository.CaseStatusASSIGNED}}, nil).Once()
+
+		GetCases(mockService)(w, newCaseRequest(t, http.MethodGet, "/api/cases?status=ASSIGNED&assigned_to=9&limit=5&offset=10", "", ""))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		mockService := new(MockCaseService)
+		w := httptest.NewRecorder()
+
+		GetCases(mockService)(w, newCaseRequest(t, http.MethodGet, "/api/cases?status=CLOSED", "", ""))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ListCases", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAssignCase(t *testing.T) {
+	t.Run("assigns to caller by default", func(t *testing.T) {
+		mockService := new(MockCaseService)
+		w := httptest.NewRecorder()
+
+		assignee := int32(9)
+		mockService.On("AssignCase", mock.Anything, int32(9), int32(3), specs.AssignFraudCaseRequest{Notes: "taking this"}).
+			Return(specs.FraudCaseResponse{ID: 3, Status: repository.CaseStatusASSIGNED, AssignedTo: &assignee}, nil).Once()
+
+		AssignCase(mockService)(w, newCaseRequest(t, http.MethodPost, "/api/cases/3/assign", "3", `{"notes":" taking this "}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("resolved case", func(t *testing.T) {
+		mockService := new(MockCaseService)
+		w := httptest.NewRecorder()
+
+		mockService.On("AssignCase", mock.Anything, int32(9), int32(3), mock.Anything).
+			Return(specs.FraudCaseResponse{}, pkgerrors.ErrCaseAlreadyResolved).Once()
+
+		AssignCase(mockService)(w, newCaseRequest(t, http.MethodPost, "/api/cases/3/assign", "3", `{}`))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("assignee is not an analyst", func(t *testing.T) {
+		mockService := new(MockCaseService)
+		w := httptest.NewRecorder()
+
+		assignee := int32(4)
+		mockService.On("AssignCase", mock.Anything, int32(9), int32(3), specs.AssignFraudCaseRequest{AnalystID: assignee}).
+			Return(specs.FraudCaseResponse{}, pkgerrors.ErrInvalidAssignee).Once()
+
+		AssignCase(mockService)(w, newCaseRequest(t, http.MethodPost, "/api/cases/3/assign", "3", `{"analyst_id":4}`))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestResolveCase(t *testing.T) {
+	t.Run("invalid disposition", func(t *testing.T) {
+		mockService := new(MockCaseService)
+		w := httptest.NewRecorder()
+
+		ResolveCase(mockService)(w, newCaseRequest(t, http.MethodPost, "/api/cases/3/resolve", "3", `{"disposition":"MAYBE"}`))
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, pkgerrors.ErrInvalidDisposition.Error(), response["error_message"])
+		mockService.AssertNotCalled(t, "ResolveCase", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("confirmed fraud", func(t *testing.T) {
+		mockService := new(MockCaseService)
+		w := httptest.NewRecorder()
+
+		mockService.On("ResolveCase", mock.Anything, int32(9), int32(3), specs.ResolveFraudCaseRequest{
+			Disposition: "CONFIRMED_FRAUD",
+			Notes:       "customer reported card stolen",
+		}).Return(specs.FraudCaseResponse{
+			ID:          3,
+			Status:      repository.CaseStatusRESOLVED,
+			Disposition: repository.FraudLabelCONFIRMEDFRAUD,
+		}, nil).Once()
+
+		body := `{"disposition":"confirmed_fraud","notes":"customer reported card stolen"}`
+		ResolveCase(mockService)(w, newCaseRequest(t, http.MethodPost, "/api/cases/3/resolve", "3", body))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data := response["data"].(map[string]any)
+		assert.Equal(t, "RESOLVED", data["status"])
+		assert.Equal(t, "CONFIRMED_FRAUD", data["disposition"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown case", func(t *testing.T) {
+		mockService := new(MockCaseService)
+		w := httptest.NewRecorder()
+
+		mockService.On("ResolveCase", mock.Anything, int32(9), int32(99), mock.Anything).
+			Return(specs.FraudCaseResponse{}, pkgerrors.ErrCaseNotFound).Once()
+
+		ResolveCase(mockService)(w, newCaseRequest(t, http.MethodPost, "/api/cases/99/resolve", "99", `{"disposition":"FALSE_POSITIVE"}`))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
 )
 
@@ -18,6 +19,7 @@ func decodeUserSignupRequest(r *http.Request) (specs.UserSignupRequest, error) {
 	}
 	req.Name = strings.TrimSpace(req.Name)
 	req.Email = strings.TrimSpace(req.Email)
+	req.Timezone = strings.TrimSpace(req.Timezone)
 
 	return req, nil
 }
@@ -33,6 +35,28 @@ func decodeUserLoginRequest(r *http.Request) (specs.UserLoginRequest, error) {
 	return req, nil
 }
 
+// decode the role change request
+func decodeUpdateUserRoleRequest(r *http.Request) (specs.UpdateUserRoleRequest, error) {
+	var req specs.UpdateUserRoleRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return specs.UpdateUserRoleRequest{}, errors.ErrInvalidBody
+	}
+	req.Role = strings.ToUpper(strings.TrimSpace(req.Role))
+	return req, nil
+}
+
+// decode the timezone change request
+func decodeUpdateTimezoneRequest(r *http.Request) (specs.UpdateTimezoneRequest, error) {
+	var req specs.UpdateTimezoneRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return specs.UpdateTimezoneRequest{}, errors.ErrInvalidBody
+	}
+	req.Timezone = strings.TrimSpace(req.Timezone)
+	return req, nil
+}
+
 // decode the transaction request
 func decodeCreateTransaction(r *http.Request) (specs.CreateTransactionRequest, error) {
 	var req specs.CreateTransactionRequest
@@ -40,5 +64,86 @@ func decodeCreateTransaction(r *http.Request) (specs.CreateTransactionRequest, e
 	if err != nil {
 		return specs.CreateTransactionRequest{}, err
 	}
+	req.DeviceID = strings.TrimSpace(req.DeviceID)
+	req.PayeeID = strings.TrimSpace(req.PayeeID)
+	req.IPAddress = strings.TrimSpace(req.IPAddress)
+	req.UserAgent = strings.TrimSpace(req.UserAgent)
+	return req, nil
+}
+
+// decode the scoring config request, starting from the built-in default so that
+// omitted fields keep their default values
+func decodeCreateScoringConfigRequest(r *http.Request) (specs.CreateScoringConfigRequest, error) {
+	req := specs.CreateScoringConfigRequest{
+		Config: *helpers.DefaultScoringConfig(),
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return specs.CreateScoringConfigRequest{}, errors.ErrInvalidBody
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	return req, nil
+}
+
+// decode the list entry creation request
+func decodeCreateListEntryRequest(r *http.Request) (specs.CreateListEntryRequest, error) {
+	var req specs.CreateListEntryRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return specs.CreateListEntryRequest{}, errors.ErrInvalidBody
+	}
+	req.ListType = strings.ToUpper(strings.TrimSpace(req.ListType))
+	req.Entity = strings.ToUpper(strings.TrimSpace(req.Entity))
+	req.Value = strings.TrimSpace(req.Value)
+	req.Reason = strings.TrimSpace(req.Reason)
+	return req, nil
+}
+
+// decode the fraud rule request
+func decodeRuleRequest(r *http.Request) (specs.RuleRequest, error) {
+	var req specs.RuleRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return specs.RuleRequest{}, errors.ErrInvalidBody
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Expression = strings.TrimSpace(req.Expression)
+	req.Action = strings.ToUpper(strings.TrimSpace(req.Action))
+	req.Decision = strings.ToUpper(strings.TrimSpace(req.Decision))
+	req.Tag = strings.TrimSpace(req.Tag)
+	return req, nil
+}
+
+// decode the mfa verification request
+func decodeVerifyMFARequest(r *http.Request) (specs.VerifyMFARequest, error) {
+	var req specs.VerifyMFARequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return specs.VerifyMFARequest{}, errors.ErrInvalidBody
+	}
+	req.OTP = strings.TrimSpace(req.OTP)
+	return req, nil
+}
+
+// decode the case assignment request
+func decodeAssignFraudCaseRequest(r *http.Request) (specs.AssignFraudCaseRequest, error) {
+	var req specs.AssignFraudCaseRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return specs.AssignFraudCaseRequest{}, errors.ErrInvalidBody
+	}
+	req.Notes = strings.TrimSpace(req.Notes)
+	return req, nil
+}
+
+// decode the case resolution request
+func decodeResolveFraudCaseRequest(r *http.Request) (specs.ResolveFraudCaseRequest, error) {
+	var req specs.ResolveFraudCaseRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return specs.ResolveFraudCaseRequest{}, errors.ErrInvalidBody
+	}
+	req.Disposition = strings.ToUpper(strings.TrimSpace(req.Disposition))
+	req.Notes = strings.TrimSpace(req.Notes)
 	return req, nil
 }
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/middleware"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/gorilla/mux"
+)
+
+type listServiceInterface interface {
+	CreateEntry(ctx context.Context, actorID int32, req specs.CreateListEntryRequest) (specs.ListEntryResponse, error)
+	ListEntries(ctx context.Context) ([]specs.ListEntryResponse, error)
+	RemoveEntry(ctx context.Context, actorID int32, entryID int32) (specs.ListEntryResponse, error)
+}
+
+// CreateListEntry returns an HTTP handler that adds an entry to the allow or deny list
+func CreateListEntry(s listServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		req, err := decodeCreateListEntryRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := req.Validate(); err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.CreateEntry(r.Context(), actorID, req)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusCreated, res)
+	}
+}
+
+// GetListEntries returns an HTTP handler that lists the active entries of both lists
+func GetListEntries(s listServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := s.ListEntries(r.Context())
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// DeleteListEntry returns an HTTP handler that takes an entry off its list
+func DeleteListEntry(s listServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		entryID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		res, err := s.RemoveEntry(r.Context(), actorID, int32(entryID))
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrListEntryNotFound) {
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newListRequest(t *testing.T, method string, target string, entryID string, body string) *http.Request {
+	t.Helper()
+	os.Setenv("JWT_SECRET", "testsecret")
+	token, _ := helpers.MakeJWT(1, "Admin", "admin@example.com", "ADMIN", "testsecret", time.Hour)
+
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if entryID != "" {
+		req = mux.SetURLVars(req, map[string]string{"id": entryID})
+	}
+	return req
+}
+
+func TestCreateListEntry(t *testing.T) {
+	t.Run("normalized request is passed through", func(t *testing.T) {
+		mockService := new(MockListService)
+		w := httptest.NewRecorder()
+
+		mockService.On("CreateEntry", mock.Anything, int32(1), specs.CreateListEntryRequest{
+			ListType: "DENY",
+			Entity:   "PAYEE",
+			Value:    "mule@upi",
+			Reason:   "confirmed mule account",
+		}).Return(specs.ListEntryResponse{ID: 4, ListType: repository.ListTypeDENY}, nil).Once()
+
+		body := `{"list_type":"deny","entity":" payee ","value":"mule@upi ","reason":"confirmed mule account"}`
+		CreateListEntry(mockService)(w, newListRequest(t, http.MethodPost, "/api/admin/lists", "", body))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid entry", func(t *testing.T) {
+		mockService := new(MockListService)
+		w := httptest.NewRecorder()
+
+		body := `{"list_type":"DENY","entity":"IP","value":"not-an-ip"}`
+		CreateListEntry(mockService)(w, newListRequest(t, http.MethodPost, "/api/admin/lists", "", body))
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, pkgerrors.ErrInvalidListValue.Error(), response["error_message"])
+		mockService.AssertNotCalled(t, "CreateEntry", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestDeleteListEntry(t *testing.T) {
+	t.Run("unknown entry", func(t *testing.T) {
+		mockService := new(MockListService)
+		w := httptest.NewRecorder()
+
+		mockService.On("RemoveEntry", mock.Anything, int32(1), int32(7)).
+			Return(specs.ListEntryResponse{}, pkgerrors.ErrListEntryNotFound).Once()
+
+		DeleteListEntry(mockService)(w, newListRequest(t, http.MethodDelete, "/api/admin/lists/7", "7", ""))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		mockService := new(MockListService)
+		w := httptest.NewRecorder()
+
+		DeleteListEntry(mockService)(w, newListRequest(t, http.MethodDelete, "/api/admin/lists/abc", "abc", ""))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "RemoveEntry", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
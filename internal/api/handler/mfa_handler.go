@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/middleware"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/gorilla/mux"
+)
+
+type mfaServiceInterface interface {
+	VerifyChallenge(ctx context.Context, userID int32, txnID int32, req specs.VerifyMFARequest) (specs.VerifyMFAResponse, error)
+}
+
+// VerifyTransactionMFA returns an HTTP handler that completes the MFA challenge of a transaction
+func VerifyTransactionMFA(s mfaServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		txnID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		req, err := decodeVerifyMFARequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := req.Validate(); err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.VerifyChallenge(r.Context(), userID, int32(txnID), req)
+		if err != nil {
+			switch {
+			case errors.Is(err, pkgerrors.ErrTransactionNotFound):
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+			case errors.Is(err, pkgerrors.ErrMFANotPending):
+				middleware.ErrorResponse(w, http.StatusConflict, err)
+			case errors.Is(err, pkgerrors.ErrInvalidOTP):
+				middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			default:
+				middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
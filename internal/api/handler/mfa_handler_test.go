@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newMFARequest(t *testing.T, txnID string, body string) *http.Request {
+	t.Helper()
+	os.Setenv("JWT_SECRET", "testsecret")
+	token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/"+txnID+"/mfa", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	return mux.SetURLVars(req, map[string]string{"id": txnID})
+}
+
+func TestVerifyTransactionMFA(t *testing.T) {
+	t.Run("missing otp", func(t *testing.T) {
+		mockService := new(MockMFAService)
+		w := httptest.NewRecorder()
+
+		VerifyTransactionMFA(mockService)(w, newMFARequest(t, "7", `{"otp":"  "}`))
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, pkgerrors.ErrMissingOTPInRequest.Error(), response["error_message"])
+		mockService.AssertNotCalled(t, "VerifyChallenge", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("verified", func(t *testing.T) {
+		mockService := new(MockMFAService)
+		w := httptest.NewRecorder()
+
+		mockService.On("VerifyChallenge", mock.Anything, int32(1), int32(7), specs.VerifyMFARequest{OTP: "123456"}).
+			Return(specs.VerifyMFAResponse{
+				TransactionID: 7,
+				Decision:      repository.TransactionDecisionALLOW,
+				Message:       "MFA verified",
+			}, nil).Once()
+
+		VerifyTransactionMFA(mockService)(w, newMFARequest(t, "7", `{"otp":"123456"}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data := response["data"].(map[string]any)
+		assert.Equal(t, "ALLOW", data["decision"])
+		mockService.AssertExpectations(t)
+	})
+
+	errorCases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"wrong otp", pkgerrors.ErrInvalidOTP, http.StatusUnauthorized},
+		{"not pending", pkgerrors.ErrMFANotPending, http.StatusConflict},
+		{"unknown transaction", pkgerrors.ErrTransactionNotFound, http.StatusNotFound},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(MockMFAService)
+			w := httptest.NewRecorder()
+
+			mockService.On("VerifyChallenge", mock.Anything, int32(1), int32(7), mock.Anything).
+				Return(specs.VerifyMFAResponse{}, tc.err).Once()
+
+			VerifyTransactionMFA(mockService)(w, newMFARequest(t, "7", `{"otp":"000000"}`))
+
+			assert.Equal(t, tc.code, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCaseService struct {
+	mock.Mock
+}
+
+func (m *MockCaseService) ListCases(ctx context.Context, req specs.ListFraudCasesRequest) ([]specs.FraudCaseResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]specs.FraudCaseResponse), args.Error(1)
+}
+
+func (m *MockCaseService) GetCase(ctx context.Context, caseID int32) (specs.FraudCaseDetailResponse, error) {
+	args := m.Called(ctx, caseID)
+	return args.Get(0).(specs.FraudCaseDetailResponse), args.Error(1)
+}
+
+func (m *MockCaseService) AssignCase(ctx context.Context, actorID int32, caseID int32, req specs.AssignFraudCaseRequest) (specs.FraudCaseResponse, error) {
+	args := m.Called(ctx, actorID, caseID, req)
+	return args.Get(0).(specs.FraudCaseResponse), args.Error(1)
+}
+
+func (m *MockCaseService) ResolveCase(ctx context.Context, actorID int32, caseID int32, req specs.ResolveFraudCaseRequest) (specs.FraudCaseResponse, error) {
+	args := m.Called(ctx, actorID, caseID, req)
+	return args.Get(0).(specs.FraudCaseResponse), args.Error(1)
+}
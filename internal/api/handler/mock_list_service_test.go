@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockListService struct {
+	mock.Mock
+}
+
+func (m *MockListService) CreateEntry(ctx context.Context, actorID int32, req specs.CreateListEntryRequest) (specs.ListEntryResponse, error) {
+	args := m.Called(ctx, actorID, req)
+	return args.Get(0).(specs.ListEntryResponse), args.Error(1)
+}
+
+func (m *MockListService) ListEntries(ctx context.Context) ([]specs.ListEntryResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]specs.ListEntryResponse), args.Error(1)
+}
+
+func (m *MockListService) RemoveEntry(ctx context.Context, actorID int32, entryID int32) (specs.ListEntryResponse, error) {
+	args := m.Called(ctx, actorID, entryID)
+	return args.Get(0).(specs.ListEntryResponse), args.Error(1)
+}
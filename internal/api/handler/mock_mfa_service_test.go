@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) VerifyChallenge(ctx context.Context, userID int32, txnID int32, req specs.VerifyMFARequest) (specs.VerifyMFAResponse, error) {
+	args := m.Called(ctx, userID, txnID, req)
+	return args.Get(0).(specs.VerifyMFAResponse), args.Error(1)
+}
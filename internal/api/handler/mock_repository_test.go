@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) GetAllTransactionsByUserID(ctx context.Context, arg repository.GetAllTransactionsByUserIDParams) ([]repository.Transaction, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]repository.Transaction), args.Error(1)
+}
+
+func (m *MockRepository) GetTransactionByTxnID(ctx context.Context, arg repository.GetTransactionByTxnIDParams) (repository.Transaction, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(repository.Transaction), args.Error(1)
+}
+
+func (m *MockRepository) GetTransactionByID(ctx context.Context, id int32) (repository.Transaction, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(repository.Transaction), args.Error(1)
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRuleService struct {
+	mock.Mock
+}
+
+func (m *MockRuleService) CreateRule(ctx context.Context, actorID int32, req specs.RuleRequest) (specs.RuleResponse, error) {
+	args := m.Called(ctx, actorID, req)
+	return args.Get(0).(specs.RuleResponse), args.Error(1)
+}
+
+func (m *MockRuleService) ListRules(ctx context.Context) ([]specs.RuleResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]specs.RuleResponse), args.Error(1)
+}
+
+func (m *MockRuleService) UpdateRule(ctx context.Context, actorID int32, ruleID int32, req specs.RuleRequest) (specs.RuleResponse, error) {
+	args := m.Called(ctx, actorID, ruleID, req)
+	return args.Get(0).(specs.RuleResponse), args.Error(1)
+}
+
+func (m *MockRuleService) DeleteRule(ctx context.Context, actorID int32, ruleID int32) (specs.RuleResponse, error) {
+	args := m.Called(ctx, actorID, ruleID)
+	return args.Get(0).(specs.RuleResponse), args.Error(1)
+}
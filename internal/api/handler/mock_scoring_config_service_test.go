@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockScoringConfigService struct {
+	mock.Mock
+}
+
+func (m *MockScoringConfigService) ActiveConfig(ctx context.Context) *specs.ScoringConfig {
+	args := m.Called(ctx)
+	return args.Get(0).(*specs.ScoringConfig)
+}
+
+func (m *MockScoringConfigService) CreateConfig(ctx context.Context, userID int32, req specs.CreateScoringConfigRequest) (specs.ScoringConfigResponse, error) {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(specs.ScoringConfigResponse), args.Error(1)
+}
+
+func (m *MockScoringConfigService) ActivateConfig(ctx context.Context, version int32) (specs.ScoringConfigResponse, error) {
+	args := m.Called(ctx, version)
+	return args.Get(0).(specs.ScoringConfigResponse), args.Error(1)
+}
+
+func (m *MockScoringConfigService) ListConfigs(ctx context.Context) ([]specs.ScoringConfigResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]specs.ScoringConfigResponse), args.Error(1)
+}
+
+func (m *MockScoringConfigService) StartShadow(ctx context.Context, version int32) (specs.ScoringConfigResponse, error) {
+	args := m.Called(ctx, version)
+	return args.Get(0).(specs.ScoringConfigResponse), args.Error(1)
+}
+
+func (m *MockScoringConfigService) StopShadow(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockScoringConfigService) ShadowSummary(ctx context.Context, version int32) (specs.ShadowSummaryResponse, error) {
+	args := m.Called(ctx, version)
+	return args.Get(0).(specs.ShadowSummaryResponse), args.Error(1)
+}
@@ -19,8 +19,23 @@ func (m *MockTransactionService) CreateTransaction(ctx context.Context, userID i
 	return args.Get(0).(specs.CreateTransactionResponse), args.Error(1)
 }
 
-func (m *MockTransactionService) ProcessBulkTransactions(ctx context.Context, userID int32, reader io.Reader, filename string) (specs.BulkProcessResponse, error) {
-	args := m.Called(ctx, userID, reader, filename)
+func (m *MockTransactionService) EvaluateTransaction(ctx context.Context, userID int32, req specs.CreateTransactionRequest) (specs.FraudAnalysisResult, error) {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(specs.FraudAnalysisResult), args.Error(1)
+}
+
+func (m *MockTransactionService) ProcessBulkTransactions(ctx context.Context, userID int32, reader io.Reader, filename string, strict bool) (specs.BulkJobResponse, error) {
+	args := m.Called(ctx, userID, reader, filename, strict)
 	log.Println(args...)
-	return args.Get(0).(specs.BulkProcessResponse), args.Error(1)
+	return args.Get(0).(specs.BulkJobResponse), args.Error(1)
+}
+
+func (m *MockTransactionService) GetBulkJob(ctx context.Context, userID int32, jobID string) (specs.BulkJobResponse, error) {
+	args := m.Called(ctx, userID, jobID)
+	return args.Get(0).(specs.BulkJobResponse), args.Error(1)
+}
+
+func (m *MockTransactionService) ListBulkJobErrors(ctx context.Context, userID int32, jobID string) ([]specs.BulkRowErrorResponse, error) {
+	args := m.Called(ctx, userID, jobID)
+	return args.Get(0).([]specs.BulkRowErrorResponse), args.Error(1)
 }
@@ -27,3 +27,13 @@ func (m *MockUserService) Logout(ctx context.Context, claims *specs.UserTokenCla
 	args := m.Called(ctx, claims)
 	return args.Error(0)
 }
+
+func (m *MockUserService) UpdateUserRole(ctx context.Context, userID int32, req specs.UpdateUserRoleRequest) (specs.UserResponse, error) {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(specs.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) UpdateTimezone(ctx context.Context, userID int32, req specs.UpdateTimezoneRequest) (specs.UserResponse, error) {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(specs.UserResponse), args.Error(1)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/middleware"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/gorilla/mux"
+)
+
+type ruleServiceInterface interface {
+	CreateRule(ctx context.Context, actorID int32, req specs.RuleRequest) (specs.RuleResponse, error)
+	ListRules(ctx context.Context) ([]specs.RuleResponse, error)
+	UpdateRule(ctx context.Context, actorID int32, ruleID int32, req specs.RuleRequest) (specs.RuleResponse, error)
+	DeleteRule(ctx context.Context, actorID int32, ruleID int32) (specs.RuleResponse, error)
+}
+
+// CreateRule returns an HTTP handler that adds a fraud rule
+func CreateRule(s ruleServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		req, err := decodeRuleRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := req.Validate(); err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.CreateRule(r.Context(), actorID, req)
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrInvalidRuleExpression) {
+				middleware.ErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusCreated, res)
+	}
+}
+
+// GetRules returns an HTTP handler that lists every fraud rule in evaluation order
+func GetRules(s ruleServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := s.ListRules(r.Context())
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// UpdateRule returns an HTTP handler that replaces a fraud rule
+func UpdateRule(s ruleServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		ruleID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		req, err := decodeRuleRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := req.Validate(); err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.UpdateRule(r.Context(), actorID, int32(ruleID), req)
+		if err != nil {
+			switch {
+			case errors.Is(err, pkgerrors.ErrInvalidRuleExpression):
+				middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			case errors.Is(err, pkgerrors.ErrRuleNotFound):
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+			default:
+				middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// DeleteRule returns an HTTP handler that removes a fraud rule
+func DeleteRule(s ruleServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		ruleID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		res, err := s.DeleteRule(r.Context(), actorID, int32(ruleID))
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrRuleNotFound) {
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
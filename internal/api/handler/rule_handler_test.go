@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateRule(t *testing.T) {
+	t.Run("normalized request is passed through", func(t *testing.T) {
+		mockService := new(MockRuleService)
+		w := httptest.NewRecorder()
+
+		mockService.On("CreateRule", mock.Anything, int32(1), specs.RuleRequest{
+			Name:       "night card",
+			Expression: `txn.mode == "CARD" && txn.hour < 5`,
+			Action:     "SET_DECISION",
+			Decision:   "BLOCK",
+			Priority:   10,
+		}).Return(specs.RuleResponse{ID: 3, Action: repository.RuleActionSETDECISION}, nil).Once()
+
+		body := `{"name":" night card","expression":"txn.mode == \"CARD\" && txn.hour < 5 ","action":"set_decision","decision":"block","priority":10}`
+		CreateRule(mockService)(w, newListRequest(t, http.MethodPost, "/api/admin/rules", "", body))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid action", func(t *testing.T) {
+		mockService := new(MockRuleService)
+		w := httptest.NewRecorder()
+
+		body := `{"name":"tag","expression":"txn.amount > 5","action":"ADD_SCORE"}`
+		CreateRule(mockService)(w, newListRequest(t, http.MethodPost, "/api/admin/rules", "", body))
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, pkgerrors.ErrInvalidRuleScoreDelta.Error(), response["error_message"])
+		mockService.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("expression does not compile", func(t *testing.T) {
+		mockService := new(MockRuleService)
+		w := httptest.NewRecorder()
+
+		mockService.On("CreateRule", mock.Anything, int32(1), mock.Anything).
+			Return(specs.RuleResponse{}, pkgerrors.ErrInvalidRuleExpression).Once()
+
+		body := `{"name":"tag","expression":"txn.country == \"IN\"","action":"ADD_TAG","tag":"abroad"}`
+		CreateRule(mockService)(w, newListRequest(t, http.MethodPost, "/api/admin/rules", "", body))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestUpdateRule(t *testing.T) {
+	t.Run("unknown rule", func(t *testing.T) {
+		mockService := new(MockRuleService)
+		w := httptest.NewRecorder()
+
+		mockService.On("UpdateRule", mock.Anything, int32(1), int32(7), mock.Anything).
+			Return(specs.RuleResponse{}, pkgerrors.ErrRuleNotFound).Once()
+
+		body := `{"name":"tag","expression":"txn.amount > 5","action":"ADD_TAG","tag":"big"}`
+		UpdateRule(mockService)(w, newListRequest(t, http.MethodPut, "/api/admin/rules/7", "7", body))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestDeleteRule(t *testing.T) {
+	t.Run("invalid id", func(t *testing.T) {
+		mockService := new(MockRuleService)
+		w := httptest.NewRecorder()
+
+		DeleteRule(mockService)(w, newListRequest(t, http.MethodDelete, "/api/admin/rules/abc", "abc", ""))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "DeleteRule", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/middleware"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/gorilla/mux"
+)
+
+type scoringConfigServiceInterface interface {
+	ActiveConfig(ctx context.Context) *specs.ScoringConfig
+	CreateConfig(ctx context.Context, userID int32, req specs.CreateScoringConfigRequest) (specs.ScoringConfigResponse, error)
+	ActivateConfig(ctx context.Context, version int32) (specs.ScoringConfigResponse, error)
+	ListConfigs(ctx context.Context) ([]specs.ScoringConfigResponse, error)
+	StartShadow(ctx context.Context, version int32) (specs.ScoringConfigResponse, error)
+	StopShadow(ctx context.Context) error
+	ShadowSummary(ctx context.Context, version int32) (specs.ShadowSummaryResponse, error)
+}
+
+// CreateScoringConfig returns an HTTP handler that stores a new, inactive config version
+func CreateScoringConfig(s scoringConfigServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		req, err := decodeCreateScoringConfigRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.CreateConfig(r.Context(), userID, req)
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrInvalidScoringConfig) || errors.Is(err, pkgerrors.ErrUnknownRiskFactor) {
+				middleware.ErrorResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusCreated, res)
+	}
+}
+
+// ActivateScoringConfig returns an HTTP handler that switches scoring to the given version
+func ActivateScoringConfig(s scoringConfigServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		version, err := strconv.ParseInt(mux.Vars(r)["version"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		res, err := s.ActivateConfig(r.Context(), int32(version))
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrScoringConfigNotFound) {
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// GetScoringConfigs returns an HTTP handler that lists every stored config version
+func GetScoringConfigs(s scoringConfigServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := s.ListConfigs(r.Context())
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// GetActiveScoringConfig returns an HTTP handler that shows the config currently used for scoring
+func GetActiveScoringConfig(s scoringConfigServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		middleware.SuccessResponse(w, http.StatusOK, s.ActiveConfig(r.Context()))
+	}
+}
+
+// ShadowScoringConfig returns an HTTP handler that shadow scores live transactions with the given version
+func ShadowScoringConfig(s scoringConfigServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		version, err := strconv.ParseInt(mux.Vars(r)["version"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		res, err := s.StartShadow(r.Context(), int32(version))
+		if err != nil {
+			switch {
+			case errors.Is(err, pkgerrors.ErrScoringConfigNotFound):
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+			case errors.Is(err, pkgerrors.ErrShadowConfigActive):
+				middleware.ErrorResponse(w, http.StatusConflict, err)
+			default:
+				middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// StopShadowScoring returns an HTTP handler that stops shadow scoring
+func StopShadowScoring(s scoringConfigServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.StopShadow(r.Context()); err != nil {
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, map[string]string{
+			"message": "Shadow scoring stopped",
+		})
+	}
+}
+
+// GetShadowSummary returns an HTTP handler that compares a version's shadow decisions with the returned ones
+func GetShadowSummary(s scoringConfigServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		version, err := strconv.ParseInt(mux.Vars(r)["version"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		res, err := s.ShadowSummary(r.Context(), int32(version))
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrScoringConfigNotFound) {
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateScoringConfig(t *testing.T) {
+	t.Run("omitted fields keep defaults", func(t *testing.T) {
+		mockService := new(MockScoringConfigService)
+		handler := CreateScoringConfig(mockService)
+		os.Setenv("JWT_SECRET", "testsecret")
+
+		token, _ := helpers.MakeJWT(1, "Admin", "admin@example.com", "ADMIN", "testsecret", time.Hour)
+		body := `{"description":"stricter flagging","config":{"risk_threshold_flag":50}}`
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/scoring-configs", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		expected := *helpers.DefaultScoringConfig()
+		expected.RiskThresholdFlag = 50
+
+		mockService.On("CreateConfig", mock.Anything, int32(1), specs.CreateScoringConfigRequest{
+			Description: "stricter flagging",
+			Config:      expected,
+		}).Return(specs.ScoringConfigResponse{Version: 2, Config: expected}, nil).Once()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		mockService := new(MockScoringConfigService)
+		handler := CreateScoringConfig(mockService)
+		os.Setenv("JWT_SECRET", "testsecret")
+
+		token, _ := helpers.MakeJWT(1, "Admin", "admin@example.com", "ADMIN", "testsecret", time.Hour)
+		body := `{"config":{"risk_threshold_allow":90}}`
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/scoring-configs", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		mockService.On("CreateConfig", mock.Anything, int32(1), mock.Anything).
+			Return(specs.ScoringConfigResponse{}, pkgerrors.ErrInvalidScoringConfig).Once()
+
+		handler(w, req)
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, pkgerrors.ErrInvalidScoringConfig.Error(), response["error_message"])
+	})
+}
+
+func TestActivateScoringConfig(t *testing.T) {
+	t.Run("invalid version", func(t *testing.T) {
+		mockService := new(MockScoringConfigService)
+		handler := ActivateScoringConfig(mockService)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/scoring-configs/abc/activate", nil)
+		req = mux.SetURLVars(req, map[string]string{"version": "abc"})
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ActivateConfig", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		mockService := new(MockScoringConfigService)
+		handler := ActivateScoringConfig(mockService)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/scoring-configs/7/activate", nil)
+		req = mux.SetURLVars(req, map[string]string{"version": "7"})
+		w := httptest.NewRecorder()
+
+		mockService.On("ActivateConfig", mock.Anything, int32(7)).
+			Return(specs.ScoringConfigResponse{}, pkgerrors.ErrScoringConfigNotFound).Once()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("successful activation", func(t *testing.T) {
+		mockService := new(MockScoringConfigService)
+		handler := ActivateScoringConfig(mockService)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/scoring-configs/3/activate", nil)
+		req = mux.SetURLVars(req, map[string]string{"version": "3"})
+		w := httptest.NewRecorder()
+
+		mockService.On("ActivateConfig", mock.Anything, int32(3)).
+			Return(specs.ScoringConfigResponse{Version: 3, IsActive: true}, nil).Once()
+
+		handler(w, req)
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data := response["data"].(map[string]any)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(3), data["version"])
+		assert.Equal(t, true, data["is_active"])
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestShadowScoringConfig(t *testing.T) {
+	t.Run("active version", func(t *testing.T) {
+		mockService := new(MockScoringConfigService)
+		handler := ShadowScoringConfig(mockService)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/scoring-configs/2/shadow", nil)
+		req = mux.SetURLVars(req, map[string]string{"version": "2"})
+		w := httptest.NewRecorder()
+
+		mockService.On("StartShadow", mock.Anything, int32(2)).
+			Return(specs.ScoringConfigResponse{}, pkgerrors.ErrShadowConfigActive).Once()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("successful shadowing", func(t *testing.T) {
+		mockService := new(MockScoringConfigService)
+		handler := ShadowScoringConfig(mockService)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/scoring-configs/4/shadow", nil)
+		req = mux.SetURLVars(req, map[string]string{"version": "4"})
+		w := httptest.NewRecorder()
+
+		mockService.On("StartShadow", mock.Anything, int32(4)).
+			Return(specs.ScoringConfigResponse{Version: 4, IsShadow: true}, nil).Once()
+
+		handler(w, req)
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data := response["data"].(map[string]any)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, data["is_shadow"])
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetShadowSummary(t *testing.T) {
+	t.Run("unknown version", func(t *testing.T) {
+		mockService := new(MockScoringConfigService)
+		handler := GetShadowSummary(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/scoring-configs/9/shadow", nil)
+		req = mux.SetURLVars(req, map[string]string{"version": "9"})
+		w := httptest.NewRecorder()
+
+		mockService.On("ShadowSummary", mock.Anything, int32(9)).
+			Return(specs.ShadowSummaryResponse{}, pkgerrors.ErrScoringConfigNotFound).Once()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		mockService := new(MockScoringConfigService)
+		handler := GetShadowSummary(mockService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/scoring-configs/4/shadow", nil)
+		req = mux.SetURLVars(req, map[string]string{"version": "4"})
+		w := httptest.NewRecorder()
+
+		mockService.On("ShadowSummary", mock.Anything, int32(4)).
+			Return(specs.ShadowSummaryResponse{Version: 4, Transactions: 10, Changed: 2}, nil).Once()
+
+		handler(w, req)
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data := response["data"].(map[string]any)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(10), data["transactions"])
+		assert.Equal(t, float64(2), data["changed"])
+		mockService.AssertExpectations(t)
+	})
+}
@@ -4,17 +4,21 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 
 	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/middleware"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/gorilla/mux"
 )
 
 type userServiceInterface interface {
 	Signup(ctx context.Context, req specs.UserSignupRequest) (specs.UserSignupResponse, error)
 	Login(ctx context.Context, req specs.UserLoginRequest) (specs.UserLoginResponse, error)
 	Logout(ctx context.Context, claims *specs.UserTokenClaims) error
+	UpdateUserRole(ctx context.Context, userID int32, req specs.UpdateUserRoleRequest) (specs.UserResponse, error)
+	UpdateTimezone(ctx context.Context, userID int32, req specs.UpdateTimezoneRequest) (specs.UserResponse, error)
 }
 
 // Signup returns an HTTP handler that signs up user using DB
@@ -98,3 +102,71 @@ func Logout(s userServiceInterface) func(http.ResponseWriter, *http.Request) {
 		})
 	}
 }
+
+// UpdateUserRole returns an HTTP handler that changes the role of a user
+func UpdateUserRole(s userServiceInterface) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		req, err := decodeUpdateUserRoleRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := req.Validate(); err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.UpdateUserRole(r.Context(), int32(userID), req)
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrUserNotFound) {
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// UpdateTimezone returns an HTTP handler that changes the caller's timezone
+func UpdateTimezone(s userServiceInterface) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := helpers.GetClaimsFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		req, err := decodeUpdateTimezoneRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := req.Validate(); err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.UpdateTimezone(r.Context(), claims.UserID, req)
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrUserNotFound) {
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
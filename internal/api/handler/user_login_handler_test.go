@@ -12,6 +12,8 @@ import (
 	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -274,7 +276,7 @@ func TestLogout(t *testing.T) {
 	os.Setenv("JWT_SECRET", "testsecret")
 
 	t.Run("Success logout", func(t *testing.T) {
-		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
 		req := httptest.NewRequest(http.MethodPost, "/logout", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
@@ -310,3 +312,108 @@ func TestLogout(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	t.Run("invalid role", func(t *testing.T) {
+		mockService := new(MockUserService)
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/users/2/role", bytes.NewBufferString(`{"role":"SUPERUSER"}`))
+		req = mux.SetURLVars(req, map[string]string{"id": "2"})
+		w := httptest.NewRecorder()
+
+		UpdateUserRole(mockService)(w, req)
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, pkgerrors.ErrInvalidRole.Error(), response["error_message"])
+		mockService.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("promote to analyst", func(t *testing.T) {
+		mockService := new(MockUserService)
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/users/2/role", bytes.NewBufferString(`{"role":"analyst"}`))
+		req = mux.SetURLVars(req, map[string]string{"id": "2"})
+		w := httptest.NewRecorder()
+
+		mockService.On("UpdateUserRole", mock.Anything, int32(2), specs.UpdateUserRoleRequest{Role: "ANALYST"}).
+			Return(specs.UserResponse{ID: 2, Role: repository.UserRoleANALYST}, nil).Once()
+
+		UpdateUserRole(mockService)(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockService := new(MockUserService)
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/users/42/role", bytes.NewBufferString(`{"role":"ADMIN"}`))
+		req = mux.SetURLVars(req, map[string]string{"id": "42"})
+		w := httptest.NewRecorder()
+
+		mockService.On("UpdateUserRole", mock.Anything, int32(42), mock.Anything).
+			Return(specs.UserResponse{}, pkgerrors.ErrUserNotFound).Once()
+
+		UpdateUserRole(mockService)(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestUpdateTimezone(t *testing.T) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+
+	t.Run("unknown timezone", func(t *testing.T) {
+		mockService := new(MockUserService)
+		req := httptest.NewRequest(http.MethodPut, "/api/users/me/timezone", bytes.NewBufferString(`{"timezone":"Mars/Olympus"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		UpdateTimezone(mockService)(w, req)
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, pkgerrors.ErrInvalidTimezone.Error(), response["error_message"])
+		mockService.AssertNotCalled(t, "UpdateTimezone", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("server local zone is rejected", func(t *testing.T) {
+		mockService := new(MockUserService)
+		req := httptest.NewRequest(http.MethodPut, "/api/users/me/timezone", bytes.NewBufferString(`{"timezone":"Local"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		UpdateTimezone(mockService)(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("updates the caller", func(t *testing.T) {
+		mockService := new(MockUserService)
+		req := httptest.NewRequest(http.MethodPut, "/api/users/me/timezone", bytes.NewBufferString(`{"timezone":" Asia/Kolkata "}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		mockService.On("UpdateTimezone", mock.Anything, int32(1), specs.UpdateTimezoneRequest{Timezone: "Asia/Kolkata"}).
+			Return(specs.UserResponse{ID: 1, Timezone: "Asia/Kolkata"}, nil).Once()
+
+		UpdateTimezone(mockService)(w, req)
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Asia/Kolkata", response["data"].(map[string]any)["timezone"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		mockService := new(MockUserService)
+		req := httptest.NewRequest(http.MethodPut, "/api/users/me/timezone", bytes.NewBufferString(`{"timezone":"UTC"}`))
+		w := httptest.NewRecorder()
+
+		UpdateTimezone(mockService)(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
@@ -19,12 +22,16 @@ import (
 
 type transactionServiceInterface interface {
 	CreateTransaction(ctx context.Context, userID int32, req specs.CreateTransactionRequest) (specs.CreateTransactionResponse, error)
-	ProcessBulkTransactions(ctx context.Context, userID int32, reader io.Reader, filename string) (specs.BulkProcessResponse, error)
+	EvaluateTransaction(ctx context.Context, userID int32, req specs.CreateTransactionRequest) (specs.FraudAnalysisResult, error)
+	ProcessBulkTransactions(ctx context.Context, userID int32, reader io.Reader, filename string, strict bool) (specs.BulkJobResponse, error)
+	GetBulkJob(ctx context.Context, userID int32, jobID string) (specs.BulkJobResponse, error)
+	ListBulkJobErrors(ctx context.Context, userID int32, jobID string) ([]specs.BulkRowErrorResponse, error)
 }
 
 type repositoryInterface interface {
 	GetAllTransactionsByUserID(ctx context.Context, arg repository.GetAllTransactionsByUserIDParams) ([]repository.Transaction, error)
 	GetTransactionByTxnID(ctx context.Context, arg repository.GetTransactionByTxnIDParams) (repository.Transaction, error)
+	GetTransactionByID(ctx context.Context, id int32) (repository.Transaction, error)
 }
 
 func PostTransaction(s transactionServiceInterface) func(w http.ResponseWriter, r *http.Request) {
@@ -45,6 +52,7 @@ func PostTransaction(s transactionServiceInterface) func(w http.ResponseWriter,
 			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
 			return
 		}
+		txnReq.IdempotencyKey = strings.TrimSpace(r.Header.Get(constants.IdempotencyKeyHeader))
 
 		if err := txnReq.Validate(); err != nil {
 			middleware.ErrorResponse(w, http.StatusBadRequest, err)
@@ -52,6 +60,45 @@ func PostTransaction(s transactionServiceInterface) func(w http.ResponseWriter,
 		}
 
 		res, err := s.CreateTransaction(r.Context(), userID, txnReq)
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrIdempotencyKeyReused) {
+				middleware.ErrorResponse(w, http.StatusUnprocessableEntity, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// EvaluateTransaction returns an HTTP handler that scores a transaction without persisting it
+func EvaluateTransaction(s transactionServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			middleware.ErrorResponse(w, http.StatusMethodNotAllowed, pkgerrors.ErrMethodNotAllowed)
+			return
+		}
+
+		userID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		txnReq, err := decodeCreateTransaction(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+			return
+		}
+
+		if err := txnReq.Validate(); err != nil {
+			middleware.ErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := s.EvaluateTransaction(r.Context(), userID, txnReq)
 		if err != nil {
 			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
 			return
@@ -85,6 +132,27 @@ func GetTransactions(DB repositoryInterface) func(w http.ResponseWriter, r *http
 			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
 			return
 		}
+
+		// analysts and admins may list another user's transactions
+		if u := q.Get("user_id"); u != "" {
+			role, err := helpers.GetRoleFromRequest(r)
+			if err != nil {
+				middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+				return
+			}
+			if !helpers.CanAccessAllUsers(role) {
+				middleware.ErrorResponse(w, http.StatusForbidden, pkgerrors.ErrForbidden)
+				return
+			}
+
+			parsed, err := strconv.ParseInt(u, 10, 32)
+			if err != nil {
+				middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+				return
+			}
+			id = int32(parsed)
+		}
+
 		txns, err := DB.GetAllTransactionsByUserID(r.Context(), repository.GetAllTransactionsByUserIDParams{
 			UserID: id,
 			Limit:  int32(limit),
@@ -126,19 +194,100 @@ func GetTransaction(DB repositoryInterface) func(w http.ResponseWriter, r *http.
 			return
 		}
 
-		txn, err := DB.GetTransactionByTxnID(r.Context(), repository.GetTransactionByTxnIDParams{
-			ID:     int32(txnID),
-			UserID: id,
-		})
+		role, err := helpers.GetRoleFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, pkgerrors.ErrInvalidToken)
+			return
+		}
+
+		internal := helpers.CanAccessAllUsers(role)
+		var txn repository.Transaction
+		if internal {
+			txn, err = DB.GetTransactionByID(r.Context(), int32(txnID))
+		} else {
+			txn, err = DB.GetTransactionByTxnID(r.Context(), repository.GetTransactionByTxnIDParams{
+				ID:     int32(txnID),
+				UserID: id,
+			})
+		}
 		if err != nil {
 			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
 
-		middleware.SuccessResponse(w, http.StatusOK, txn)
+		res, err := mapTransactionToDetail(txn, internal)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		middleware.SuccessResponse(w, http.StatusOK, res)
+	}
+}
+
+// mapTransactionToDetail decodes the stored factor scores and explanation.
+// Device network details, list entries, rules, tags and labels are internal
+// and only mapped for analysts and admins.
+func mapTransactionToDetail(txn repository.Transaction, internal bool) (specs.TransactionDetailResponse, error) {
+	res := specs.TransactionDetailResponse{
+		ID:                txn.ID,
+		UserID:            txn.UserID,
+		Amount:            txn.Amount,
+		Mode:              txn.Mode,
+		RiskScore:         txn.RiskScore,
+		TriggeredFactors:  txn.TriggeredFactors,
+		Decision:          txn.Decision,
+		CreatedAt:         txn.CreatedAt.Time,
+		UpdatedAt:         txn.UpdatedAt.Time,
+		UTCOffsetMinutes:  txn.UtcOffsetMinutes,
+		ExternalReference: txn.ExternalReference.String,
+		DeviceID:          txn.DeviceID.String,
+		PayeeID:           txn.PayeeID.String,
+	}
+	if txn.ConfigVersion.Valid {
+		res.ConfigVersion = &txn.ConfigVersion.Int32
+	}
+	if txn.Latitude.Valid && txn.Longitude.Valid {
+		res.Latitude, res.Longitude = &txn.Latitude.Float64, &txn.Longitude.Float64
+	}
+	if len(txn.FactorScores) > 0 {
+		if err := json.Unmarshal(txn.FactorScores, &res.FactorScores); err != nil {
+			return specs.TransactionDetailResponse{}, err
+		}
+	}
+	if len(txn.Explanation) > 0 {
+		var explanation specs.DecisionExplanation
+		if err := json.Unmarshal(txn.Explanation, &explanation); err != nil {
+			return specs.TransactionDetailResponse{}, err
+		}
+		if !internal {
+			explanation.MatchedRules = []specs.MatchedRule{}
+			explanation.Tags = []string{}
+			explanation.ListEntry = nil
+		}
+		res.Explanation = &explanation
+	}
+
+	if !internal {
+		return res, nil
+	}
+	if txn.IpAddress != nil {
+		res.IPAddress = txn.IpAddress.String()
+	}
+	res.UserAgent = txn.UserAgent.String
+	if txn.ListEntryID.Valid {
+		res.ListEntryID = &txn.ListEntryID.Int32
 	}
+	res.MatchedRules = txn.MatchedRules
+	res.Tags = txn.Tags
+	if txn.FraudLabel.Valid {
+		res.FraudLabel = txn.FraudLabel.FraudLabel
+	}
+	return res, nil
 }
 
+// ProcessBulkTransactions returns an HTTP handler that queues an uploaded file
+// as a bulk job and responds with 202 before any row is processed. Setting the
+// strict form field rejects rows with an invalid created_at.
 func ProcessBulkTransactions(s transactionServiceInterface) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -167,7 +316,16 @@ func ProcessBulkTransactions(s transactionServiceInterface) func(w http.Response
 		}
 		defer file.Close()
 
-		res, err := s.ProcessBulkTransactions(r.Context(), userID, file, header.Filename)
+		strict := false
+		if v := r.FormValue("strict"); v != "" {
+			strict, err = strconv.ParseBool(v)
+			if err != nil {
+				middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidBody)
+				return
+			}
+		}
+
+		res, err := s.ProcessBulkTransactions(r.Context(), userID, file, header.Filename, strict)
 		if err != nil {
 			if errors.Is(err, pkgerrors.ErrUnexpectedHeadersInFile) {
 				middleware.ErrorResponse(w, http.StatusBadRequest, err)
@@ -177,6 +335,76 @@ func ProcessBulkTransactions(s transactionServiceInterface) func(w http.Response
 			return
 		}
 
+		middleware.SuccessResponse(w, http.StatusAccepted, res)
+	}
+}
+
+// GetBulkJobStatus returns an HTTP handler that reports the progress of a bulk job
+func GetBulkJobStatus(s transactionServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		res, err := s.GetBulkJob(r.Context(), userID, mux.Vars(r)["job_id"])
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrBulkJobNotFound) {
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
 		middleware.SuccessResponse(w, http.StatusOK, res)
 	}
 }
+
+// GetBulkJobErrors returns an HTTP handler that reports the rows rejected by a
+// bulk job, as JSON (default) or as a CSV download with ?format=csv
+func GetBulkJobErrors(s transactionServiceInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := helpers.GetIDFromRequest(r)
+		if err != nil {
+			middleware.ErrorResponse(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		format := strings.ToLower(r.URL.Query().Get("format"))
+		if format != "" && format != "json" && format != "csv" {
+			middleware.ErrorResponse(w, http.StatusBadRequest, pkgerrors.ErrInvalidReportFormat)
+			return
+		}
+
+		jobID := mux.Vars(r)["job_id"]
+		res, err := s.ListBulkJobErrors(r.Context(), userID, jobID)
+		if err != nil {
+			if errors.Is(err, pkgerrors.ErrBulkJobNotFound) {
+				middleware.ErrorResponse(w, http.StatusNotFound, err)
+				return
+			}
+			middleware.ErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if format != "csv" {
+			middleware.SuccessResponse(w, http.StatusOK, res)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", jobID+"-errors.csv"))
+		w.WriteHeader(http.StatusOK)
+
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"line", "reason", "message", "amount", "mode", "created_at", "external_reference"})
+		for _, row := range res {
+			values := make([]string, 4)
+			copy(values, row.Values)
+			writer.Write(append([]string{strconv.Itoa(int(row.Line)), string(row.Reason), row.Message}, values...))
+		}
+		writer.Flush()
+	}
+}
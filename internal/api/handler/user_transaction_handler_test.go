@@ -3,17 +3,24 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
 	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
 	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -43,7 +50,7 @@ func TestPostTransaction(t *testing.T) {
 		}
 		reqBody, _ := json.Marshal(txnReq)
 
-		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
 		req := httptest.NewRequest(http.MethodPost, "/api/transaction", bytes.NewBuffer(reqBody))
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
@@ -53,7 +60,7 @@ func TestPostTransaction(t *testing.T) {
 				TransactionID:    1,
 				Decision:         "Allow",
 				RiskScore:        55,
-				TriggeredFactors: []string{string(repository.TriggerFactorsNEWMODE)},
+				TriggeredFactors: []string{constants.TriggerFactorsNEWMODE},
 				CreatedAt:        time.Now(),
 			},
 			nil,
@@ -71,6 +78,69 @@ func TestPostTransaction(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("idempotency key is passed to the service", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		handler := PostTransaction(mockService)
+		os.Setenv("JWT_SECRET", "testsecret")
+
+		reqBody, _ := json.Marshal(specs.CreateTransactionRequest{Amount: 1000, Mode: "UPI"})
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodPost, "/api/transaction", bytes.NewBuffer(reqBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(constants.IdempotencyKeyHeader, " order-42 ")
+		w := httptest.NewRecorder()
+
+		expected := specs.CreateTransactionRequest{Amount: 1000, Mode: "UPI", IdempotencyKey: "order-42"}
+		mockService.On("CreateTransaction", mock.Anything, int32(1), expected).Return(
+			specs.CreateTransactionResponse{TransactionID: 7, Decision: "ALLOW"}, nil,
+		).Once()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("idempotency key reused for a different transaction", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		handler := PostTransaction(mockService)
+		os.Setenv("JWT_SECRET", "testsecret")
+
+		reqBody, _ := json.Marshal(specs.CreateTransactionRequest{Amount: 2000, Mode: "UPI"})
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodPost, "/api/transaction", bytes.NewBuffer(reqBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(constants.IdempotencyKeyHeader, "order-42")
+		w := httptest.NewRecorder()
+
+		mockService.On("CreateTransaction", mock.Anything, int32(1), mock.Anything).Return(
+			specs.CreateTransactionResponse{}, pkgerrors.ErrIdempotencyKeyReused,
+		).Once()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("idempotency key too long", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		handler := PostTransaction(mockService)
+		os.Setenv("JWT_SECRET", "testsecret")
+
+		reqBody, _ := json.Marshal(specs.CreateTransactionRequest{Amount: 1000, Mode: "UPI"})
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodPost, "/api/transaction", bytes.NewBuffer(reqBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(constants.IdempotencyKeyHeader, strings.Repeat("k", 256))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
 	t.Run("unauthorized - no token", func(t *testing.T) {
 		mockService := new(MockTransactionService)
 		handler := PostTransaction(mockService)
@@ -88,7 +158,7 @@ func TestPostTransaction(t *testing.T) {
 		handler := PostTransaction(mockService)
 		os.Setenv("JWT_SECRET", "testsecret")
 
-		token, _ := helpers.MakeJWT(2, "Test User", "test-2@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(2, "Test User", "test-2@example.com", "CUSTOMER", "testsecret", time.Hour)
 		req := httptest.NewRequest(http.MethodPost, "/api/transaction", bytes.NewBufferString("invalid request"))
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
@@ -110,7 +180,7 @@ func TestPostTransaction(t *testing.T) {
 		}
 		reqBody, _ := json.Marshal(txnReq)
 
-		token, _ := helpers.MakeJWT(2, "Test User", "test-2@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(2, "Test User", "test-2@example.com", "CUSTOMER", "testsecret", time.Hour)
 		req := httptest.NewRequest(http.MethodPost, "/api/transaction", bytes.NewBuffer(reqBody))
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
@@ -123,6 +193,251 @@ func TestPostTransaction(t *testing.T) {
 	})
 }
 
+func TestEvaluateTransaction(t *testing.T) {
+	t.Run("unauthorized - no token", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		handler := EvaluateTransaction(mockService)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions/evaluate", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertNotCalled(t, "EvaluateTransaction", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		handler := EvaluateTransaction(mockService)
+		os.Setenv("JWT_SECRET", "testsecret")
+
+		reqBody, _ := json.Marshal(specs.CreateTransactionRequest{Amount: 100, Mode: "CASH"})
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions/evaluate", bytes.NewBuffer(reqBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, pkgerrors.ErrInvalidPaymentMode.Error(), response["error_message"])
+	})
+
+	t.Run("successful evaluation", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		handler := EvaluateTransaction(mockService)
+		os.Setenv("JWT_SECRET", "testsecret")
+
+		txnReq := specs.CreateTransactionRequest{Amount: 5000, Mode: "CARD"}
+		reqBody, _ := json.Marshal(txnReq)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions/evaluate", bytes.NewBuffer(reqBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		mockService.On("EvaluateTransaction", mock.Anything, int32(1), txnReq).Return(specs.FraudAnalysisResult{
+			Decision:          "FLAG",
+			FinalRiskScore:    42,
+			RawRiskScore:      47.5,
+			ProfileConfidence: 20,
+			TriggeredFactors:  []string{constants.TriggerFactorsAMOUNTDEVIATION},
+			Factors: []specs.FactorResult{
+				{Name: constants.TriggerFactorsAMOUNTDEVIATION, Score: 90, Weight: 0.4, Triggered: true},
+			},
+		}, nil).Once()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data := response["data"].(map[string]any)
+		assert.Equal(t, "FLAG", data["decision"])
+		assert.Equal(t, 47.5, data["raw_risk_score"])
+		assert.Equal(t, float64(20), data["profile_confidence"])
+		assert.Len(t, data["factors"], 1)
+		mockService.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything, mock.Anything)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("profile lookup failure", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		handler := EvaluateTransaction(mockService)
+		os.Setenv("JWT_SECRET", "testsecret")
+
+		txnReq := specs.CreateTransactionRequest{Amount: 5000, Mode: "CARD"}
+		reqBody, _ := json.Marshal(txnReq)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions/evaluate", bytes.NewBuffer(reqBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		mockService.On("EvaluateTransaction", mock.Anything, int32(1), txnReq).
+			Return(specs.FraudAnalysisResult{}, errors.New("connection reset")).Once()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetTransactions(t *testing.T) {
+	os.Setenv("JWT_SECRET", "testsecret")
+
+	t.Run("customer sees own transactions", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		mockRepo.On("GetAllTransactionsByUserID", mock.Anything, repository.GetAllTransactionsByUserIDParams{
+			UserID: 1,
+			Limit:  20,
+			Offset: 0,
+		}).Return([]repository.Transaction{{ID: 5, UserID: 1}}, nil).Once()
+
+		GetTransactions(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("customer cannot query another user", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions?user_id=2", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		GetTransactions(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockRepo.AssertNotCalled(t, "GetAllTransactionsByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("analyst queries another user", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		token, _ := helpers.MakeJWT(9, "Analyst", "analyst@example.com", "ANALYST", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions?user_id=2&limit=5", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		mockRepo.On("GetAllTransactionsByUserID", mock.Anything, repository.GetAllTransactionsByUserIDParams{
+			UserID: 2,
+			Limit:  5,
+			Offset: 0,
+		}).Return([]repository.Transaction{{ID: 6, UserID: 2}}, nil).Once()
+
+		GetTransactions(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestGetTransaction(t *testing.T) {
+	os.Setenv("JWT_SECRET", "testsecret")
+
+	t.Run("customer lookup is scoped to the caller", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions/6", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req = mux.SetURLVars(req, map[string]string{"id": "6"})
+		w := httptest.NewRecorder()
+
+		mockRepo.On("GetTransactionByTxnID", mock.Anything, repository.GetTransactionByTxnIDParams{ID: 6, UserID: 1}).
+			Return(repository.Transaction{}, pgx.ErrNoRows).Once()
+
+		GetTransaction(mockRepo)(w, req)
+
+		assert.NotEqual(t, http.StatusOK, w.Code)
+		mockRepo.AssertNotCalled(t, "GetTransactionByID", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	ip := netip.MustParseAddr("203.0.113.7")
+	stored := repository.Transaction{
+		ID:           6,
+		UserID:       1,
+		Amount:       4200,
+		Mode:         repository.ModeUPI,
+		Decision:     repository.TransactionDecisionBLOCK,
+		FactorScores: []byte(`{"AMOUNT_DEVIATION":100}`),
+		DeviceID:     pgtype.Text{String: "phone-1", Valid: true},
+		IpAddress:    &ip,
+		UserAgent:    pgtype.Text{String: "okhttp/4.12", Valid: true},
+		ListEntryID:  pgtype.Int4{Int32: 3, Valid: true},
+		MatchedRules: []int32{4},
+		Tags:         []string{"mule"},
+		FraudLabel:   repository.NullFraudLabel{FraudLabel: repository.FraudLabelCONFIRMEDFRAUD, Valid: true},
+		Explanation:  []byte(`{"reason_codes":["DEVICE_NEW"],"tags":["mule"],"matched_rules":[{"id":4,"name":"mule payees"}]}`),
+	}
+
+	t.Run("customer reads the explanation without internal fields", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions/6", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req = mux.SetURLVars(req, map[string]string{"id": "6"})
+		w := httptest.NewRecorder()
+
+		mockRepo.On("GetTransactionByTxnID", mock.Anything, repository.GetTransactionByTxnIDParams{ID: 6, UserID: 1}).
+			Return(stored, nil).Once()
+
+		GetTransaction(mockRepo)(w, req)
+
+		var response struct {
+			Data map[string]any `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "phone-1", response.Data["device_id"])
+		assert.Equal(t, map[string]any{"AMOUNT_DEVIATION": 100.0}, response.Data["factor_scores"])
+		for _, field := range []string{"ip_address", "user_agent", "list_entry_id", "matched_rules", "tags", "fraud_label"} {
+			assert.NotContains(t, response.Data, field)
+		}
+		explanation := response.Data["explanation"].(map[string]any)
+		assert.Equal(t, []any{"DEVICE_NEW"}, explanation["reason_codes"])
+		assert.Empty(t, explanation["matched_rules"])
+		assert.Empty(t, explanation["tags"])
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("admin reads any transaction", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		token, _ := helpers.MakeJWT(9, "Admin", "admin@example.com", "ADMIN", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions/6", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req = mux.SetURLVars(req, map[string]string{"id": "6"})
+		w := httptest.NewRecorder()
+
+		mockRepo.On("GetTransactionByID", mock.Anything, int32(6)).Return(stored, nil).Once()
+
+		GetTransaction(mockRepo)(w, req)
+
+		var response struct {
+			Data specs.TransactionDetailResponse `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "203.0.113.7", response.Data.IPAddress)
+		assert.Equal(t, "okhttp/4.12", response.Data.UserAgent)
+		assert.Equal(t, int32(3), *response.Data.ListEntryID)
+		assert.Equal(t, []int32{4}, response.Data.MatchedRules)
+		assert.Equal(t, []string{"mule"}, response.Data.Tags)
+		assert.Equal(t, repository.FraudLabelCONFIRMEDFRAUD, response.Data.FraudLabel)
+		assert.Equal(t, []string{"DEVICE_NEW"}, response.Data.Explanation.ReasonCodes)
+		assert.Equal(t, []string{"mule"}, response.Data.Explanation.Tags)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
 func TestProcessBulkTransactions(t *testing.T) {
 	t.Run("invalid request: not allowed method", func(t *testing.T) {
 		mockService := new(MockTransactionService)
@@ -159,7 +474,7 @@ func TestProcessBulkTransactions(t *testing.T) {
 
 		writer.Close()
 
-		token, _ := helpers.MakeJWT(2, "Test User", "test-2@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(2, "Test User", "test-2@example.com", "CUSTOMER", "testsecret", time.Hour)
 		req := httptest.NewRequest(http.MethodPost, "/api/transactions/upload", &body)
 		req.Header.Set("Authorization", "Bearer "+token)
 		req.Header.Set("Content-Type", writer.FormDataContentType())
@@ -192,7 +507,7 @@ func TestProcessBulkTransactions(t *testing.T) {
 
 		writer.Close()
 
-		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
 
 		req := httptest.NewRequest(http.MethodPost, "/api/transactions/upload", &body)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -226,7 +541,7 @@ func TestProcessBulkTransactions(t *testing.T) {
 
 		writer.Close()
 
-		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
 
 		req := httptest.NewRequest(http.MethodPost, "/api/transactions/upload", &body)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -234,7 +549,7 @@ func TestProcessBulkTransactions(t *testing.T) {
 
 		w := httptest.NewRecorder()
 
-		mockService.On("ProcessBulkTransactions", mock.Anything, int32(1), mock.Anything, "test.csv").Return(specs.BulkProcessResponse{}, pkgerrors.ErrUnexpectedHeadersInFile).Once()
+		mockService.On("ProcessBulkTransactions", mock.Anything, int32(1), mock.Anything, "test.csv", false).Return(specs.BulkJobResponse{}, pkgerrors.ErrUnexpectedHeadersInFile).Once()
 
 		handler(w, req)
 
@@ -262,7 +577,7 @@ func TestProcessBulkTransactions(t *testing.T) {
 
 		writer.Close()
 
-		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
 
 		req := httptest.NewRequest(http.MethodPost, "/api/transactions/upload", &body)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -270,26 +585,202 @@ func TestProcessBulkTransactions(t *testing.T) {
 
 		w := httptest.NewRecorder()
 
-		mockService.On("ProcessBulkTransactions", mock.Anything, int32(1), mock.Anything, "test.csv").Return(specs.BulkProcessResponse{
-			JobID:     "1",
-			Status:    "success",
-			Processed: 1,
-			Success:   1,
-			Failed:    0,
+		mockService.On("ProcessBulkTransactions", mock.Anything, int32(1), mock.Anything, "test.csv", false).Return(specs.BulkJobResponse{
+			JobID:    "811d11d8-1f9b-444d-a8c0-06f9b2c0220f",
+			Filename: "test.csv",
+			Status:   repository.BulkJobStatusPENDING,
+			Progress: specs.BulkJobProgress{Total: 1},
 		}, nil).Once()
 
 		handler(w, req)
 
+		var response map[string]any
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		data := response["data"].(map[string]any)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "811d11d8-1f9b-444d-a8c0-06f9b2c0220f", data["job_id"])
+		assert.Equal(t, "PENDING", data["status"])
+		progress := data["progress"].(map[string]any)
+		assert.Equal(t, float64(1), progress["total"])
+		assert.Equal(t, float64(0), progress["processed"])
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetBulkJobStatus(t *testing.T) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	jobID := "811d11d8-1f9b-444d-a8c0-06f9b2c0220f"
+
+	newRequest := func(userID int32) *http.Request {
+		token, _ := helpers.MakeJWT(userID, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions/upload/"+jobID, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return mux.SetURLVars(req, map[string]string{"job_id": jobID})
+	}
+
+	t.Run("invalid request: unauthorized", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions/upload/"+jobID, nil)
+		w := httptest.NewRecorder()
+
+		GetBulkJobStatus(mockService)(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertNotCalled(t, "GetBulkJob", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("job not found", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		mockService.On("GetBulkJob", mock.Anything, int32(2), jobID).Return(specs.BulkJobResponse{}, pkgerrors.ErrBulkJobNotFound).Once()
+		w := httptest.NewRecorder()
+
+		GetBulkJobStatus(mockService)(w, newRequest(2))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("successful request", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		mockService.On("GetBulkJob", mock.Anything, int32(1), jobID).Return(specs.BulkJobResponse{
+			JobID:  jobID,
+			Status: repository.BulkJobStatusPROCESSING,
+			Progress: specs.BulkJobProgress{
+				Total:     1000,
+				Processed: 400,
+				Success:   398,
+				Failed:    2,
+				Percent:   40,
+			},
+		}, nil).Once()
+		w := httptest.NewRecorder()
+
+		GetBulkJobStatus(mockService)(w, newRequest(1))
+
 		var response map[string]any
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		data := response["data"].(map[string]any)
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "1", data["job_id"])
-		assert.Equal(t, "success", data["status"])
-		assert.Equal(t, float64(1), data["processed"])
-		assert.Equal(t, float64(1), data["success"])
-		assert.Equal(t, float64(0), data["failed"])
+		assert.Equal(t, "PROCESSING", data["status"])
+		progress := data["progress"].(map[string]any)
+		assert.Equal(t, float64(400), progress["processed"])
+		assert.Equal(t, float64(398), progress["success"])
+		assert.Equal(t, float64(2), progress["failed"])
+		assert.Equal(t, float64(40), progress["percent"])
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestProcessBulkTransactionsStrict(t *testing.T) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+
+	newRequest := func(strict string) *http.Request {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		fileWriter, _ := writer.CreateFormFile("file", "test.csv")
+		fileWriter.Write([]byte("amount,mode,created_at\n1000,UPI,2025-10-23T22:05:19Z"))
+		writer.WriteField("strict", strict)
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions/upload", &body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req
+	}
+
+	t.Run("strict flag is passed to the service", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		mockService.On("ProcessBulkTransactions", mock.Anything, int32(1), mock.Anything, "test.csv", true).Return(specs.BulkJobResponse{
+			Status: repository.BulkJobStatusPENDING,
+			Strict: true,
+		}, nil).Once()
+		w := httptest.NewRecorder()
+
+		ProcessBulkTransactions(mockService)(w, newRequest("true"))
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid strict flag", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		w := httptest.NewRecorder()
+
+		ProcessBulkTransactions(mockService)(w, newRequest("sometimes"))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ProcessBulkTransactions", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetBulkJobErrors(t *testing.T) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	jobID := "811d11d8-1f9b-444d-a8c0-06f9b2c0220f"
+	rowErrors := []specs.BulkRowErrorResponse{
+		{Line: 3, Values: []string{"abc", "UPI", "2023-10-01T10:00:00Z", "order-3"}, Reason: repository.BulkRowErrorReasonINVALIDAMOUNT, Message: pkgerrors.ErrBulkRowInvalidAmount.Error()},
+		{Line: 7, Values: []string{"100", "UPI"}, Reason: repository.BulkRowErrorReasonMALFORMEDROW, Message: pkgerrors.ErrBulkRowMalformed.Error()},
+	}
+
+	newRequest := func(query string) *http.Request {
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions/upload/"+jobID+"/errors"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return mux.SetURLVars(req, map[string]string{"job_id": jobID})
+	}
+
+	t.Run("json report", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		mockService.On("ListBulkJobErrors", mock.Anything, int32(1), jobID).Return(rowErrors, nil).Once()
+		w := httptest.NewRecorder()
+
+		GetBulkJobErrors(mockService)(w, newRequest(""))
+
+		var response map[string]any
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].([]any)
+		assert.Len(t, data, 2)
+		assert.Equal(t, "INVALID_AMOUNT", data[0].(map[string]any)["reason"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("csv report", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		mockService.On("ListBulkJobErrors", mock.Anything, int32(1), jobID).Return(rowErrors, nil).Once()
+		w := httptest.NewRecorder()
+
+		GetBulkJobErrors(mockService)(w, newRequest("?format=csv"))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Equal(t, "line,reason,message,amount,mode,created_at,external_reference\n"+
+			"3,INVALID_AMOUNT,amount should be a positive number,abc,UPI,2023-10-01T10:00:00Z,order-3\n"+
+			"7,MALFORMED_ROW,\"row should have amount, mode and created_at columns\",100,UPI,,\n", w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		w := httptest.NewRecorder()
+
+		GetBulkJobErrors(mockService)(w, newRequest("?format=xml"))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ListBulkJobErrors", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("job not found", func(t *testing.T) {
+		mockService := new(MockTransactionService)
+		mockService.On("ListBulkJobErrors", mock.Anything, int32(1), jobID).Return([]specs.BulkRowErrorResponse(nil), pkgerrors.ErrBulkJobNotFound).Once()
+		w := httptest.NewRecorder()
+
+		GetBulkJobErrors(mockService)(w, newRequest(""))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
 		mockService.AssertExpectations(t)
 	})
 }
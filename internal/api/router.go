@@ -12,7 +12,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewRouter(DB *repository.Queries, RD *redis.Client, txnService *service.TransactionService, userService *service.UserService, logger *zap.Logger) *mux.Router {
+func NewRouter(DB *repository.Queries, RD *redis.Client, txnService *service.TransactionService, userService *service.UserService, configService *service.ScoringConfigService, mfaService *service.MFAService, caseService *service.CaseService, listService *service.ListService, ruleService *service.RuleService, logger *zap.Logger) *mux.Router {
 	router := mux.NewRouter()
 
 	// user registration/login routes
@@ -26,11 +26,45 @@ func NewRouter(DB *repository.Queries, RD *redis.Client, txnService *service.Tra
 
 	// Transaction routes
 	protected.HandleFunc("/transactions", handler.PostTransaction(txnService)).Methods(http.MethodPost)
+	protected.HandleFunc("/transactions/evaluate", handler.EvaluateTransaction(txnService)).Methods(http.MethodPost)
 	protected.HandleFunc("/transactions", handler.GetTransactions(DB)).Methods(http.MethodGet)
 	protected.HandleFunc("/transactions/{id}", handler.GetTransaction(DB)).Methods(http.MethodGet)
+	protected.HandleFunc("/transactions/{id}/mfa", handler.VerifyTransactionMFA(mfaService)).Methods(http.MethodPost)
 
 	// bulk ingestion handlers
 	protected.HandleFunc("/transactions/upload", handler.ProcessBulkTransactions(txnService)).Methods(http.MethodPost)
+	protected.HandleFunc("/transactions/upload/{job_id}", handler.GetBulkJobStatus(txnService)).Methods(http.MethodGet)
+	protected.HandleFunc("/transactions/upload/{job_id}/errors", handler.GetBulkJobErrors(txnService)).Methods(http.MethodGet)
+
+	// fraud case review routes, for analysts and admins
+	cases := protected.PathPrefix("/cases").Subrouter()
+	cases.Use(middleware.RequireRole(repository.UserRoleANALYST, repository.UserRoleADMIN))
+	cases.HandleFunc("", handler.GetCases(caseService)).Methods(http.MethodGet)
+	cases.HandleFunc("/{id}", handler.GetCase(caseService)).Methods(http.MethodGet)
+	cases.HandleFunc("/{id}/assign", handler.AssignCase(caseService)).Methods(http.MethodPost)
+	cases.HandleFunc("/{id}/resolve", handler.ResolveCase(caseService)).Methods(http.MethodPost)
+
+	// admin routes
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireRole(repository.UserRoleADMIN))
+	admin.HandleFunc("/scoring-configs", handler.CreateScoringConfig(configService)).Methods(http.MethodPost)
+	admin.HandleFunc("/scoring-configs", handler.GetScoringConfigs(configService)).Methods(http.MethodGet)
+	admin.HandleFunc("/scoring-configs/active", handler.GetActiveScoringConfig(configService)).Methods(http.MethodGet)
+	admin.HandleFunc("/scoring-configs/{version}/activate", handler.ActivateScoringConfig(configService)).Methods(http.MethodPost)
+	admin.HandleFunc("/scoring-configs/shadow", handler.StopShadowScoring(configService)).Methods(http.MethodDelete)
+	admin.HandleFunc("/scoring-configs/{version}/shadow", handler.ShadowScoringConfig(configService)).Methods(http.MethodPost)
+	admin.HandleFunc("/scoring-configs/{version}/shadow", handler.GetShadowSummary(configService)).Methods(http.MethodGet)
+	admin.HandleFunc("/users/{id}/role", handler.UpdateUserRole(userService)).Methods(http.MethodPut)
+	admin.HandleFunc("/lists", handler.CreateListEntry(listService)).Methods(http.MethodPost)
+	admin.HandleFunc("/lists", handler.GetListEntries(listService)).Methods(http.MethodGet)
+	admin.HandleFunc("/lists/{id}", handler.DeleteListEntry(listService)).Methods(http.MethodDelete)
+	admin.HandleFunc("/rules", handler.CreateRule(ruleService)).Methods(http.MethodPost)
+	admin.HandleFunc("/rules", handler.GetRules(ruleService)).Methods(http.MethodGet)
+	admin.HandleFunc("/rules/{id}", handler.UpdateRule(ruleService)).Methods(http.MethodPut)
+	admin.HandleFunc("/rules/{id}", handler.DeleteRule(ruleService)).Methods(http.MethodDelete)
+
+	// user settings
+	protected.HandleFunc("/users/me/timezone", handler.UpdateTimezone(userService)).Methods(http.MethodPut)
 
 	// logout handler
 	protected.HandleFunc("/logout", handler.Logout(userService)).Methods(http.MethodPost)
@@ -0,0 +1,51 @@
+-- +goose Up
+ALTER TABLE transactions ADD COLUMN factor_scores JSONB NOT NULL DEFAULT '{}';
+
+UPDATE transactions SET factor_scores = jsonb_build_object(
+  'AMOUNT_DEVIATION', amount_deviation_score,
+  'FREQUENCY_SPIKE', frequency_deviation_score,
+  'NEW_MODE', mode_deviation_score,
+  'TIME_ANOMALY', time_deviation_score
+);
+
+ALTER TABLE transactions ALTER COLUMN triggered_factors DROP DEFAULT;
+ALTER TABLE transactions ALTER COLUMN triggered_factors TYPE TEXT[] USING triggered_factors::TEXT[];
+ALTER TABLE transactions ALTER COLUMN triggered_factors SET DEFAULT '{}';
+
+ALTER TABLE transactions
+  DROP COLUMN amount_deviation_score,
+  DROP COLUMN frequency_deviation_score,
+  DROP COLUMN mode_deviation_score,
+  DROP COLUMN time_deviation_score;
+
+DROP TYPE trigger_factors;
+
+-- +goose Down
+CREATE TYPE trigger_factors AS ENUM (
+  'AMOUNT_DEVIATION',
+  'FREQUENCY_SPIKE',
+  'NEW_MODE',
+  'TIME_ANOMALY'
+);
+
+ALTER TABLE transactions
+  ADD COLUMN amount_deviation_score INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN frequency_deviation_score INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN mode_deviation_score INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN time_deviation_score INTEGER NOT NULL DEFAULT 0;
+
+UPDATE transactions SET
+  amount_deviation_score = COALESCE((factor_scores->>'AMOUNT_DEVIATION')::NUMERIC, 0)::INTEGER,
+  frequency_deviation_score = COALESCE((factor_scores->>'FREQUENCY_SPIKE')::NUMERIC, 0)::INTEGER,
+  mode_deviation_score = COALESCE((factor_scores->>'NEW_MODE')::NUMERIC, 0)::INTEGER,
+  time_deviation_score = COALESCE((factor_scores->>'TIME_ANOMALY')::NUMERIC, 0)::INTEGER,
+  triggered_factors = ARRAY(
+    SELECT f FROM unnest(triggered_factors) AS f
+    WHERE f IN ('AMOUNT_DEVIATION', 'FREQUENCY_SPIKE', 'NEW_MODE', 'TIME_ANOMALY')
+  );
+
+ALTER TABLE transactions ALTER COLUMN triggered_factors DROP DEFAULT;
+ALTER TABLE transactions ALTER COLUMN triggered_factors TYPE trigger_factors[] USING triggered_factors::trigger_factors[];
+ALTER TABLE transactions ALTER COLUMN triggered_factors SET DEFAULT '{}';
+
+ALTER TABLE transactions DROP COLUMN factor_scores;
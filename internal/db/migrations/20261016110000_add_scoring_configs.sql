@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE scoring_configs (
+  version SERIAL PRIMARY KEY,
+  description TEXT NOT NULL DEFAULT '',
+  config JSONB NOT NULL,
+  is_active BOOLEAN NOT NULL DEFAULT FALSE,
+  created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  activated_at TIMESTAMP
+);
+
+-- at most one version can be active at a time
+CREATE UNIQUE INDEX scoring_configs_single_active ON scoring_configs (is_active) WHERE is_active;
+
+ALTER TABLE transactions ADD COLUMN config_version INTEGER REFERENCES scoring_configs(version);
+
+-- +goose Down
+ALTER TABLE transactions DROP COLUMN config_version;
+
+DROP TABLE IF EXISTS scoring_configs;
@@ -0,0 +1,77 @@
+-- +goose Up
+CREATE TYPE case_status AS ENUM (
+  'OPEN',
+  'ASSIGNED',
+  'RESOLVED'
+);
+
+CREATE TYPE fraud_label AS ENUM (
+  'CONFIRMED_FRAUD',
+  'FALSE_POSITIVE'
+);
+
+-- analyst verdict on the transaction, written back when its case is resolved
+ALTER TABLE transactions ADD COLUMN fraud_label fraud_label;
+
+-- whether the transaction counts as legitimate behaviour when building profiles:
+-- analyst labels take precedence over the decision made at scoring time
+ALTER TABLE transactions ADD COLUMN is_legitimate BOOLEAN NOT NULL GENERATED ALWAYS AS (
+  CASE
+    WHEN fraud_label = 'CONFIRMED_FRAUD' THEN FALSE
+    WHEN fraud_label = 'FALSE_POSITIVE' THEN TRUE
+    ELSE decision IN ('ALLOW', 'FLAG')
+  END
+) STORED;
+
+CREATE TABLE fraud_cases (
+  id SERIAL PRIMARY KEY,
+  transaction_id INTEGER UNIQUE NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status case_status NOT NULL DEFAULT 'OPEN',
+  assigned_to INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  disposition fraud_label,
+  notes TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  resolved_at TIMESTAMP
+);
+
+CREATE INDEX fraud_cases_status_idx ON fraud_cases (status, created_at);
+
+CREATE TABLE fraud_case_events (
+  id SERIAL PRIMARY KEY,
+  case_id INTEGER NOT NULL REFERENCES fraud_cases(id) ON DELETE CASCADE,
+  actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  from_status case_status,
+  to_status case_status NOT NULL,
+  assigned_to INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  disposition fraud_label,
+  notes TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX fraud_case_events_case_idx ON fraud_case_events (case_id, created_at);
+
+-- open a case for every transaction already waiting for review
+WITH new_cases AS (
+  INSERT INTO fraud_cases (transaction_id, user_id, created_at, updated_at)
+  SELECT id, user_id, created_at, created_at
+  FROM transactions
+  WHERE decision IN ('FLAG', 'BLOCK')
+  RETURNING id, created_at
+)
+INSERT INTO fraud_case_events (case_id, to_status, notes, created_at)
+SELECT id, 'OPEN', 'backfilled', created_at FROM new_cases;
+
+-- +goose Down
+DROP TABLE IF EXISTS fraud_case_events;
+
+DROP TABLE IF EXISTS fraud_cases;
+
+ALTER TABLE transactions DROP COLUMN is_legitimate;
+
+ALTER TABLE transactions DROP COLUMN fraud_label;
+
+DROP TYPE IF EXISTS fraud_label;
+
+DROP TYPE IF EXISTS case_status;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TYPE user_role AS ENUM (
+  'CUSTOMER',
+  'ANALYST',
+  'ADMIN'
+);
+
+ALTER TABLE users ADD COLUMN role user_role NOT NULL DEFAULT 'CUSTOMER';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN role;
+
+DROP TYPE IF EXISTS user_role;
@@ -0,0 +1,35 @@
+-- +goose Up
+CREATE TYPE bulk_job_status AS ENUM (
+  'PENDING',
+  'PROCESSING',
+  'COMPLETED',
+  'FAILED'
+);
+
+-- uploaded bulk files, processed in the background by the job workers.
+-- processed_rows doubles as the resume cursor: it is advanced in the same DB
+-- transaction as each row's insert, so a restarted job skips exactly the rows
+-- that were already stored
+CREATE TABLE bulk_jobs (
+  id UUID PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  filename TEXT NOT NULL,
+  file BYTEA NOT NULL,
+  status bulk_job_status NOT NULL DEFAULT 'PENDING',
+  total_rows INTEGER NOT NULL DEFAULT 0,
+  processed_rows INTEGER NOT NULL DEFAULT 0,
+  success_rows INTEGER NOT NULL DEFAULT 0,
+  failed_rows INTEGER NOT NULL DEFAULT 0,
+  error TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMP,
+  heartbeat_at TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX bulk_jobs_status_idx ON bulk_jobs (status, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS bulk_jobs;
+
+DROP TYPE IF EXISTS bulk_job_status;
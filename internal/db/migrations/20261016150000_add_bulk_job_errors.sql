@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TYPE bulk_row_error_reason AS ENUM (
+  'MALFORMED_ROW',
+  'INVALID_AMOUNT',
+  'INVALID_MODE',
+  'INVALID_TIMESTAMP',
+  'INSERT_FAILED'
+);
+
+-- strict jobs reject rows with an unparseable created_at instead of
+-- defaulting it to the processing time
+ALTER TABLE bulk_jobs ADD COLUMN strict BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- rows rejected by a bulk job, one per failed row. line_number counts the
+-- header as line 1
+CREATE TABLE bulk_job_errors (
+  id SERIAL PRIMARY KEY,
+  job_id UUID NOT NULL REFERENCES bulk_jobs(id) ON DELETE CASCADE,
+  line_number INTEGER NOT NULL,
+  raw_values TEXT[] NOT NULL,
+  reason bulk_row_error_reason NOT NULL,
+  message TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX bulk_job_errors_job_idx ON bulk_job_errors (job_id, line_number);
+
+-- +goose Down
+DROP TABLE IF EXISTS bulk_job_errors;
+
+ALTER TABLE bulk_jobs DROP COLUMN strict;
+
+DROP TYPE IF EXISTS bulk_row_error_reason;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- client supplied reference: the Idempotency-Key of POST /api/transactions or
+-- the external_reference column of a bulk upload. A reference can only be
+-- used once per user, so retried requests and re-uploaded rows are not stored twice
+ALTER TABLE transactions ADD COLUMN external_reference TEXT;
+
+ALTER TABLE transactions
+  ADD CONSTRAINT transactions_user_external_reference_key UNIQUE (user_id, external_reference);
+
+ALTER TYPE bulk_row_error_reason ADD VALUE 'DUPLICATE_REFERENCE';
+
+-- +goose Down
+ALTER TABLE transactions DROP CONSTRAINT transactions_user_external_reference_key;
+
+ALTER TABLE transactions DROP COLUMN external_reference;
+
+-- enum values cannot be dropped; DUPLICATE_REFERENCE stays in bulk_row_error_reason
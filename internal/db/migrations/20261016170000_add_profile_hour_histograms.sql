@@ -0,0 +1,62 @@
+-- +goose Up
+-- time-decayed histograms of legitimate transactions by hour of day (24 buckets)
+-- and by weekday and hour (7x24 buckets, Sunday first). Weights halve every
+-- 30 days and are relative to histogram_decayed_at.
+ALTER TABLE user_profile_behavior
+  ADD COLUMN hour_histogram DOUBLE PRECISION[] NOT NULL DEFAULT array_fill(0::DOUBLE PRECISION, ARRAY[24]),
+  ADD COLUMN weekday_hour_histogram DOUBLE PRECISION[] NOT NULL DEFAULT array_fill(0::DOUBLE PRECISION, ARRAY[168]),
+  ADD COLUMN histogram_decayed_at TIMESTAMP;
+
+-- +goose StatementBegin
+CREATE FUNCTION profile_hour_histogram(p_user_id INTEGER, p_as_of TIMESTAMP, p_buckets INTEGER)
+RETURNS DOUBLE PRECISION[] AS $$
+    SELECT ARRAY_AGG(COALESCE(w.weight, 0) ORDER BY b.bucket)
+    FROM generate_series(0, p_buckets - 1) AS b(bucket)
+    LEFT JOIN (
+        SELECT
+            CASE WHEN p_buckets = 168
+                THEN EXTRACT(DOW FROM t.created_at)::INTEGER * 24 + EXTRACT(HOUR FROM t.created_at)::INTEGER
+                ELSE EXTRACT(HOUR FROM t.created_at)::INTEGER
+            END AS bucket,
+            -- 30 day half-life, see constants.HourHistogramHalfLife
+            SUM(POWER(0.5, EXTRACT(EPOCH FROM p_as_of - t.created_at) / 2592000)) AS weight
+        FROM transactions t
+        WHERE t.user_id = p_user_id
+        AND t.is_legitimate
+        AND t.created_at <= p_as_of
+        GROUP BY 1
+    ) w ON w.bucket = b.bucket;
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+UPDATE user_profile_behavior SET
+    hour_histogram = profile_hour_histogram(user_id, NOW()::TIMESTAMP, 24),
+    weekday_hour_histogram = profile_hour_histogram(user_id, NOW()::TIMESTAMP, 168),
+    histogram_decayed_at = NOW();
+
+ALTER TABLE user_profile_behavior
+  DROP COLUMN usual_transaction_start_hour,
+  DROP COLUMN usual_transaction_end_hour;
+
+-- +goose Down
+ALTER TABLE user_profile_behavior
+  ADD COLUMN usual_transaction_start_hour TIMESTAMP,
+  ADD COLUMN usual_transaction_end_hour TIMESTAMP;
+
+UPDATE user_profile_behavior p SET
+    usual_transaction_start_hour = t.start_hour,
+    usual_transaction_end_hour = t.end_hour
+FROM (
+    SELECT user_id, MIN(created_at) AS start_hour, MAX(created_at) AS end_hour
+    FROM transactions
+    WHERE is_legitimate
+    GROUP BY user_id
+) t
+WHERE p.user_id = t.user_id;
+
+DROP FUNCTION profile_hour_histogram(INTEGER, TIMESTAMP, INTEGER);
+
+ALTER TABLE user_profile_behavior
+  DROP COLUMN hour_histogram,
+  DROP COLUMN weekday_hour_histogram,
+  DROP COLUMN histogram_decayed_at;
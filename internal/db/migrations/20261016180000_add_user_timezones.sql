@@ -0,0 +1,76 @@
+-- +goose Up
+-- IANA timezone the user's activity is scored in, e.g. Asia/Kolkata
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- created_at holds UTC; utc_offset_minutes is the offset of the client's local
+-- time when the transaction was made, either sent by the client or taken from
+-- the user's timezone.
+ALTER TABLE transactions ADD COLUMN utc_offset_minutes INTEGER NOT NULL DEFAULT 0;
+
+-- Existing rows hold the local time of the server that stored them. Name its
+-- timezone in the fraud.legacy_timezone setting when migrating, e.g.
+--   PGOPTIONS='-c fraud.legacy_timezone=Asia/Kolkata' make migrationsUp
+-- Without the setting the server is assumed to have run in UTC. The rows move
+-- to UTC with the server's offset at the time, and existing users are scored
+-- in the server's timezone, as they were before.
+UPDATE transactions SET
+    created_at = (created_at AT TIME ZONE l.tz) AT TIME ZONE 'UTC',
+    utc_offset_minutes = EXTRACT(EPOCH FROM created_at - (created_at AT TIME ZONE l.tz) AT TIME ZONE 'UTC')::INTEGER / 60
+FROM (SELECT COALESCE(NULLIF(current_setting('fraud.legacy_timezone', TRUE), ''), 'UTC') AS tz) l;
+
+UPDATE users SET timezone = COALESCE(NULLIF(current_setting('fraud.legacy_timezone', TRUE), ''), 'UTC');
+
+-- bucket transactions by the client's local hour instead of the UTC one
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION profile_hour_histogram(p_user_id INTEGER, p_as_of TIMESTAMP, p_buckets INTEGER)
+RETURNS DOUBLE PRECISION[] AS $$
+    SELECT ARRAY_AGG(COALESCE(w.weight, 0) ORDER BY b.bucket)
+    FROM generate_series(0, p_buckets - 1) AS b(bucket)
+    LEFT JOIN (
+        SELECT
+            CASE WHEN p_buckets = 168
+                THEN EXTRACT(DOW FROM l.local_at)::INTEGER * 24 + EXTRACT(HOUR FROM l.local_at)::INTEGER
+                ELSE EXTRACT(HOUR FROM l.local_at)::INTEGER
+            END AS bucket,
+            -- 30 day half-life, see constants.HourHistogramHalfLife
+            SUM(POWER(0.5, EXTRACT(EPOCH FROM p_as_of - l.created_at) / 2592000)) AS weight
+        FROM (
+            SELECT t.created_at, t.created_at + t.utc_offset_minutes * INTERVAL '1 minute' AS local_at
+            FROM transactions t
+            WHERE t.user_id = p_user_id
+            AND t.is_legitimate
+            AND t.created_at <= p_as_of
+        ) l
+        GROUP BY 1
+    ) w ON w.bucket = b.bucket;
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION profile_hour_histogram(p_user_id INTEGER, p_as_of TIMESTAMP, p_buckets INTEGER)
+RETURNS DOUBLE PRECISION[] AS $$
+    SELECT ARRAY_AGG(COALESCE(w.weight, 0) ORDER BY b.bucket)
+    FROM generate_series(0, p_buckets - 1) AS b(bucket)
+    LEFT JOIN (
+        SELECT
+            CASE WHEN p_buckets = 168
+                THEN EXTRACT(DOW FROM t.created_at)::INTEGER * 24 + EXTRACT(HOUR FROM t.created_at)::INTEGER
+                ELSE EXTRACT(HOUR FROM t.created_at)::INTEGER
+            END AS bucket,
+            SUM(POWER(0.5, EXTRACT(EPOCH FROM p_as_of - t.created_at) / 2592000)) AS weight
+        FROM transactions t
+        WHERE t.user_id = p_user_id
+        AND t.is_legitimate
+        AND t.created_at <= p_as_of
+        GROUP BY 1
+    ) w ON w.bucket = b.bucket;
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- back to the local time of the server
+UPDATE transactions SET created_at = created_at + utc_offset_minutes * INTERVAL '1 minute';
+
+ALTER TABLE transactions DROP COLUMN utc_offset_minutes;
+
+ALTER TABLE users DROP COLUMN timezone;
@@ -0,0 +1,84 @@
+-- +goose Up
+-- keep the standard deviation at full precision, like the average
+ALTER TABLE user_profile_behavior
+  ALTER COLUMN std_dev_transaction_amount TYPE DOUBLE PRECISION;
+
+-- amount distribution of legitimate transactions, overall and per mode:
+-- {"count", "mean", "std_dev", "median", "mad", "p90", "p99"}. mode_amount_stats
+-- is keyed by mode and only holds the modes the user paid with.
+ALTER TABLE user_profile_behavior
+  ADD COLUMN amount_stats JSONB NOT NULL DEFAULT '{}',
+  ADD COLUMN mode_amount_stats JSONB NOT NULL DEFAULT '{}';
+
+-- amount distribution of a user's legitimate transactions before p_until, for
+-- one mode or for all of them when p_mode is NULL. '{}' without transactions.
+-- +goose StatementBegin
+CREATE FUNCTION profile_amount_stats(p_user_id INTEGER, p_mode mode, p_until TIMESTAMP)
+RETURNS JSONB AS $$
+    WITH amounts AS (
+        SELECT t.amount
+        FROM transactions t
+        WHERE t.user_id = p_user_id
+        AND t.is_legitimate
+        AND t.created_at < p_until
+        AND (p_mode IS NULL OR t.mode = p_mode)
+    ),
+    median AS (
+        SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY amount) AS median
+        FROM amounts
+    )
+    SELECT COALESCE(
+        (
+            SELECT jsonb_build_object(
+                'count', COUNT(*),
+                'mean', AVG(a.amount),
+                'std_dev', COALESCE(STDDEV(a.amount), 0),
+                'median', m.median,
+                'mad', (
+                    SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY ABS(amount - m.median))
+                    FROM amounts
+                ),
+                'p90', percentile_cont(0.9) WITHIN GROUP (ORDER BY a.amount),
+                'p99', percentile_cont(0.99) WITHIN GROUP (ORDER BY a.amount)
+            )
+            FROM amounts a
+            CROSS JOIN median m
+            GROUP BY m.median
+        ),
+        '{}'::JSONB
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION profile_mode_amount_stats(p_user_id INTEGER, p_until TIMESTAMP)
+RETURNS JSONB AS $$
+    SELECT COALESCE(
+        jsonb_object_agg(m.mode, profile_amount_stats(p_user_id, m.mode, p_until)),
+        '{}'::JSONB
+    )
+    FROM (
+        SELECT DISTINCT t.mode
+        FROM transactions t
+        WHERE t.user_id = p_user_id
+        AND t.is_legitimate
+        AND t.created_at < p_until
+    ) m;
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+UPDATE user_profile_behavior SET
+    amount_stats = profile_amount_stats(user_id, NULL, 'infinity'),
+    mode_amount_stats = profile_mode_amount_stats(user_id, 'infinity');
+
+-- +goose Down
+DROP FUNCTION profile_mode_amount_stats(INTEGER, TIMESTAMP);
+
+DROP FUNCTION profile_amount_stats(INTEGER, mode, TIMESTAMP);
+
+ALTER TABLE user_profile_behavior
+  DROP COLUMN amount_stats,
+  DROP COLUMN mode_amount_stats;
+
+ALTER TABLE user_profile_behavior
+  ALTER COLUMN std_dev_transaction_amount TYPE INTEGER USING std_dev_transaction_amount::INTEGER;
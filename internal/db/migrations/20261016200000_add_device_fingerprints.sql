@@ -0,0 +1,27 @@
+-- +goose Up
+-- optional fingerprint of the client that made the transaction
+ALTER TABLE transactions
+  ADD COLUMN device_id TEXT,
+  ADD COLUMN ip_address INET,
+  ADD COLUMN user_agent TEXT;
+
+-- devices a user made legitimate transactions from; a transaction from any
+-- other device raises the NEW_DEVICE factor
+CREATE TABLE known_devices (
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  device_id TEXT NOT NULL,
+  first_seen_at TIMESTAMP NOT NULL,
+  last_seen_at TIMESTAMP NOT NULL,
+  last_ip_address INET,
+  last_user_agent TEXT,
+  transaction_count INTEGER NOT NULL DEFAULT 1,
+  PRIMARY KEY (user_id, device_id)
+);
+
+-- +goose Down
+DROP TABLE known_devices;
+
+ALTER TABLE transactions
+  DROP COLUMN device_id,
+  DROP COLUMN ip_address,
+  DROP COLUMN user_agent;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- where the transaction was made: coordinates sent by the client, or the
+-- GeoIP location of its ip_address. Compared against the user's previous
+-- located transaction by the IMPOSSIBLE_TRAVEL factor.
+--
+-- The factor gets no trigger_factors enum value and no deviation score
+-- column: 20261016100000_generalize_factor_scores dropped both in favour of
+-- TEXT[] triggered_factors and the factor_scores JSONB, so its score is
+-- stored in factor_scores['IMPOSSIBLE_TRAVEL'].
+ALTER TABLE transactions
+  ADD COLUMN latitude DOUBLE PRECISION,
+  ADD COLUMN longitude DOUBLE PRECISION;
+
+CREATE INDEX transactions_user_located_idx ON transactions (user_id, created_at)
+  WHERE latitude IS NOT NULL AND is_legitimate;
+
+-- +goose Down
+DROP INDEX transactions_user_located_idx;
+
+ALTER TABLE transactions
+  DROP COLUMN latitude,
+  DROP COLUMN longitude;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- optional counterparty of the transaction: a UPI VPA, card merchant ID or
+-- account number
+ALTER TABLE transactions
+  ADD COLUMN payee_id TEXT;
+
+-- payees a user made legitimate transactions to; paying any other payee raises
+-- the NEW_PAYEE factor until the payee has been paid often enough to be trusted
+CREATE TABLE known_payees (
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  payee_id TEXT NOT NULL,
+  first_seen_at TIMESTAMP NOT NULL,
+  last_seen_at TIMESTAMP NOT NULL,
+  transaction_count INTEGER NOT NULL DEFAULT 1,
+  PRIMARY KEY (user_id, payee_id)
+);
+
+-- +goose Down
+DROP TABLE known_payees;
+
+ALTER TABLE transactions
+  DROP COLUMN payee_id;
@@ -0,0 +1,41 @@
+-- +goose Up
+CREATE TYPE list_type AS ENUM (
+  'ALLOW',
+  'DENY'
+);
+
+CREATE TYPE list_entity AS ENUM (
+  'USER',
+  'PAYEE',
+  'DEVICE',
+  'IP'
+);
+
+-- allow and deny list entries decide a transaction before it is scored. Entries
+-- are removed by setting removed_at, so transactions keep pointing at the entry
+-- that decided them.
+CREATE TABLE list_entries (
+  id SERIAL PRIMARY KEY,
+  list_type list_type NOT NULL,
+  entity list_entity NOT NULL,
+  value TEXT NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+  expires_at TIMESTAMP,
+  removed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  removed_at TIMESTAMP
+);
+
+CREATE INDEX list_entries_lookup_idx ON list_entries (entity, value) WHERE removed_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN list_entry_id INTEGER REFERENCES list_entries(id);
+
+-- +goose Down
+ALTER TABLE transactions DROP COLUMN list_entry_id;
+
+DROP TABLE IF EXISTS list_entries;
+
+DROP TYPE IF EXISTS list_entity;
+
+DROP TYPE IF EXISTS list_type;
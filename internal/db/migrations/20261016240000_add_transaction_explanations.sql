@@ -0,0 +1,7 @@
+-- +goose Up
+-- explanation holds the decision's per-factor breakdown, as returned when the
+-- transaction was created. Transactions scored before it existed have none.
+ALTER TABLE transactions ADD COLUMN explanation JSONB;
+
+-- +goose Down
+ALTER TABLE transactions DROP COLUMN explanation;
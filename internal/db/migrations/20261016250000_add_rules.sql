@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TYPE rule_action AS ENUM (
+  'SET_DECISION',
+  'ADD_SCORE',
+  'ADD_TAG'
+);
+
+-- rules are evaluated after the weighted score, highest priority first. The
+-- first matching SET_DECISION rule overrides the decision, ADD_SCORE rules add
+-- score_delta to the final risk score and ADD_TAG rules tag the transaction.
+CREATE TABLE rules (
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  expression TEXT NOT NULL,
+  action rule_action NOT NULL,
+  decision transaction_decision,
+  score_delta DOUBLE PRECISION NOT NULL DEFAULT 0,
+  tag TEXT NOT NULL DEFAULT '',
+  priority INTEGER NOT NULL DEFAULT 0,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CHECK ((action = 'SET_DECISION') = (decision IS NOT NULL))
+);
+
+-- ids of the rules matching the transaction, in evaluation order. Deleted
+-- rules are still named in the transaction's explanation.
+ALTER TABLE transactions ADD COLUMN matched_rules INTEGER[] NOT NULL DEFAULT '{}';
+ALTER TABLE transactions ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE transactions DROP COLUMN tags;
+
+ALTER TABLE transactions DROP COLUMN matched_rules;
+
+DROP TABLE IF EXISTS rules;
+
+DROP TYPE IF EXISTS rule_action;
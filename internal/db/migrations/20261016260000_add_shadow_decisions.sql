@@ -0,0 +1,33 @@
+-- +goose Up
+-- the shadow version scores live transactions next to the active one without
+-- affecting their decision
+ALTER TABLE scoring_configs ADD COLUMN is_shadow BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- at most one version can be shadowed at a time, and never the active one
+CREATE UNIQUE INDEX scoring_configs_single_shadow ON scoring_configs (is_shadow) WHERE is_shadow;
+ALTER TABLE scoring_configs ADD CONSTRAINT scoring_configs_shadow_not_active CHECK (NOT (is_active AND is_shadow));
+
+-- the challenger's outcome for a transaction, next to the champion's stored
+-- one. config_version is NULL for the built-in defaults.
+CREATE TABLE shadow_decisions (
+  id SERIAL PRIMARY KEY,
+  transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+  champion_config_version INTEGER REFERENCES scoring_configs(version),
+  champion_risk_score INTEGER NOT NULL,
+  champion_decision transaction_decision NOT NULL,
+  config_version INTEGER NOT NULL REFERENCES scoring_configs(version),
+  risk_score INTEGER NOT NULL,
+  decision transaction_decision NOT NULL,
+  triggered_factors TEXT[] NOT NULL DEFAULT '{}',
+  factor_scores JSONB NOT NULL DEFAULT '{}',
+  latency_micros INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX shadow_decisions_transaction_id ON shadow_decisions (transaction_id);
+CREATE INDEX shadow_decisions_config_version ON shadow_decisions (config_version);
+
+-- +goose Down
+DROP TABLE IF EXISTS shadow_decisions;
+
+ALTER TABLE scoring_configs DROP COLUMN is_shadow;
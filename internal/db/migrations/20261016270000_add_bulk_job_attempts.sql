@@ -0,0 +1,7 @@
+-- +goose Up
+-- counts how often a job was claimed, so a job that keeps failing is given up
+-- instead of being reclaimed forever
+ALTER TABLE bulk_jobs ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE bulk_jobs DROP COLUMN attempts;
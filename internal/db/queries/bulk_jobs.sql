@@ -0,0 +1,66 @@
+-- name: CreateBulkJob :exec
+INSERT INTO bulk_jobs (id, user_id, filename, file, total_rows, strict, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    NOW()
+);
+
+-- name: GetBulkJobByID :one
+SELECT id, user_id, filename, status, total_rows, processed_rows, success_rows, failed_rows, error, created_at, started_at, heartbeat_at, finished_at, strict
+FROM bulk_jobs
+WHERE id = $1 AND user_id = $2;
+
+-- name: ClaimBulkJob :one
+UPDATE bulk_jobs
+SET status = 'PROCESSING',
+    started_at = COALESCE(started_at, NOW()),
+    heartbeat_at = NOW(),
+    attempts = attempts + 1
+WHERE id = (
+    SELECT id FROM bulk_jobs
+    WHERE status = 'PENDING'
+       OR (status = 'PROCESSING' AND heartbeat_at < NOW() - sqlc.arg(stale_seconds)::int * INTERVAL '1 second')
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: AdvanceBulkJob :exec
+UPDATE bulk_jobs
+SET processed_rows = processed_rows + 1,
+    success_rows = success_rows + sqlc.arg(success)::int,
+    failed_rows = failed_rows + sqlc.arg(failed)::int,
+    heartbeat_at = NOW()
+WHERE id = $1;
+
+-- name: CompleteBulkJob :exec
+UPDATE bulk_jobs
+SET status = 'COMPLETED', finished_at = NOW(), file = ''::bytea
+WHERE id = $1;
+
+-- name: FailBulkJob :exec
+UPDATE bulk_jobs
+SET status = 'FAILED', error = $2, finished_at = NOW(), file = ''::bytea
+WHERE id = $1;
+
+-- name: CreateBulkJobError :exec
+INSERT INTO bulk_job_errors (job_id, line_number, raw_values, reason, message, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    NOW()
+);
+
+-- name: ListBulkJobErrors :many
+SELECT * FROM bulk_job_errors
+WHERE job_id = $1
+ORDER BY line_number;
@@ -0,0 +1,64 @@
+-- name: OpenFraudCase :exec
+WITH new_case AS (
+    INSERT INTO fraud_cases (transaction_id, user_id, status, created_at, updated_at)
+    VALUES ($1, $2, 'OPEN', NOW(), NOW())
+    ON CONFLICT (transaction_id) DO NOTHING
+    RETURNING id
+)
+INSERT INTO fraud_case_events (case_id, to_status, notes, created_at)
+SELECT id, 'OPEN', $3, NOW()
+FROM new_case;
+
+-- name: GetFraudCaseByID :one
+SELECT * FROM fraud_cases
+WHERE id = $1;
+
+-- name: GetFraudCaseForUpdate :one
+SELECT * FROM fraud_cases
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListFraudCases :many
+SELECT * FROM fraud_cases
+WHERE (sqlc.narg('status')::case_status IS NULL OR status = sqlc.narg('status'))
+AND (sqlc.narg('assigned_to')::INTEGER IS NULL OR assigned_to = sqlc.narg('assigned_to'))
+ORDER BY created_at
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: AssignFraudCase :one
+UPDATE fraud_cases
+SET assigned_to = $2, status = 'ASSIGNED', updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ResolveFraudCase :one
+UPDATE fraud_cases
+SET status = 'RESOLVED', disposition = $2, notes = $3, resolved_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateFraudCaseEvent :exec
+INSERT INTO fraud_case_events (
+    case_id,
+    actor_id,
+    from_status,
+    to_status,
+    assigned_to,
+    disposition,
+    notes,
+    created_at
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    NOW()
+);
+
+-- name: ListFraudCaseEvents :many
+SELECT * FROM fraud_case_events
+WHERE case_id = $1
+ORDER BY created_at, id;
@@ -0,0 +1,33 @@
+-- name: DeleteKnownDevice :exec
+-- forgets a device used for confirmed fraud, so it is new again
+DELETE FROM known_devices
+WHERE user_id = $1 AND device_id = $2;
+
+-- name: GetDeviceHistory :one
+SELECT
+    COUNT(*) AS known_devices,
+    COUNT(*) FILTER (WHERE device_id = $2) > 0 AS known
+FROM known_devices
+WHERE user_id = $1;
+
+-- name: UpsertKnownDevice :exec
+INSERT INTO known_devices (
+    user_id,
+    device_id,
+    first_seen_at,
+    last_seen_at,
+    last_ip_address,
+    last_user_agent
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (user_id, device_id) DO UPDATE SET
+    last_seen_at = GREATEST(known_devices.last_seen_at, EXCLUDED.last_seen_at),
+    last_ip_address = COALESCE(EXCLUDED.last_ip_address, known_devices.last_ip_address),
+    last_user_agent = COALESCE(EXCLUDED.last_user_agent, known_devices.last_user_agent),
+    transaction_count = known_devices.transaction_count + 1;
@@ -0,0 +1,27 @@
+-- name: DeleteKnownPayee :exec
+-- forgets a payee paid in confirmed fraud, so it is unfamiliar again
+DELETE FROM known_payees
+WHERE user_id = $1 AND payee_id = $2;
+
+-- name: GetPayeeHistory :one
+SELECT
+    COUNT(*) AS known_payees,
+    COALESCE(SUM(transaction_count) FILTER (WHERE payee_id = $2), 0)::int AS payments
+FROM known_payees
+WHERE user_id = $1;
+
+-- name: UpsertKnownPayee :exec
+INSERT INTO known_payees (
+    user_id,
+    payee_id,
+    first_seen_at,
+    last_seen_at
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $3
+)
+ON CONFLICT (user_id, payee_id) DO UPDATE SET
+    last_seen_at = GREATEST(known_payees.last_seen_at, EXCLUDED.last_seen_at),
+    transaction_count = known_payees.transaction_count + 1;
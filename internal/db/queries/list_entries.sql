@@ -0,0 +1,40 @@
+-- name: CreateListEntry :one
+INSERT INTO list_entries (list_type, entity, value, reason, created_by, expires_at, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    (NOW() AT TIME ZONE 'UTC')
+)
+RETURNING *;
+
+-- name: GetListEntry :one
+SELECT * FROM list_entries
+WHERE id = $1;
+
+-- name: ListActiveListEntries :many
+SELECT * FROM list_entries
+WHERE removed_at IS NULL
+AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC'))
+ORDER BY id DESC;
+
+-- name: FindActiveListEntry :one
+-- deny entries sort after allow entries in the enum, so a deny entry wins
+-- when the value is on both lists
+SELECT * FROM list_entries
+WHERE entity = $1
+AND value = $2
+AND removed_at IS NULL
+AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC'))
+ORDER BY list_type DESC, id DESC
+LIMIT 1;
+
+-- name: RemoveListEntry :one
+UPDATE list_entries
+SET removed_at = (NOW() AT TIME ZONE 'UTC'), removed_by = $2
+WHERE id = $1
+AND removed_at IS NULL
+RETURNING *;
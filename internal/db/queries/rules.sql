@@ -0,0 +1,45 @@
+-- name: CreateRule :one
+INSERT INTO rules (name, expression, action, decision, score_delta, tag, priority, enabled, created_by, created_at, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: ListRules :many
+SELECT * FROM rules
+ORDER BY priority DESC, id;
+
+-- name: ListEnabledRules :many
+-- in evaluation order
+SELECT * FROM rules
+WHERE enabled
+ORDER BY priority DESC, id;
+
+-- name: UpdateRule :one
+UPDATE rules
+SET name = $2,
+    expression = $3,
+    action = $4,
+    decision = $5,
+    score_delta = $6,
+    tag = $7,
+    priority = $8,
+    enabled = $9,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteRule :one
+DELETE FROM rules
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,47 @@
+-- name: CreateScoringConfig :one
+INSERT INTO scoring_configs (description, config, created_by, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+RETURNING *;
+
+-- name: GetScoringConfigByVersion :one
+SELECT * FROM scoring_configs
+WHERE version = $1;
+
+-- name: GetActiveScoringConfig :one
+SELECT * FROM scoring_configs
+WHERE is_active;
+
+-- name: ListScoringConfigs :many
+SELECT * FROM scoring_configs
+ORDER BY version DESC;
+
+-- name: DeactivateScoringConfigs :exec
+UPDATE scoring_configs
+SET is_active = FALSE
+WHERE is_active;
+
+-- name: ActivateScoringConfig :one
+UPDATE scoring_configs
+SET is_active = TRUE, is_shadow = FALSE, activated_at = NOW()
+WHERE version = $1
+RETURNING *;
+
+-- name: GetShadowScoringConfig :one
+SELECT * FROM scoring_configs
+WHERE is_shadow;
+
+-- name: ClearShadowScoringConfig :exec
+UPDATE scoring_configs
+SET is_shadow = FALSE
+WHERE is_shadow;
+
+-- name: ShadowScoringConfig :one
+UPDATE scoring_configs
+SET is_shadow = TRUE
+WHERE version = $1
+RETURNING *;
@@ -0,0 +1,38 @@
+-- name: CreateShadowDecision :exec
+INSERT INTO shadow_decisions (
+    transaction_id,
+    champion_config_version,
+    champion_risk_score,
+    champion_decision,
+    config_version,
+    risk_score,
+    decision,
+    triggered_factors,
+    factor_scores,
+    latency_micros
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
+);
+
+-- name: SummarizeShadowDecisions :many
+-- SummarizeShadowDecisions counts a challenger's decisions by the champion's
+-- decision and the transaction's fraud label
+SELECT
+    s.champion_decision,
+    s.decision,
+    t.fraud_label,
+    COUNT(*) AS transactions,
+    SUM(s.latency_micros)::bigint AS latency_micros
+FROM shadow_decisions s
+JOIN transactions t ON t.id = s.transaction_id
+WHERE s.config_version = $1
+GROUP BY s.champion_decision, s.decision, t.fraud_label;
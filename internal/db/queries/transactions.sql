@@ -1,9 +1,3 @@
--- name: CountRecentTransactions :one
-SELECT COUNT(*)
-FROM transactions
-WHERE user_id = $1
-AND created_at > NOW() - make_interval(secs => $2);
-
 -- name: CreateTransaction :one
 INSERT INTO transactions (
     user_id,
@@ -12,36 +6,62 @@ INSERT INTO transactions (
     risk_score,
     triggered_factors,
     decision,
-    amount_deviation_score,
-    frequency_deviation_score,
-    mode_deviation_score,
-    time_deviation_score,
+    factor_scores,
+    config_version,
     created_at,
+    external_reference,
+    utc_offset_minutes,
+    device_id,
+    ip_address,
+    user_agent,
+    latitude,
+    longitude,
+    payee_id,
+    list_entry_id,
+    explanation,
+    matched_rules,
+    tags,
     updated_at
 ) VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5::text[]::trigger_factors[],   
+    $5,
     $6,
     $7,
     $8,
     $9,
     $10,
     $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    $18,
+    $19,
+    $20,
+    $21,
     NOW()
 )
+ON CONFLICT (user_id, external_reference) DO NOTHING
 RETURNING
     id,
     user_id,
     amount,
     mode,
     risk_score,
-    triggered_factors::text[] AS triggered_factors, 
+    triggered_factors,
     decision,
+    config_version,
     created_at,
-    updated_at;
+    updated_at,
+    device_id,
+    ip_address,
+    user_agent,
+    payee_id;
 
 -- name: CountTodaysTransactions :one
 SELECT COUNT(*)
@@ -68,3 +88,47 @@ SELECT
 FROM transactions
 WHERE user_id = $1
   AND created_at >= CURRENT_DATE;
+
+-- name: UpdatePendingMFADecision :one
+UPDATE transactions
+SET decision = $3, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND decision = 'MFA_REQUIRED'
+RETURNING *;
+
+-- name: GetTransactionByID :one
+SELECT * FROM transactions
+WHERE id = $1;
+
+-- name: SetTransactionFraudLabel :exec
+UPDATE transactions
+SET fraud_label = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetTransactionByExternalReference :one
+SELECT * FROM transactions
+WHERE user_id = $1 AND external_reference = $2;
+
+-- name: ListTransactionActivity :many
+SELECT id, mode, amount, created_at
+FROM transactions
+WHERE user_id = $1
+AND created_at > sqlc.arg(since)::timestamp
+AND created_at <= sqlc.arg(until)::timestamp
+ORDER BY created_at;
+
+-- name: GetLastLocatedTransaction :one
+SELECT latitude::DOUBLE PRECISION AS latitude, longitude::DOUBLE PRECISION AS longitude, created_at
+FROM transactions
+WHERE user_id = $1
+AND latitude IS NOT NULL
+AND longitude IS NOT NULL
+AND is_legitimate
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ListTransactionsForReplay :many
+-- ListTransactionsForReplay returns every transaction created in [since, until) in scoring order
+SELECT * FROM transactions
+WHERE created_at >= sqlc.arg(since)::timestamp
+AND created_at < sqlc.arg(until)::timestamp
+ORDER BY created_at, id;
@@ -1,4 +1,9 @@
--- name: UpsertUserProfileByUserID :exec
+-- name: ListProfiledUserIDs :many
+-- users with a transaction history, whose profiles are rebuilt nightly
+SELECT DISTINCT user_id FROM transactions
+ORDER BY user_id;
+
+-- name: RecalculateUserProfile :exec
 INSERT INTO user_profile_behavior (
     user_id,
     average_transaction_amount,
@@ -6,8 +11,11 @@ INSERT INTO user_profile_behavior (
     max_transaction_amount_seen,
     average_number_of_transactions_per_day,
     registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
+    hour_histogram,
+    weekday_hour_histogram,
+    histogram_decayed_at,
+    amount_stats,
+    mode_amount_stats,
     total_transactions,
     allowed_transactions,
     updated_at
@@ -16,42 +24,45 @@ SELECT
     u.id AS user_id,
 
     COALESCE(
-        AVG(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
+        AVG(t.amount) FILTER (WHERE t.is_legitimate),
         0
-    )::INTEGER AS average_transaction_amount,
+    ) AS average_transaction_amount,
 
     COALESCE(
-        STDDEV(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
+        STDDEV(t.amount) FILTER (WHERE t.is_legitimate),
         0
-    )::INTEGER AS std_dev_transaction_amount,
+    ) AS std_dev_transaction_amount,
 
-    COALESCE(MAX(t.amount), 0)::INTEGER AS max_transaction_amount_seen,
+    COALESCE(MAX(t.amount), 0) AS max_transaction_amount_seen,
 
     LEAST(
-        COUNT(*) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
+        COUNT(*) FILTER (WHERE t.is_legitimate) / GREATEST(DATE_PART('day', NOW() - MIN(t.created_at)), 1),
         50
     )::INTEGER AS average_number_of_transactions_per_day,
 
     COALESCE(
-        ARRAY_AGG(DISTINCT t.mode) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
+        ARRAY_AGG(DISTINCT t.mode) FILTER (WHERE t.is_legitimate),
         ARRAY[]::mode[]
     ) AS registered_payment_modes,
 
-    MIN(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_start_hour,
+    profile_hour_histogram(u.id, (NOW() AT TIME ZONE 'UTC'), 24) AS hour_histogram,
+
+    profile_hour_histogram(u.id, (NOW() AT TIME ZONE 'UTC'), 168) AS weekday_hour_histogram,
 
-    MAX(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_end_hour,
+    (NOW() AT TIME ZONE 'UTC') AS histogram_decayed_at,
+
+    profile_amount_stats(u.id, NULL, 'infinity') AS amount_stats,
+
+    profile_mode_amount_stats(u.id, 'infinity') AS mode_amount_stats,
 
     COUNT(t.id) AS total_transactions,
 
-    COUNT(t.id) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS allowed_transactions,
+    COUNT(t.id) FILTER (WHERE t.is_legitimate) AS allowed_transactions,
 
     NOW() AS updated_at
 FROM users u
 LEFT JOIN transactions t
     ON t.user_id = u.id
-    AND t.created_at < CURRENT_DATE
 WHERE u.id = $1
 GROUP BY u.id
 
@@ -61,142 +72,35 @@ ON CONFLICT (user_id) DO UPDATE SET
     max_transaction_amount_seen = EXCLUDED.max_transaction_amount_seen,
     average_number_of_transactions_per_day = EXCLUDED.average_number_of_transactions_per_day,
     registered_payment_modes = EXCLUDED.registered_payment_modes,
-    usual_transaction_start_hour = EXCLUDED.usual_transaction_start_hour,
-    usual_transaction_end_hour = EXCLUDED.usual_transaction_end_hour,
+    hour_histogram = EXCLUDED.hour_histogram,
+    weekday_hour_histogram = EXCLUDED.weekday_hour_histogram,
+    histogram_decayed_at = EXCLUDED.histogram_decayed_at,
+    amount_stats = EXCLUDED.amount_stats,
+    mode_amount_stats = EXCLUDED.mode_amount_stats,
     total_transactions = EXCLUDED.total_transactions,
     allowed_transactions = EXCLUDED.allowed_transactions,
     updated_at = EXCLUDED.updated_at;
 
-
--- name: RebuildAllUserProfiles :exec
-INSERT INTO user_profile_behavior (
-    user_id,
-    average_transaction_amount,
-    std_dev_transaction_amount,
-    max_transaction_amount_seen,
-    average_number_of_transactions_per_day,
-    registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
-    updated_at
-)
+-- name: GetUserProfileByUserID :one
 SELECT
-    t.user_id,
-
-    COALESCE(
-        AVG(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        0
-    )::INTEGER AS average_transaction_amount,
-
-    COALESCE(
-        STDDEV(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        0
-    )::INTEGER AS std_dev_transaction_amount,
-
-    COALESCE(MAX(t.amount), 0)::INTEGER AS max_transaction_amount_seen,
-
-    LEAST(
-        COUNT(*) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        50
-    )::INTEGER AS average_number_of_transactions_per_day,
-
-    COALESCE(
-        (ARRAY_AGG(DISTINCT t.mode) 
-            FILTER (WHERE t.decision IN ('ALLOW', 'FLAG'))),
-        ARRAY[]::mode[]
-    ) AS registered_payment_modes,
-
-    MIN(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_start_hour,
-
-    MAX(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_end_hour,
-
-    NOW() AS updated_at
-
-FROM transactions t
-WHERE t.created_at < CURRENT_DATE
-GROUP BY t.user_id
-
-ON CONFLICT (user_id) DO UPDATE SET
-    average_transaction_amount = EXCLUDED.average_transaction_amount,
-    std_dev_transaction_amount = EXCLUDED.std_dev_transaction_amount,
-    max_transaction_amount_seen = EXCLUDED.max_transaction_amount_seen,
-    average_number_of_transactions_per_day = EXCLUDED.average_number_of_transactions_per_day,
-    registered_payment_modes = EXCLUDED.registered_payment_modes,
-    usual_transaction_start_hour = EXCLUDED.usual_transaction_start_hour,
-    usual_transaction_end_hour = EXCLUDED.usual_transaction_end_hour,
-    updated_at = EXCLUDED.updated_at;
-
--- name: RecalculateUserProfile :exec
-INSERT INTO user_profile_behavior (
     user_id,
     average_transaction_amount,
     std_dev_transaction_amount,
     max_transaction_amount_seen,
     average_number_of_transactions_per_day,
-    registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
+    registered_payment_modes::text[] AS registered_payment_modes,
+    hour_histogram,
+    weekday_hour_histogram,
+    histogram_decayed_at,
+    amount_stats,
+    mode_amount_stats,
     total_transactions,
     allowed_transactions,
     updated_at
-)
-SELECT
-    u.id AS user_id,
-
-    COALESCE(
-        AVG(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        0
-    )::INTEGER AS average_transaction_amount,
-
-    COALESCE(
-        STDDEV(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        0
-    )::INTEGER AS std_dev_transaction_amount,
-
-    COALESCE(MAX(t.amount), 0)::INTEGER AS max_transaction_amount_seen,
-
-    LEAST(
-        COUNT(*) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) / GREATEST(DATE_PART('day', NOW() - MIN(t.created_at)), 1),
-        50
-    )::INTEGER AS average_number_of_transactions_per_day,
-
-    COALESCE(
-        ARRAY_AGG(DISTINCT t.mode) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        ARRAY[]::mode[]
-    ) AS registered_payment_modes,
-
-    MIN(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_start_hour,
-
-    MAX(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_end_hour,
-
-    COUNT(t.id) AS total_transactions,
-
-    COUNT(t.id) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS allowed_transactions,
-
-    NOW() AS updated_at
-FROM users u
-LEFT JOIN transactions t
-    ON t.user_id = u.id
-WHERE u.id = $1
-GROUP BY u.id
-
-ON CONFLICT (user_id) DO UPDATE SET
-    average_transaction_amount = EXCLUDED.average_transaction_amount,
-    std_dev_transaction_amount = EXCLUDED.std_dev_transaction_amount,
-    max_transaction_amount_seen = EXCLUDED.max_transaction_amount_seen,
-    average_number_of_transactions_per_day = EXCLUDED.average_number_of_transactions_per_day,
-    registered_payment_modes = EXCLUDED.registered_payment_modes,
-    usual_transaction_start_hour = EXCLUDED.usual_transaction_start_hour,
-    usual_transaction_end_hour = EXCLUDED.usual_transaction_end_hour,
-    total_transactions = EXCLUDED.total_transactions,
-    allowed_transactions = EXCLUDED.allowed_transactions,
-    updated_at = EXCLUDED.updated_at;
+FROM user_profile_behavior
+WHERE user_id = $1;
 
--- name: GetUserProfileByUserID :one
+-- name: GetUserProfileByUserIDForUpdate :one
 SELECT
     user_id,
     average_transaction_amount,
@@ -204,13 +108,22 @@ SELECT
     max_transaction_amount_seen,
     average_number_of_transactions_per_day,
     registered_payment_modes::text[] AS registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
+    hour_histogram,
+    weekday_hour_histogram,
+    histogram_decayed_at,
+    amount_stats,
+    mode_amount_stats,
     total_transactions,
     allowed_transactions,
     updated_at
 FROM user_profile_behavior
-WHERE user_id = $1;
+WHERE user_id = $1
+FOR UPDATE;
+
+-- name: LockUserProfile :exec
+-- serializes the scoring and profile updates of a user until the end of the
+-- transaction; the lock is taken even when the user has no profile row yet
+SELECT pg_advisory_xact_lock('user_profile_behavior'::regclass::oid::int, sqlc.arg(user_id)::int);
 
 -- name: UpsertUserProfileFromProfile :exec
 INSERT INTO user_profile_behavior (
@@ -220,8 +133,11 @@ INSERT INTO user_profile_behavior (
     max_transaction_amount_seen,
     average_number_of_transactions_per_day,
     registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
+    hour_histogram,
+    weekday_hour_histogram,
+    histogram_decayed_at,
+    amount_stats,
+    mode_amount_stats,
     total_transactions,
     allowed_transactions,
     updated_at
@@ -232,11 +148,14 @@ VALUES (
     $3,  -- std_dev_transaction_amount
     $4,  -- max_transaction_amount_seen
     $5,  -- average_number_of_transactions_per_day
-    $6,  -- registered_payment_modes
-    $7,  -- usual_transaction_start_hour
-    $8,  -- usual_transaction_end_hour
-    $9,  -- total_transactions
-    $10, -- allowed_transactions
+    $6::text[]::mode[],  -- registered_payment_modes
+    $7,  -- hour_histogram
+    $8,  -- weekday_hour_histogram
+    $9,  -- histogram_decayed_at
+    $10, -- amount_stats
+    $11, -- mode_amount_stats
+    $12, -- total_transactions
+    $13, -- allowed_transactions
     NOW()
 )
 ON CONFLICT (user_id) DO UPDATE SET
@@ -245,8 +164,11 @@ ON CONFLICT (user_id) DO UPDATE SET
     max_transaction_amount_seen = EXCLUDED.max_transaction_amount_seen,
     average_number_of_transactions_per_day = EXCLUDED.average_number_of_transactions_per_day,
     registered_payment_modes = EXCLUDED.registered_payment_modes,
-    usual_transaction_start_hour = EXCLUDED.usual_transaction_start_hour,
-    usual_transaction_end_hour = EXCLUDED.usual_transaction_end_hour,
+    hour_histogram = EXCLUDED.hour_histogram,
+    weekday_hour_histogram = EXCLUDED.weekday_hour_histogram,
+    histogram_decayed_at = EXCLUDED.histogram_decayed_at,
+    amount_stats = EXCLUDED.amount_stats,
+    mode_amount_stats = EXCLUDED.mode_amount_stats,
     total_transactions = EXCLUDED.total_transactions,
     allowed_transactions = EXCLUDED.allowed_transactions,
     updated_at = NOW();
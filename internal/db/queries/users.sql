@@ -1,9 +1,10 @@
 -- name: CreateUser :one
-INSERT INTO users(name, email, hashed_pass, created_at, updated_at)
+INSERT INTO users(name, email, hashed_pass, timezone, created_at, updated_at)
 VALUES (
     $1,
     $2,
     $3,
+    $4,
     NOW(),
     NOW()
 )
@@ -15,4 +16,20 @@ WHERE email = $1;
 
 -- name: GetUserByID :one
 SELECT * FROM users
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetUserTimezone :one
+SELECT timezone FROM users
+WHERE id = $1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserTimezone :one
+UPDATE users
+SET timezone = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
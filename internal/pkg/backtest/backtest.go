@@ -0,0 +1,246 @@
+// Package backtest replays historical transactions against a candidate
+// scoring config. Profiles, velocity, known devices and payees are rebuilt in
+// memory in chronological order, so every transaction is scored with what was
+// known when it was made, and the replayed decisions are compared with the
+// stored ones.
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/velocity"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+// Transaction is a historical transaction to replay
+type Transaction struct {
+	ID     int32
+	UserID int32
+	Amount float64
+	Mode   repository.Mode
+	// CreatedAt is in the user's local time
+	CreatedAt time.Time
+	// DeviceID and PayeeID are empty and Location is nil when unknown
+	DeviceID string
+	PayeeID  string
+	Location *specs.GeoPoint
+	// Decision is the stored decision, empty when there is none to compare with
+	Decision repository.TransactionDecision
+	// TriggeredFactors are the stored triggered factors, nil when unknown
+	TriggeredFactors []string
+	// Label is the analyst's fraud label, empty when unlabeled
+	Label repository.FraudLabel
+	// ListDecided transactions were decided by an allow or deny list entry;
+	// they keep their stored decision
+	ListDecided bool
+}
+
+// FromRepository converts stored transactions, restoring the local time each
+// was made at
+func FromRepository(rows []repository.Transaction) []Transaction {
+	txns := make([]Transaction, 0, len(rows))
+	for _, row := range rows {
+		t := Transaction{
+			ID:               row.ID,
+			UserID:           row.UserID,
+			Amount:           row.Amount,
+			Mode:             row.Mode,
+			CreatedAt:        row.CreatedAt.Time.In(time.FixedZone("", int(row.UtcOffsetMinutes)*60)),
+			DeviceID:         row.DeviceID.String,
+			PayeeID:          row.PayeeID.String,
+			Decision:         row.Decision,
+			TriggeredFactors: append([]string{}, row.TriggeredFactors...),
+			ListDecided:      row.ListEntryID.Valid,
+		}
+		if row.Latitude.Valid && row.Longitude.Valid {
+			t.Location = &specs.GeoPoint{Latitude: row.Latitude.Float64, Longitude: row.Longitude.Float64}
+		}
+		if row.FraudLabel.Valid {
+			t.Label = row.FraudLabel.FraudLabel
+		}
+		txns = append(txns, t)
+	}
+	return txns
+}
+
+// LoadConfig reads a candidate config in the format of the scoring config
+// API. Omitted fields keep their default values.
+func LoadConfig(r io.Reader) (*specs.ScoringConfig, error) {
+	cfg := helpers.DefaultScoringConfig()
+	if err := json.NewDecoder(r).Decode(cfg); err != nil {
+		return nil, err
+	}
+	if err := helpers.ValidateScoringConfig(*cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// userState is what the replay knows about a user before their next transaction
+type userState struct {
+	profile  *repository.UserProfileBehavior
+	velocity *velocity.Replay
+	devices  map[string]bool
+	payees   map[string]int32
+	previous *specs.LocatedTransaction
+}
+
+// Run replays the transactions in chronological order under cfg and the given
+// fraud rules, which may be nil.
+//
+// Profiles learn from what each transaction turned out to be, like the
+// profile rebuild: analyst labels first, then the stored decision and, for
+// transactions without one, the replayed decision. Replayed profiles are kept
+// incrementally, so amounts are scored like on profiles that were never
+// rebuilt.
+func Run(ctx context.Context, cfg *specs.ScoringConfig, compiled []specs.CompiledRule, txns []Transaction) Report {
+	txns = chronological(txns)
+
+	report := newReport()
+	users := map[int32]*userState{}
+	for _, t := range txns {
+		state := userFor(users, t.UserID)
+
+		decision := t.Decision
+		if t.ListDecided {
+			report.addListDecided(t)
+		} else {
+			result := state.score(ctx, cfg, compiled, t)
+			report.add(t, result)
+			decision = result.Decision
+		}
+
+		state.learn(t, decision)
+	}
+
+	report.Users = len(users)
+	report.finish()
+	return report
+}
+
+// Observe replays the transactions in chronological order like Run, without
+// scoring them: visit is called with each transaction as the scoring pipeline
+// would have seen it. Profiles learn from labels and stored decisions only, so
+// transactions without a decision are not learned as legitimate.
+func Observe(txns []Transaction, visit func(t Transaction, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory)) {
+	users := map[int32]*userState{}
+	for _, t := range chronological(txns) {
+		state := userFor(users, t.UserID)
+		txn, history := state.input(t)
+		visit(t, txn, state.profile, history)
+		state.learn(t, t.Decision)
+	}
+}
+
+func chronological(txns []Transaction) []Transaction {
+	txns = slices.Clone(txns)
+	slices.SortStableFunc(txns, func(a, b Transaction) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return txns
+}
+
+// userFor returns the replay state of a user, starting from an empty profile
+func userFor(users map[int32]*userState, userID int32) *userState {
+	state, ok := users[userID]
+	if !ok {
+		state = &userState{
+			profile:  helpers.NewEmptyUserProfile(userID),
+			velocity: velocity.NewReplay(),
+			devices:  map[string]bool{},
+			payees:   map[string]int32{},
+		}
+		users[userID] = state
+	}
+	return state
+}
+
+// score analyzes a transaction against the user's replayed history
+func (s *userState) score(ctx context.Context, cfg *specs.ScoringConfig, compiled []specs.CompiledRule, t Transaction) specs.FraudAnalysisResult {
+	txn, history := s.input(t)
+	result := helpers.AnalyzeTransaction(ctx, cfg, txn, s.profile, history)
+	return helpers.ApplyRules(cfg, compiled, txn, s.profile, history, result)
+}
+
+// input is the transaction and the user's replayed history as the scoring
+// pipeline sees them
+func (s *userState) input(t Transaction) (*specs.TransactionInput, *specs.TransactionHistory) {
+	history := &specs.TransactionHistory{
+		Velocity: s.velocity.Snapshot(t.Mode, t.CreatedAt),
+	}
+	if t.DeviceID != "" {
+		history.Devices = specs.DeviceHistory{Known: s.devices[t.DeviceID], KnownDevices: int64(len(s.devices))}
+	}
+	if t.PayeeID != "" {
+		history.Payees = specs.PayeeHistory{Payments: s.payees[t.PayeeID], KnownPayees: int64(len(s.payees))}
+	}
+	if t.Location != nil {
+		history.PreviousLocation = s.previous
+	}
+
+	txn := &specs.TransactionInput{
+		Amount:    t.Amount,
+		Mode:      t.Mode,
+		CreatedAt: t.CreatedAt,
+		DeviceID:  t.DeviceID,
+		Location:  t.Location,
+		PayeeID:   t.PayeeID,
+	}
+	return txn, history
+}
+
+// learn applies a transaction to the user's history; replayed is its decision
+// in the replay
+func (s *userState) learn(t Transaction, replayed repository.TransactionDecision) {
+	legitimate := isLegitimate(t, replayed)
+
+	// the profile only tells legitimate transactions apart by their decision
+	decision := repository.TransactionDecisionBLOCK
+	if legitimate {
+		decision = repository.TransactionDecisionALLOW
+	}
+	helpers.ApplyTransactionToProfile(s.profile, t.Amount, t.Mode, t.CreatedAt, decision)
+
+	s.velocity.Add(velocity.Event{
+		TxnID:     t.ID,
+		Mode:      t.Mode,
+		Amount:    t.Amount,
+		CreatedAt: t.CreatedAt,
+	})
+
+	if !legitimate {
+		return
+	}
+	if t.DeviceID != "" {
+		s.devices[t.DeviceID] = true
+	}
+	if t.PayeeID != "" {
+		s.payees[t.PayeeID]++
+	}
+	if t.Location != nil {
+		s.previous = &specs.LocatedTransaction{GeoPoint: *t.Location, CreatedAt: t.CreatedAt}
+	}
+}
+
+// isLegitimate mirrors the is_legitimate column: analyst labels take
+// precedence over the decision
+func isLegitimate(t Transaction, replayed repository.TransactionDecision) bool {
+	switch t.Label {
+	case repository.FraudLabelCONFIRMEDFRAUD:
+		return false
+	case repository.FraudLabelFALSEPOSITIVE:
+		return true
+	}
+
+	decision := t.Decision
+	if decision == "" {
+		decision = replayed
+	}
+	return decision == repository.TransactionDecisionALLOW || decision == repository.TransactionDecisionFLAG
+}
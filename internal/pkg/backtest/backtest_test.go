@@ -0,0 +1,226 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decisionRule(t *testing.T, expression string, decision repository.TransactionDecision) specs.CompiledRule {
+	t.Helper()
+	e, err := helpers.CompileRule(expression)
+	require.NoError(t, err)
+	return specs.CompiledRule{
+		MatchedRule: specs.MatchedRule{Name: expression, Action: repository.RuleActionSETDECISION, Decision: decision},
+		Expression:  e,
+	}
+}
+
+func TestRun(t *testing.T) {
+	cfg := helpers.DefaultScoringConfig()
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	allowed := func(id int32, amount float64, day int) Transaction {
+		return Transaction{
+			ID:               id,
+			UserID:           1,
+			Amount:           amount,
+			Mode:             repository.ModeUPI,
+			CreatedAt:        start.AddDate(0, 0, day),
+			Decision:         repository.TransactionDecisionALLOW,
+			TriggeredFactors: []string{},
+		}
+	}
+
+	t.Run("decision deltas and metrics", func(t *testing.T) {
+		compiled := []specs.CompiledRule{
+			decisionRule(t, `txn.amount > 10000`, repository.TransactionDecisionBLOCK),
+			decisionRule(t, `txn.amount > 0`, repository.TransactionDecisionALLOW),
+		}
+		txns := []Transaction{
+			allowed(1, 500, 0),
+			allowed(2, 700, 1),
+			allowed(3, 50000, 2),
+			allowed(4, 600, 3),
+			allowed(5, 800, 4),
+		}
+		txns[1].Label = repository.FraudLabelCONFIRMEDFRAUD
+		txns[2].Label = repository.FraudLabelCONFIRMEDFRAUD
+		txns[3].Label = repository.FraudLabelFALSEPOSITIVE
+		txns[1].TriggeredFactors = []string{"AMOUNT_DEVIATION"}
+
+		report := Run(context.Background(), cfg, compiled, txns)
+		assert.Equal(t, 5, report.Transactions)
+		assert.Equal(t, 1, report.Users)
+		assert.Equal(t, 5, report.Compared)
+		assert.Equal(t, 1, report.Changed)
+		assert.Equal(t, 4, report.Deltas[repository.TransactionDecisionALLOW][repository.TransactionDecisionALLOW])
+		assert.Equal(t, 1, report.Deltas[repository.TransactionDecisionALLOW][repository.TransactionDecisionBLOCK])
+		assert.Equal(t, 5, report.StoredDecisions[repository.TransactionDecisionALLOW])
+		assert.Equal(t, 1, report.Decisions[repository.TransactionDecisionBLOCK])
+
+		require.NotNil(t, report.Stored)
+		assert.Equal(t, specs.LabelMetrics{Labeled: 3, FalseNegatives: 2}, *report.Stored)
+		require.NotNil(t, report.Replayed)
+		assert.Equal(t, specs.LabelMetrics{Labeled: 3, TruePositives: 1, FalseNegatives: 1, Precision: 1, Recall: 0.5}, *report.Replayed)
+
+		assert.NotEmpty(t, report.Factors)
+		for _, f := range report.Factors {
+			assert.LessOrEqual(t, f.Rate, 1.0)
+			if f.Name == "AMOUNT_DEVIATION" {
+				assert.Equal(t, 1, f.StoredTriggered)
+				assert.Equal(t, 0.2, f.StoredRate)
+			} else {
+				assert.Zero(t, f.StoredTriggered)
+			}
+		}
+	})
+
+	t.Run("list decided transactions keep their decision", func(t *testing.T) {
+		compiled := []specs.CompiledRule{decisionRule(t, `txn.amount > 0`, repository.TransactionDecisionALLOW)}
+		denied := allowed(2, 500, 1)
+		denied.Decision, denied.ListDecided = repository.TransactionDecisionBLOCK, true
+
+		report := Run(context.Background(), cfg, compiled, []Transaction{allowed(1, 500, 0), denied})
+		assert.Equal(t, 1, report.ListDecided)
+		assert.Equal(t, 0, report.Changed)
+		assert.Equal(t, 1, report.Decisions[repository.TransactionDecisionBLOCK])
+		assert.Nil(t, report.Replayed)
+	})
+
+	t.Run("history is learned in order from legitimate transactions", func(t *testing.T) {
+		compiled := []specs.CompiledRule{
+			decisionRule(t, `txn.device_id != "" && !device.known`, repository.TransactionDecisionMFAREQUIRED),
+			decisionRule(t, `txn.amount > 0`, repository.TransactionDecisionALLOW),
+		}
+		onDevice := func(id int32, day int, device string) Transaction {
+			txn := allowed(id, 500, day)
+			txn.DeviceID = device
+			return txn
+		}
+		fraud := onDevice(3, 2, "d2")
+		fraud.Label = repository.FraudLabelCONFIRMEDFRAUD
+
+		// out of order on purpose: the replay sorts by creation time
+		report := Run(context.Background(), cfg, compiled, []Transaction{
+			onDevice(4, 3, "d2"),
+			fraud,
+			onDevice(2, 1, "d1"),
+			onDevice(1, 0, "d1"),
+		})
+		assert.Equal(t, 3, report.Decisions[repository.TransactionDecisionMFAREQUIRED])
+		assert.Equal(t, 1, report.Decisions[repository.TransactionDecisionALLOW])
+	})
+
+	t.Run("unchanged config", func(t *testing.T) {
+		txns := []Transaction{allowed(1, 500, 0), allowed(2, 700, 1)}
+		for i := range txns {
+			txns[i].Decision = ""
+		}
+		report := Run(context.Background(), cfg, nil, txns)
+		assert.Equal(t, 2, report.Transactions)
+		assert.Equal(t, 0, report.Compared)
+		assert.Empty(t, report.Deltas)
+
+		var out bytes.Buffer
+		assert.NoError(t, report.WriteText(&out))
+		assert.Contains(t, out.String(), "changed: 0 of 0")
+	})
+}
+
+func TestObserve(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	txns := []Transaction{
+		{ID: 2, UserID: 1, Amount: 900, Mode: repository.ModeUPI, CreatedAt: start.Add(30 * time.Minute), DeviceID: "d2", Decision: repository.TransactionDecisionALLOW},
+		{ID: 1, UserID: 1, Amount: 500, Mode: repository.ModeUPI, CreatedAt: start, DeviceID: "d1", Decision: repository.TransactionDecisionALLOW},
+		{ID: 3, UserID: 2, Amount: 100, Mode: repository.ModeCARD, CreatedAt: start.Add(15 * time.Minute)},
+	}
+
+	var seen []int32
+	Observe(txns, func(observed Transaction, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory) {
+		seen = append(seen, observed.ID)
+		assert.Equal(t, observed.Amount, txn.Amount)
+		assert.Equal(t, observed.UserID, profile.UserID)
+
+		if observed.ID == 2 {
+			assert.Equal(t, int32(1), profile.TotalTransactions)
+			assert.Equal(t, specs.DeviceHistory{KnownDevices: 1}, history.Devices)
+			assert.Equal(t, 1, history.VelocityWindow(constants.VelocityWindow1h).Count)
+		}
+	})
+	assert.Equal(t, []int32{1, 3, 2}, seen)
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig(strings.NewReader(`{"risk_threshold_flag": 50, "factors": {"AMOUNT_DEVIATION": {"weight": 0.5, "threshold": 30}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, cfg.RiskThresholdFlag)
+	assert.Equal(t, 0.5, cfg.Factors["AMOUNT_DEVIATION"].Weight)
+	assert.Equal(t, helpers.DefaultScoringConfig().RiskThresholdMFA, cfg.RiskThresholdMFA)
+
+	_, err = LoadConfig(strings.NewReader(`{"factors": {"UNKNOWN": {"weight": 1}}}`))
+	assert.ErrorIs(t, err, errors.ErrUnknownRiskFactor)
+
+	_, err = LoadConfig(strings.NewReader(`{"risk_threshold_allow": 90}`))
+	assert.ErrorIs(t, err, errors.ErrInvalidScoringConfig)
+}
+
+func TestReadCSV(t *testing.T) {
+	loc := time.FixedZone("IST", 330*60)
+
+	t.Run("optional columns", func(t *testing.T) {
+		txns, err := ReadCSV(strings.NewReader(
+			"amount,mode,created_at,external_reference,user_id,payee_id,decision,fraud_label\n"+
+				"500,upi,2026-01-01T10:00:00Z,ref-1,7,p1,allow,\n"+
+				"900,CARD,2026-01-01T12:00:00+01:00,,8,,BLOCK,confirmed_fraud\n"), loc)
+		require.NoError(t, err)
+		require.Len(t, txns, 2)
+
+		assert.Equal(t, int32(2), txns[0].ID)
+		assert.Equal(t, int32(7), txns[0].UserID)
+		assert.Equal(t, repository.ModeUPI, txns[0].Mode)
+		assert.Equal(t, 15, txns[0].CreatedAt.Hour())
+		assert.Equal(t, "p1", txns[0].PayeeID)
+		assert.Equal(t, repository.TransactionDecisionALLOW, txns[0].Decision)
+		assert.Empty(t, txns[0].Label)
+		assert.Nil(t, txns[0].TriggeredFactors)
+
+		assert.Equal(t, 12, txns[1].CreatedAt.Hour())
+		assert.Equal(t, repository.FraudLabelCONFIRMEDFRAUD, txns[1].Label)
+	})
+
+	t.Run("bulk upload file", func(t *testing.T) {
+		txns, err := ReadCSV(strings.NewReader("amount,mode,created_at\n500,UPI,2026-01-01T10:00:00Z\n"), time.UTC)
+		require.NoError(t, err)
+		require.Len(t, txns, 1)
+		assert.Zero(t, txns[0].UserID)
+		assert.Empty(t, txns[0].Decision)
+	})
+
+	t.Run("invalid rows", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			csv string
+			err error
+		}{
+			"headers":   {"amount,mode\n500,UPI\n", errors.ErrUnexpectedHeadersInFile},
+			"timestamp": {"amount,mode,created_at\n500,UPI,yesterday\n", errors.ErrBulkRowInvalidTimestamp},
+			"user id":   {"amount,mode,created_at,user_id\n500,UPI,2026-01-01T10:00:00Z,abc\n", errors.ErrInvalidBacktestUserID},
+			"decision":  {"amount,mode,created_at,decision\n500,UPI,2026-01-01T10:00:00Z,MAYBE\n", errors.ErrInvalidBacktestDecision},
+			"label":     {"amount,mode,created_at,fraud_label\n500,UPI,2026-01-01T10:00:00Z,FRAUD\n", errors.ErrInvalidBacktestLabel},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := ReadCSV(strings.NewReader(tc.csv), time.UTC)
+				assert.ErrorIs(t, err, tc.err)
+			})
+		}
+	})
+}
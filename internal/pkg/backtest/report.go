@@ -0,0 +1,168 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+var decisions = []repository.TransactionDecision{
+	repository.TransactionDecisionALLOW,
+	repository.TransactionDecisionFLAG,
+	repository.TransactionDecisionMFAREQUIRED,
+	repository.TransactionDecisionBLOCK,
+}
+
+// Report summarizes a replay
+type Report struct {
+	Transactions int `json:"transactions"`
+	Users        int `json:"users"`
+	ListDecided  int `json:"list_decided"`
+	// Compared counts the transactions with a stored decision and Changed the
+	// ones among them whose replayed decision differs
+	Compared int `json:"compared"`
+	Changed  int `json:"changed"`
+	// Deltas counts compared transactions by stored, then replayed decision
+	Deltas          map[repository.TransactionDecision]map[repository.TransactionDecision]int `json:"deltas"`
+	StoredDecisions map[repository.TransactionDecision]int                                    `json:"stored_decisions"`
+	Decisions       map[repository.TransactionDecision]int                                    `json:"decisions"`
+	// Factors are in registration order; list decided transactions are not
+	// scored and left out of the rates
+	Factors []FactorRate `json:"factors"`
+	// Stored and Replayed are nil when no compared transaction is labeled
+	Stored   *specs.LabelMetrics `json:"stored,omitempty"`
+	Replayed *specs.LabelMetrics `json:"replayed,omitempty"`
+
+	scored       int
+	scoredStored int
+	stored       specs.LabelMetrics
+	replayed     specs.LabelMetrics
+}
+
+// FactorRate is how often a factor triggered among the scored transactions in
+// the replay, and in the stored outcome of the ones whose triggered factors
+// are known
+type FactorRate struct {
+	Name            string  `json:"name"`
+	Triggered       int     `json:"triggered"`
+	Rate            float64 `json:"rate"`
+	StoredTriggered int     `json:"stored_triggered"`
+	StoredRate      float64 `json:"stored_rate"`
+}
+
+func ratio(n int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+func newReport() Report {
+	return Report{
+		Deltas:          map[repository.TransactionDecision]map[repository.TransactionDecision]int{},
+		StoredDecisions: map[repository.TransactionDecision]int{},
+		Decisions:       map[repository.TransactionDecision]int{},
+		Factors:         []FactorRate{},
+	}
+}
+
+func (r *Report) addListDecided(t Transaction) {
+	r.ListDecided++
+	r.compare(t, t.Decision)
+}
+
+func (r *Report) add(t Transaction, result specs.FraudAnalysisResult) {
+	r.scored++
+	if t.TriggeredFactors != nil {
+		r.scoredStored++
+	}
+
+	for _, f := range result.Factors {
+		i := slices.IndexFunc(r.Factors, func(rate FactorRate) bool { return rate.Name == f.Name })
+		if i < 0 {
+			r.Factors = append(r.Factors, FactorRate{Name: f.Name})
+			i = len(r.Factors) - 1
+		}
+		if f.Triggered {
+			r.Factors[i].Triggered++
+		}
+		if slices.Contains(t.TriggeredFactors, f.Name) {
+			r.Factors[i].StoredTriggered++
+		}
+	}
+
+	r.compare(t, result.Decision)
+}
+
+// compare counts the replayed decision of a transaction against its stored one
+func (r *Report) compare(t Transaction, replayed repository.TransactionDecision) {
+	r.Transactions++
+	r.Decisions[replayed]++
+
+	if t.Decision == "" {
+		return
+	}
+	r.Compared++
+	r.StoredDecisions[t.Decision]++
+	if r.Deltas[t.Decision] == nil {
+		r.Deltas[t.Decision] = map[repository.TransactionDecision]int{}
+	}
+	r.Deltas[t.Decision][replayed]++
+	if replayed != t.Decision {
+		r.Changed++
+	}
+
+	r.stored.Add(t.Label, t.Decision, 1)
+	r.replayed.Add(t.Label, replayed, 1)
+}
+
+func (r *Report) finish() {
+	for i := range r.Factors {
+		r.Factors[i].Rate = ratio(r.Factors[i].Triggered, r.scored)
+		r.Factors[i].StoredRate = ratio(r.Factors[i].StoredTriggered, r.scoredStored)
+	}
+	r.Stored = r.stored.Finish()
+	r.Replayed = r.replayed.Finish()
+}
+
+// WriteText writes the report as plain text tables
+func (r Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "transactions: %d of %d users, %d decided by list entries\n", r.Transactions, r.Users, r.ListDecided)
+	fmt.Fprintf(tw, "changed: %d of %d with a stored decision\n\n", r.Changed, r.Compared)
+
+	fmt.Fprintln(tw, "DECISION\tSTORED\tREPLAYED\t")
+	for _, d := range decisions {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t\n", d, r.StoredDecisions[d], r.Decisions[d])
+	}
+
+	fmt.Fprintln(tw, "\nSTORED -> REPLAYED\tTRANSACTIONS\t")
+	for _, from := range decisions {
+		for _, to := range decisions {
+			if n := r.Deltas[from][to]; n > 0 && from != to {
+				fmt.Fprintf(tw, "%s -> %s\t%d\t\n", from, to, n)
+			}
+		}
+	}
+
+	fmt.Fprintln(tw, "\nFACTOR\tSTORED RATE\tREPLAYED RATE\t")
+	for _, f := range r.Factors {
+		stored := "-"
+		if r.scoredStored > 0 {
+			stored = fmt.Sprintf("%.1f%%", f.StoredRate*100)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.1f%%\t\n", f.Name, stored, f.Rate*100)
+	}
+
+	if r.Replayed != nil {
+		fmt.Fprintf(tw, "\nLABELED (%d)\tPRECISION\tRECALL\t\n", r.Replayed.Labeled)
+		fmt.Fprintf(tw, "stored\t%.3f\t%.3f\t\n", r.Stored.Precision, r.Stored.Recall)
+		fmt.Fprintf(tw, "replayed\t%.3f\t%.3f\t\n", r.Replayed.Precision, r.Replayed.Recall)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,136 @@
+package backtest
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ReadDB reads the transactions created at or after since and before until
+func ReadDB(ctx context.Context, queries *repository.Queries, since time.Time, until time.Time) ([]Transaction, error) {
+	rows, err := queries.ListTransactionsForReplay(ctx, repository.ListTransactionsForReplayParams{
+		Since: pgtype.Timestamp{Time: since, Valid: true},
+		Until: pgtype.Timestamp{Time: until, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return FromRepository(rows), nil
+}
+
+// ParseDate parses a date or an RFC3339 timestamp as a UTC time, returning def
+// when value is empty
+func ParseDate(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+// ReadCSV reads transactions in the bulk upload format: amount, mode and
+// created_at, followed by any of the optional columns user_id, device_id,
+// payee_id, decision (the stored decision) and fraud_label, matched by header.
+// Other columns, such as external_reference, are ignored. Rows without a
+// user_id belong to user 0, and UTC timestamps are moved to loc like bulk
+// uploads. Every row needs a valid created_at.
+func ReadCSV(r io.Reader, loc *time.Location) ([]Transaction, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, errors.ErrFailureInParsingCSV
+	}
+	if len(headers) < 3 ||
+		!strings.EqualFold(strings.TrimSpace(headers[0]), "amount") ||
+		!strings.EqualFold(strings.TrimSpace(headers[1]), "mode") ||
+		!strings.EqualFold(strings.TrimSpace(headers[2]), "created_at") {
+		return nil, errors.ErrUnexpectedHeadersInFile
+	}
+	columns := map[string]int{}
+	for i, h := range headers[3:] {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i + 3
+	}
+	column := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var txns []Transaction
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return txns, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		t, err := parseRow(record, column, loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		t.ID = int32(line)
+		txns = append(txns, t)
+	}
+}
+
+func parseRow(record []string, column func([]string, string) string, loc *time.Location) (Transaction, error) {
+	req, err := helpers.ParseTransactionCSVRow(record[:min(len(record), 3)], true)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	t := Transaction{
+		Amount:    req.Amount,
+		Mode:      repository.Mode(req.Mode),
+		CreatedAt: helpers.BulkRowLocalTime(req.CreatedAt, loc),
+		DeviceID:  column(record, "device_id"),
+		PayeeID:   column(record, "payee_id"),
+	}
+
+	if v := column(record, "user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || id <= 0 {
+			return Transaction{}, errors.ErrInvalidBacktestUserID
+		}
+		t.UserID = int32(id)
+	}
+
+	decision := repository.TransactionDecision(strings.ToUpper(column(record, "decision")))
+	switch decision {
+	case "", repository.TransactionDecisionALLOW, repository.TransactionDecisionFLAG,
+		repository.TransactionDecisionMFAREQUIRED, repository.TransactionDecisionBLOCK:
+		t.Decision = decision
+	default:
+		return Transaction{}, errors.ErrInvalidBacktestDecision
+	}
+
+	label := repository.FraudLabel(strings.ToUpper(column(record, "fraud_label")))
+	switch label {
+	case "", repository.FraudLabelCONFIRMEDFRAUD, repository.FraudLabelFALSEPOSITIVE:
+		t.Label = label
+	default:
+		return Transaction{}, errors.ErrInvalidBacktestLabel
+	}
+	return t, nil
+}
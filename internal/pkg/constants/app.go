@@ -16,6 +16,24 @@ const (
 
 	DefaultTransactionsLimit  = 20
 	DefaultTransactionsOffset = 0
+
+	// Header carrying the client's idempotency key for POST /api/transactions
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// Longest accepted idempotency key or bulk external_reference
+	MaxExternalReferenceLength = 255
+
+	// Timezone of users that did not choose one
+	DefaultTimezone = "UTC"
+	// Range of client UTC offsets accepted with a transaction, in minutes
+	MinUTCOffsetMinutes = -12 * 60
+	MaxUTCOffsetMinutes = 14 * 60
+
+	// Longest accepted device fingerprint fields of a transaction
+	MaxDeviceIDLength  = 128
+	MaxUserAgentLength = 512
+
+	// Longest accepted payee identifier of a transaction
+	MaxPayeeIDLength = 255
 )
 
 // CorsOptions defines the CORS (Cross-Origin Resource Sharing) configuration.
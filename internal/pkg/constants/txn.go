@@ -3,17 +3,30 @@ package constants
 import "time"
 
 const (
-	// Factor weights (must sum to 1.0 for proper risk calculation)
-	WeightAmountDeviation = 0.40 // 40%
-	WeightFrequencySpike  = 0.30 // 30%
-	WeightModeDeviation   = 0.20 // 20%
-	WeightTimeAnomaly     = 0.10 // 10%
+	// Factor weights. The first four apply to every transaction and sum to 1.0.
+	// The others only apply to transactions carrying what they score, and the
+	// raw risk score is renormalized over the weights of the factors that apply.
+	WeightAmountDeviation  = 0.40 // 40%
+	WeightFrequencySpike   = 0.30 // 30%
+	WeightModeDeviation    = 0.20 // 20%
+	WeightTimeAnomaly      = 0.10 // 10%
+	WeightNewDevice        = 0.10 // with a device_id
+	WeightImpossibleTravel = 0.15 // with a location
+	WeightNewPayee         = 0.10 // with a payee_id
+
+	// The model factor is only registered when a model file is loaded, and
+	// only explains decisions until a scoring config weights it
+	WeightModelScore    = 0.0
+	ThresholdModelScore = 50.0 // Trigger if the fraud probability is over 50%
 
 	// Risk thresholds for each factor (0-100 scale)
-	ThresholdAmountDeviation = 30.0 // Trigger if score > 30
-	ThresholdFrequencySpike  = 40.0 // Trigger if score > 40
-	ThresholdModeDeviation   = 50.0 // Trigger if score > 50
-	ThresholdTimeAnomaly     = 35.0 // Trigger if score > 35
+	ThresholdAmountDeviation  = 30.0 // Trigger if score > 30
+	ThresholdFrequencySpike   = 40.0 // Trigger if score > 40
+	ThresholdModeDeviation    = 50.0 // Trigger if score > 50
+	ThresholdTimeAnomaly      = 35.0 // Trigger if score > 35
+	ThresholdNewDevice        = 50.0 // Trigger if score > 50
+	ThresholdImpossibleTravel = 50.0 // Trigger if score > 50
+	ThresholdNewPayee         = 50.0 // Trigger if score > 50
 
 	// Decision thresholds (after dampening with profile confidence)
 	RiskThresholdAllow = 30.0 // < 30: Allow
@@ -21,22 +34,154 @@ const (
 	RiskThresholdMFA   = 80.0 // 60-80: MFA Required
 	// > 80: Block
 
-	// Time window for frequency calculation (in hours)
-	FrequencyWindowHours = 1.0 * time.Hour
-
-	// Frequency constants
+	// Frequency constants: ThresholdFrequency transactions are allowed per user
+	// in the 1h velocity window, each one after that adds RiskPerTxnAfterThreshold
 	ThresholdFrequency       = 3
 	RiskPerTxnAfterThreshold = 20.0
 
+	// Velocity windows tracked per user and per user+mode
+	VelocityWindow1m  = "1m"
+	VelocityWindow10m = "10m"
+	VelocityWindow1h  = "1h"
+	VelocityWindow24h = "24h"
+	VelocityWindow7d  = "7d"
+
+	// Default velocity limits on top of ThresholdFrequency. Every transaction
+	// past a count limit adds RiskPerTxnAfterThreshold to the frequency score.
+	VelocityLimit1mCount      = 2
+	VelocityLimit10mModeCount = 3
+	VelocityLimit24hCount     = 12
+	VelocityLimit24hModeCount = 8
+	VelocityLimit7dCount      = 50
+
+	// A payee becomes trusted, and no longer raises NEW_PAYEE, after this many
+	// legitimate payments to it
+	TrustedPayeeMinPayments = 3
+
+	// Impossible travel: moving faster than an airliner between two located
+	// transactions is suspicious. Shorter distances are ignored, GeoIP locations
+	// are only accurate to a city.
+	ImpossibleTravelMaxSpeedKmh   = 900.0
+	ImpossibleTravelMinDistanceKm = 100.0
+	EarthRadiusKm                 = 6371.0
+
+	// Redis sorted set holding a user's transactions for the velocity windows
+	VelocityKeyPrefix = "velocity:"
+
 	// Amount deviation multipliers
 	AmountDeviationModerate = 1.5 // 1.5x average is moderate risk
 	AmountDeviationHigh     = 3.0 // 3x average is high risk
 
+	// Amount scoring methods of the scoring config: a z-score on the mean and
+	// standard deviation, or a robust score on the median, MAD and percentiles
+	AmountScoringZScore  = "zscore"
+	AmountScoringRobust  = "robust"
+	DefaultAmountScoring = AmountScoringZScore
+	// Scales the MAD to the standard deviation of a normal distribution
+	MADScale = 1.4826
+
+	// Hour-of-day histograms of the profile: weights halve every
+	// HourHistogramHalfLife, and the histograms are only used once they hold
+	// at least the given weight
+	HourHistogramBuckets        = 24
+	WeekdayHourHistogramBuckets = 7 * 24
+	HourHistogramHalfLife       = 30 * 24 * time.Hour
+	HourHistogramMinWeight      = 5.0
+	WeekdayHistogramMinWeight   = 20.0
+
 	// Minimum transactions needed for reliable profiling
 	MinTransactionsForProfiling = 5
 
-	TriggerFactorsAMOUNTDEVIATION = "AMOUNT_DEVIATION"
-	TriggerFactorsFREQUENCYSPIKE  = "FREQUENCY_SPIKE"
-	TriggerFactorsNEWMODE         = "NEW_MODE"
-	TriggerFactorsTIMEANOMALY     = "TIME_ANOMALY"
+	// Decision thresholds for users without enough history to profile
+	ColdStartThresholdAllow = 60.0 // < 60: Allow
+	ColdStartThresholdFlag  = 75.0 // 60-75: Flag
+	// > 75: MFA Required
+
+	// How long the active scoring config is cached before re-reading it from the DB
+	ScoringConfigCacheTTL = 30 * time.Second
+
+	// Shadow scoring runs off the request path on at most ShadowScoringWorkers
+	// transactions at a time; busier traffic is not shadowed. Challenger
+	// results that take longer than ShadowScoringBudget are dropped, and
+	// storing one gives up after ShadowStoreTimeout.
+	ShadowScoringWorkers = 16
+	ShadowScoringBudget  = 50 * time.Millisecond
+	ShadowStoreTimeout   = 2 * time.Second
+
+	// MFA challenge settings for MFA_REQUIRED transactions
+	MFAOTPLength          = 6
+	MFAChallengeTTL       = 5 * time.Minute
+	MFAMaxAttempts        = 3
+	MFAChallengeKeyPrefix = "mfa:"
+
+	// Allow/deny list entries are cached in Redis per entity value, for at most
+	// this long and never beyond the entry's expiry
+	ListEntryCacheTTL       = 5 * time.Minute
+	ListEntryCacheKeyPrefix = "list:"
+	MaxListValueLength      = 255
+
+	// Fraud rules: the enabled rules are cached in memory for RulesCacheTTL
+	RulesCacheTTL           = 30 * time.Second
+	MaxRuleNameLength       = 100
+	MaxRuleExpressionLength = 1000
+	MaxRuleTagLength        = 50
+	MaxRuleScoreDelta       = 100.0
+
+	// Bulk upload job workers
+	BulkJobWorkers      = 2
+	BulkJobPollInterval = 2 * time.Second
+	BulkJobStaleAfter   = 2 * time.Minute // PROCESSING jobs without a heartbeat for this long are reclaimed
+	BulkJobMaxAttempts  = 5               // jobs still failing after this many claims are marked FAILED
+
+	TriggerFactorsAMOUNTDEVIATION  = "AMOUNT_DEVIATION"
+	TriggerFactorsFREQUENCYSPIKE   = "FREQUENCY_SPIKE"
+	TriggerFactorsNEWMODE          = "NEW_MODE"
+	TriggerFactorsTIMEANOMALY      = "TIME_ANOMALY"
+	TriggerFactorsNEWDEVICE        = "NEW_DEVICE"
+	TriggerFactorsIMPOSSIBLETRAVEL = "IMPOSSIBLE_TRAVEL"
+	TriggerFactorsNEWPAYEE         = "NEW_PAYEE"
+	TriggerFactorsMODELSCORE       = "MODEL_SCORE"
+
+	// Units of the observed and baseline values of factor explanations
+	UnitAmount       = "amount"
+	UnitTransactions = "transactions"
+	UnitPercent      = "percent"
+	UnitDevices      = "devices"
+	UnitKmPerHour    = "km/h"
+	UnitPayments     = "payments"
+
+	// Reason codes of the factor explanations, one per finding of a factor.
+	// Codes are stable and safe to match on; explanation texts are not.
+	ReasonAmountNoHistory     = "AMOUNT_NO_HISTORY"
+	ReasonAmountAboveMaxSeen  = "AMOUNT_ABOVE_MAX_SEEN"
+	ReasonAmountWithinMaxSeen = "AMOUNT_WITHIN_MAX_SEEN"
+	ReasonAmountAboveUsual    = "AMOUNT_ABOVE_USUAL"
+	ReasonAmountUsual         = "AMOUNT_USUAL"
+	ReasonVelocityOverLimit   = "VELOCITY_OVER_LIMIT"
+	ReasonVelocityNormal      = "VELOCITY_NORMAL"
+	ReasonModeUnregistered    = "MODE_UNREGISTERED"
+	ReasonModeRegistered      = "MODE_REGISTERED"
+	ReasonHourNoHistory       = "HOUR_NO_HISTORY"
+	ReasonHourUnusual         = "HOUR_UNUSUAL"
+	ReasonHourUsual           = "HOUR_USUAL"
+	ReasonDeviceMissing       = "DEVICE_MISSING"
+	ReasonDeviceNoHistory     = "DEVICE_NO_HISTORY"
+	ReasonDeviceKnown         = "DEVICE_KNOWN"
+	ReasonDeviceNew           = "DEVICE_NEW"
+	ReasonLocationMissing     = "LOCATION_MISSING"
+	ReasonLocationNoHistory   = "LOCATION_NO_HISTORY"
+	ReasonTravelPlausible     = "TRAVEL_PLAUSIBLE"
+	ReasonTravelImpossible    = "TRAVEL_IMPOSSIBLE"
+	ReasonPayeeMissing        = "PAYEE_MISSING"
+	ReasonPayeeNoHistory      = "PAYEE_NO_HISTORY"
+	ReasonPayeeTrusted        = "PAYEE_TRUSTED"
+	ReasonPayeeUnfamiliar     = "PAYEE_UNFAMILIAR"
+	ReasonPayeeNew            = "PAYEE_NEW"
+	ReasonListAllow           = "LIST_ALLOW"
+	ReasonListDeny            = "LIST_DENY"
+	ReasonModelLikelyFraud    = "MODEL_LIKELY_FRAUD"
+	ReasonModelLikelyLegit    = "MODEL_LIKELY_LEGITIMATE"
+
+	// Model explanations name the features contributing most to the prediction
+	ModelExplanationFeatures = 3
 )
@@ -26,6 +26,8 @@ var (
 	ErrEmptyToken              = errors.New("empty token")
 	ErrInvalidToken            = errors.New("invalid token")
 	ErrExpiredToken            = errors.New("expired token")
+	ErrForbidden               = errors.New("role not allowed to access this resource")
+	ErrInvalidRole             = errors.New("role should be CUSTOMER, ANALYST or ADMIN")
 	ErrLogoutFailed            = errors.New("token blacklisting failed")
 	ErrAuthServiceUnavailable  = errors.New("redis down for authentication")
 	ErrAuthInternalService     = errors.New("error in auth package")
@@ -46,3 +48,104 @@ var (
 var (
 	ErrBackgroundJobFailed = errors.New("error in profile updating job")
 )
+
+// Scoring related errors
+var (
+	ErrDuplicateRiskFactor   = errors.New("risk factor with given name already registered")
+	ErrInvalidRiskFactor     = errors.New("risk factor needs a name, a non-negative weight and a 0-100 threshold")
+	ErrUnknownRiskFactor     = errors.New("config references an unregistered risk factor")
+	ErrInvalidScoringConfig  = errors.New("invalid scoring config")
+	ErrScoringConfigNotFound = errors.New("scoring config version not found")
+	ErrShadowConfigActive    = errors.New("the active scoring config cannot be shadowed")
+)
+
+// MFA related errors
+var (
+	ErrMissingOTPInRequest = errors.New("missing otp in request body")
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrMFANotPending       = errors.New("transaction is not awaiting mfa verification")
+	ErrInvalidOTP          = errors.New("invalid otp")
+)
+
+// Case management errors
+var (
+	ErrCaseNotFound        = errors.New("fraud case not found")
+	ErrCaseAlreadyResolved = errors.New("fraud case is already resolved")
+	ErrInvalidCaseStatus   = errors.New("invalid case status")
+	ErrInvalidDisposition  = errors.New("disposition should be CONFIRMED_FRAUD or FALSE_POSITIVE")
+	ErrInvalidAssignee     = errors.New("cases can only be assigned to analysts or admins")
+)
+
+// Allow/deny list errors
+var (
+	ErrListEntryNotFound = errors.New("list entry not found")
+	ErrInvalidListType   = errors.New("list_type should be ALLOW or DENY")
+	ErrInvalidListEntity = errors.New("entity should be USER, PAYEE, DEVICE or IP")
+	ErrInvalidListValue  = errors.New("value should be a user id, payee id, device id or IP address of at most 255 characters")
+	ErrInvalidListExpiry = errors.New("expires_at should be in the future")
+)
+
+// Rule errors
+var (
+	ErrRuleNotFound          = errors.New("rule not found")
+	ErrInvalidRuleName       = errors.New("name should be between 1 and 100 characters")
+	ErrInvalidRuleExpression = errors.New("invalid rule expression")
+	ErrInvalidRuleAction     = errors.New("action should be SET_DECISION, ADD_SCORE or ADD_TAG")
+	ErrInvalidRuleDecision   = errors.New("SET_DECISION rules need a decision of ALLOW, FLAG, MFA_REQUIRED or BLOCK")
+	ErrInvalidRuleScoreDelta = errors.New("ADD_SCORE rules need a non-zero score_delta between -100 and 100")
+	ErrInvalidRuleTag        = errors.New("ADD_TAG rules need a tag of at most 50 characters")
+)
+
+// Bulk job errors
+var (
+	ErrBulkJobNotFound         = errors.New("bulk job not found")
+	ErrBulkJobAttemptsExceeded = errors.New("bulk job failed too many times")
+	ErrBulkRowMalformed        = errors.New("row should have amount, mode and created_at columns")
+	ErrBulkRowInvalidAmount    = errors.New("amount should be a positive number")
+	ErrBulkRowInvalidTimestamp = errors.New("created_at should be an RFC3339 timestamp")
+	ErrInvalidReportFormat     = errors.New("format should be csv or json")
+)
+
+// Idempotency errors
+var (
+	ErrInvalidExternalReference = errors.New("idempotency key or external reference should be at most 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different transaction")
+	ErrDuplicateReference       = errors.New("external reference was already used")
+)
+
+// Timezone errors
+var (
+	ErrInvalidTimezone  = errors.New("timezone should be an IANA name such as Asia/Kolkata")
+	ErrInvalidUTCOffset = errors.New("utc_offset_minutes should be in range -720 to 840")
+)
+
+// Device fingerprint errors
+var (
+	ErrInvalidDeviceID  = errors.New("device_id should be at most 128 characters")
+	ErrInvalidIPAddress = errors.New("ip_address should be an IPv4 or IPv6 address")
+	ErrInvalidUserAgent = errors.New("user_agent should be at most 512 characters")
+)
+
+// Payee errors
+var (
+	ErrInvalidPayeeID = errors.New("payee_id should be at most 255 characters")
+)
+
+// Location errors
+var (
+	ErrInvalidCoordinates = errors.New("latitude and longitude should be sent together, in range -90 to 90 and -180 to 180")
+)
+
+// Backtest errors
+var (
+	ErrInvalidBacktestUserID   = errors.New("user_id should be a positive integer")
+	ErrInvalidBacktestDecision = errors.New("decision should be empty, ALLOW, FLAG, MFA_REQUIRED or BLOCK")
+	ErrInvalidBacktestLabel    = errors.New("fraud_label should be empty, CONFIRMED_FRAUD or FALSE_POSITIVE")
+)
+
+// Model errors
+var (
+	ErrInvalidModel             = errors.New("invalid model file")
+	ErrUnknownModelFeature      = errors.New("model uses an unknown feature")
+	ErrInsufficientTrainingData = errors.New("training needs both fraud and legitimate transactions")
+)
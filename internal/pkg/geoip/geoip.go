@@ -0,0 +1,83 @@
+package geoip
+
+import (
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+	"go.uber.org/zap"
+)
+
+// Location is the approximate position of an IP address
+type Location struct {
+	Latitude  float64
+	Longitude float64
+	Country   string
+}
+
+// Locator resolves IP addresses to locations
+type Locator interface {
+	Locate(addr netip.Addr) (Location, bool)
+}
+
+// New returns a locator backed by the GeoIP database at path. Without a path,
+// or when the database cannot be loaded, IP addresses are not resolved.
+func New(path string, logger *zap.Logger) Locator {
+	if path == "" {
+		return NopLocator{}
+	}
+
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		logger.Error("failed to load geoip database", zap.String("path", path), zap.Error(err))
+		return NopLocator{}
+	}
+	return NewDatabaseLocator(reader, logger)
+}
+
+// NopLocator resolves nothing, for deployments without a GeoIP database
+type NopLocator struct{}
+
+func (NopLocator) Locate(netip.Addr) (Location, bool) {
+	return Location{}, false
+}
+
+// DatabaseLocator reads locations from a MaxMind City database
+type DatabaseLocator struct {
+	reader *maxminddb.Reader
+	logger *zap.Logger
+}
+
+// cityRecord holds the fields of a City database record used for locating
+type cityRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+func NewDatabaseLocator(reader *maxminddb.Reader, logger *zap.Logger) *DatabaseLocator {
+	return &DatabaseLocator{reader: reader, logger: logger}
+}
+
+// Locate returns the location of the address's network. Networks without
+// coordinates, such as anonymous proxies, are not located.
+func (l *DatabaseLocator) Locate(addr netip.Addr) (Location, bool) {
+	var record cityRecord
+	result := l.reader.Lookup(addr.Unmap())
+	if err := result.Decode(&record); err != nil {
+		l.logger.Error("failed to look up ip address", zap.String("ip_address", addr.String()), zap.Error(err))
+		return Location{}, false
+	}
+
+	if !result.Found() || record.Location.Latitude == nil || record.Location.Longitude == nil {
+		return Location{}, false
+	}
+	return Location{
+		Latitude:  *record.Location.Latitude,
+		Longitude: *record.Location.Longitude,
+		Country:   record.Country.ISOCode,
+	}, true
+}
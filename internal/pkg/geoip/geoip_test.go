@@ -0,0 +1,180 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"math"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MaxMind DB format details needed to build test databases
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+const dataSectionSeparator = 16
+
+const (
+	typePointer = 1
+	typeString  = 2
+	typeDouble  = 3
+	typeUint16  = 5
+	typeUint32  = 6
+	typeMap     = 7
+)
+
+// testDB builds a minimal IPv4 MaxMind DB with 24 bit records mapping each
+// prefix to its data section record
+type testDB struct {
+	nodes [][2]int // negative values index data records, 0 is empty
+	data  []byte
+}
+
+func (db *testDB) insert(prefix netip.Prefix, record []byte) {
+	if len(db.nodes) == 0 {
+		db.nodes = append(db.nodes, [2]int{})
+	}
+	offset := len(db.data)
+	db.data = append(db.data, record...)
+
+	ip, node := prefix.Addr().As4(), 0
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := int(ip[i/8]>>(7-i%8)) & 1
+		if i == prefix.Bits()-1 {
+			db.nodes[node][bit] = -(offset + 1)
+			break
+		}
+		if db.nodes[node][bit] <= 0 {
+			db.nodes = append(db.nodes, [2]int{})
+			db.nodes[node][bit] = len(db.nodes) - 1
+		}
+		node = db.nodes[node][bit]
+	}
+}
+
+func (db *testDB) bytes() []byte {
+	nodeCount := len(db.nodes)
+	record := func(v int) int {
+		switch {
+		case v < 0:
+			return nodeCount + dataSectionSeparator + (-v - 1)
+		case v == 0:
+			return nodeCount
+		default:
+			return v
+		}
+	}
+
+	var buf []byte
+	for _, n := range db.nodes {
+		for _, v := range n {
+			r := record(v)
+			buf = append(buf, byte(r>>16), byte(r>>8), byte(r))
+		}
+	}
+	buf = append(buf, make([]byte, dataSectionSeparator)...)
+	buf = append(buf, db.data...)
+	buf = append(buf, metadataMarker...)
+	buf = append(buf, encodeMap(
+		"binary_format_major_version", encodeUint(typeUint16, 2),
+		"binary_format_minor_version", encodeUint(typeUint16, 0),
+		"node_count", encodeUint(typeUint32, uint64(nodeCount)),
+		"record_size", encodeUint(typeUint16, 24),
+		"ip_version", encodeUint(typeUint16, 4),
+		"database_type", encodeString("Test-City"),
+	)...)
+	return buf
+}
+
+func encodeString(s string) []byte {
+	return append([]byte{byte(typeString<<5 | len(s))}, s...)
+}
+
+func encodeDouble(f float64) []byte {
+	return binary.BigEndian.AppendUint64([]byte{typeDouble<<5 | 8}, math.Float64bits(f))
+}
+
+func encodeUint(typ int, v uint64) []byte {
+	b := binary.BigEndian.AppendUint64(nil, v)
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	return append([]byte{byte(typ<<5 | len(b))}, b...)
+}
+
+func encodePointer(offset int) []byte {
+	return []byte{typePointer<<5 | byte(offset>>8), byte(offset)}
+}
+
+// encodeMap takes alternating keys and encoded values
+func encodeMap(pairs ...any) []byte {
+	b := []byte{byte(typeMap<<5 | len(pairs)/2)}
+	for i := 0; i < len(pairs); i += 2 {
+		b = append(b, encodeString(pairs[i].(string))...)
+		b = append(b, pairs[i+1].([]byte)...)
+	}
+	return b
+}
+
+func encodeCity(lat, lon float64, country []byte) []byte {
+	return encodeMap(
+		"country", country,
+		"location", encodeMap("latitude", encodeDouble(lat), "longitude", encodeDouble(lon)),
+	)
+}
+
+func TestReaderLookup(t *testing.T) {
+	db := &testDB{}
+	db.insert(netip.MustParsePrefix("203.0.113.0/24"), encodeCity(19.07, 72.87, encodeMap("iso_code", encodeString("IN"))))
+	// the second record points at the first record's country map
+	db.insert(netip.MustParsePrefix("198.51.100.0/24"), encodeCity(28.61, 77.20, encodePointer(1+len(encodeString("country")))))
+	db.insert(netip.MustParsePrefix("192.0.2.0/25"), encodeMap("country", encodeMap("iso_code", encodeString("US"))))
+
+	reader, err := maxminddb.OpenBytes(db.bytes())
+	require.NoError(t, err)
+	locator := NewDatabaseLocator(reader, zap.NewNop())
+
+	loc, ok := locator.Locate(netip.MustParseAddr("203.0.113.7"))
+	assert.True(t, ok)
+	assert.Equal(t, Location{Latitude: 19.07, Longitude: 72.87, Country: "IN"}, loc)
+
+	loc, ok = locator.Locate(netip.MustParseAddr("::ffff:198.51.100.200"))
+	assert.True(t, ok)
+	assert.Equal(t, Location{Latitude: 28.61, Longitude: 77.20, Country: "IN"}, loc)
+
+	_, ok = locator.Locate(netip.MustParseAddr("192.0.2.1"))
+	assert.False(t, ok, "record without coordinates")
+
+	_, ok = locator.Locate(netip.MustParseAddr("192.0.2.200"))
+	assert.False(t, ok, "outside every network")
+
+	_, ok = locator.Locate(netip.MustParseAddr("2001:db8::1"))
+	assert.False(t, ok, "IPv6 address in an IPv4 database")
+}
+
+func TestNew(t *testing.T) {
+	assert.IsType(t, NopLocator{}, New("", zap.NewNop()))
+	assert.IsType(t, NopLocator{}, New(filepath.Join(t.TempDir(), "missing.mmdb"), zap.NewNop()))
+
+	path := filepath.Join(t.TempDir(), "invalid.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o600))
+	assert.IsType(t, NopLocator{}, New(path, zap.NewNop()))
+}
+
+func TestLocateCorruptRecord(t *testing.T) {
+	db := &testDB{}
+	// a record pointing at itself must fail instead of recursing forever
+	db.insert(netip.MustParsePrefix("203.0.113.0/24"), encodePointer(0))
+
+	reader, err := maxminddb.OpenBytes(db.bytes())
+	require.NoError(t, err)
+	locator := NewDatabaseLocator(reader, zap.NewNop())
+
+	_, ok := locator.Locate(netip.MustParseAddr("203.0.113.7"))
+	assert.False(t, ok)
+}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -25,11 +27,12 @@ func CheckPasswordHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func MakeJWT(userID int32, userName, email, tokenSecret string, expiresIn time.Duration) (string, error) {
+func MakeJWT(userID int32, userName, email string, role repository.UserRole, tokenSecret string, expiresIn time.Duration) (string, error) {
 	claims := specs.UserTokenClaims{
 		UserID: userID,
 		Name:   userName,
 		Email:  email,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.NewString(),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -40,6 +43,12 @@ func MakeJWT(userID int32, userName, email, tokenSecret string, expiresIn time.D
 	return token.SignedString([]byte(tokenSecret))
 }
 
+// RevokedTokensKey is the Redis key holding the unix time up to which the
+// user's tokens are revoked, e.g. because their role changed
+func RevokedTokensKey(userID int32) string {
+	return fmt.Sprintf("blacklist:user:%d", userID)
+}
+
 func GetClaimsFromRequest(r *http.Request) (*specs.UserTokenClaims, error) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
@@ -85,3 +94,21 @@ func GetIDFromRequest(r *http.Request) (int32, error) {
 	}
 	return claims.UserID, nil
 }
+
+// GetRoleFromRequest returns the caller's role. Tokens issued before roles
+// existed carry none and are treated as customers.
+func GetRoleFromRequest(r *http.Request) (repository.UserRole, error) {
+	claims, err := GetClaimsFromRequest(r)
+	if err != nil {
+		return "", err
+	}
+	if claims.Role == "" {
+		return repository.UserRoleCUSTOMER, nil
+	}
+	return claims.Role, nil
+}
+
+// CanAccessAllUsers reports whether the role may read other users' data
+func CanAccessAllUsers(role repository.UserRole) bool {
+	return role == repository.UserRoleANALYST || role == repository.UserRoleADMIN
+}
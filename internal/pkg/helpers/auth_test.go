@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,7 +31,7 @@ func TestJWTHelpers(t *testing.T) {
 	userName := "Test User"
 	email := "test@example.com"
 
-	token, err := MakeJWT(userID, userName, email, "testsecret", time.Hour)
+	token, err := MakeJWT(userID, userName, email, "ANALYST", "testsecret", time.Hour)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 
@@ -41,6 +42,11 @@ func TestJWTHelpers(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, userID, claims.UserID)
 	assert.Equal(t, userName, claims.Name)
+	assert.Equal(t, repository.UserRoleANALYST, claims.Role)
+
+	role, err := GetRoleFromRequest(req)
+	assert.NoError(t, err)
+	assert.True(t, CanAccessAllUsers(role))
 }
 
 func TestGetIDFromRequest(t *testing.T) {
@@ -56,7 +62,7 @@ func TestGetIDFromRequest(t *testing.T) {
 
 	t.Run("From token", func(t *testing.T) {
 		os.Setenv("JWT_SECRET", "testsecret")
-		token, _ := MakeJWT(1, "User", "u@e.com", "testsecret", time.Hour)
+		token, _ := MakeJWT(1, "User", "u@e.com", "CUSTOMER", "testsecret", time.Hour)
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 
@@ -5,38 +5,151 @@ import (
 	"encoding/csv"
 	"io"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
 	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/xuri/excelize/v2"
 )
 
-func ParseTransactionCSVRow(record []string) (specs.CreateBulkTransactionRequest, error) {
+// OpenBulkFile checks the header row of an uploaded CSV or XLSX file and
+// returns an iterator over its data rows. The iterator returns io.EOF after the
+// last row; closeFunc releases the underlying reader. Columns after created_at
+// are dropped unless the fourth header is external_reference.
+func OpenBulkFile(reader io.Reader, filename string) (next func() ([]string, error), closeFunc func(), err error) {
+	if strings.HasSuffix(strings.ToLower(filename), ".xlsx") {
+		f, err := excelize.OpenReader(reader)
+		if err != nil {
+			return nil, nil, errors.ErrFailureInParsingExcel
+		}
+
+		// assuming first sheet
+		rows, err := f.Rows(f.GetSheetName(0))
+		if err != nil {
+			f.Close()
+			return nil, nil, errors.ErrFailureInParsingExcel
+		}
+		closeFunc = func() {
+			rows.Close()
+			f.Close()
+		}
+
+		if !rows.Next() {
+			closeFunc()
+			return nil, nil, errors.ErrUnexpectedHeadersInFile
+		}
+		headers, err := rows.Columns()
+		if err != nil {
+			closeFunc()
+			return nil, nil, errors.ErrFailureInParsingExcel
+		}
+		if !validBulkHeaders(headers) {
+			closeFunc()
+			return nil, nil, errors.ErrUnexpectedHeadersInFile
+		}
+
+		next = func() ([]string, error) {
+			if !rows.Next() {
+				return nil, io.EOF
+			}
+			return rows.Columns()
+		}
+		return bulkColumns(headers, next), closeFunc, nil
+	}
+
+	csvReader := csv.NewReader(reader)
+	// rows with a missing column are reported per row rather than failing the file
+	csvReader.FieldsPerRecord = -1
+	headers, err := csvReader.Read()
+	if err != nil {
+		return nil, nil, errors.ErrFailureInParsingCSV
+	}
+	if !validBulkHeaders(headers) {
+		return nil, nil, errors.ErrUnexpectedHeadersInFile
+	}
+
+	return bulkColumns(headers, csvReader.Read), func() {}, nil
+}
+
+// bulkColumns trims rows to the columns named in a valid header row
+func bulkColumns(headers []string, next func() ([]string, error)) func() ([]string, error) {
+	width := 3
+	if len(headers) > 3 && strings.ToLower(strings.TrimSpace(headers[3])) == "external_reference" {
+		width = 4
+	}
+	return func() ([]string, error) {
+		record, err := next()
+		if len(record) > width {
+			record = record[:width]
+		}
+		return record, err
+	}
+}
+
+func validBulkHeaders(headers []string) bool {
+	return len(headers) >= 3 &&
+		strings.ToLower(headers[0]) == "amount" &&
+		strings.ToLower(headers[1]) == "mode" &&
+		strings.ToLower(headers[2]) == "created_at"
+}
+
+// ParseTransactionCSVRow parses an amount,mode,created_at row with an optional
+// external_reference. An unparseable created_at defaults to the current time
+// unless strict is set, in which case the row is rejected.
+func ParseTransactionCSVRow(record []string, strict bool) (specs.CreateBulkTransactionRequest, error) {
 	if len(record) < 3 {
-		return specs.CreateBulkTransactionRequest{}, errors.ErrInvalidBody
+		return specs.CreateBulkTransactionRequest{}, errors.ErrBulkRowMalformed
 	}
 
-	amount, err := strconv.ParseFloat(record[0], 64)
-	if err != nil || amount <= 0 {
-		return specs.CreateBulkTransactionRequest{}, errors.ErrInvalidBody
+	amount, err := strconv.ParseFloat(strings.TrimSpace(record[0]), 64)
+	if err != nil || amount <= 0 || amount > 1e16 {
+		return specs.CreateBulkTransactionRequest{}, errors.ErrBulkRowInvalidAmount
 	}
 
-	mode := record[1]
+	mode := strings.ToUpper(strings.TrimSpace(record[1]))
+	switch repository.Mode(mode) {
+	case repository.ModeUPI, repository.ModeCARD, repository.ModeNETBANKING:
+	default:
+		return specs.CreateBulkTransactionRequest{}, errors.ErrInvalidPaymentMode
+	}
 
-	createdAt, err := time.Parse(time.RFC3339, record[2])
-	if err != nil {
-		createdAt = time.Now()
+	createdAt, ok := BulkRowTimestamp(record)
+	if !ok {
+		if strict {
+			return specs.CreateBulkTransactionRequest{}, errors.ErrBulkRowInvalidTimestamp
+		}
+		createdAt = time.Now().UTC()
+	}
+
+	var reference string
+	if len(record) > 3 {
+		reference = strings.TrimSpace(record[3])
+		if len(reference) > constants.MaxExternalReferenceLength {
+			return specs.CreateBulkTransactionRequest{}, errors.ErrInvalidExternalReference
+		}
 	}
 
 	return specs.CreateBulkTransactionRequest{
-		Amount:    amount,
-		Mode:      mode,
-		CreatedAt: createdAt,
+		Amount:            amount,
+		Mode:              mode,
+		CreatedAt:         createdAt,
+		ExternalReference: reference,
 	}, nil
 }
 
+// BulkRowTimestamp parses the created_at column of a bulk row
+func BulkRowTimestamp(record []string) (time.Time, bool) {
+	if len(record) < 3 {
+		return time.Time{}, false
+	}
+	createdAt, err := time.Parse(time.RFC3339, strings.TrimSpace(record[2]))
+	return createdAt, err == nil
+}
+
 func NewEmptyUserProfile(userID int32) *repository.UserProfileBehavior {
 	return &repository.UserProfileBehavior{
 		UserID:                            userID,
@@ -53,11 +166,15 @@ func MapDBProfileToDomain(p repository.GetUserProfileByUserIDRow) *repository.Us
 	return &repository.UserProfileBehavior{
 		UserID:                            p.UserID,
 		AverageTransactionAmount:          p.AverageTransactionAmount,
+		StdDevTransactionAmount:           p.StdDevTransactionAmount,
 		AverageNumberOfTransactionsPerDay: p.AverageNumberOfTransactionsPerDay,
 		MaxTransactionAmountSeen:          p.MaxTransactionAmountSeen,
 		RegisteredPaymentModes:            GetModeSliceFromStringSlice(p.RegisteredPaymentModes),
-		UsualTransactionStartHour:         p.UsualTransactionStartHour,
-		UsualTransactionEndHour:           p.UsualTransactionEndHour,
+		HourHistogram:                     p.HourHistogram,
+		WeekdayHourHistogram:              p.WeekdayHourHistogram,
+		HistogramDecayedAt:                p.HistogramDecayedAt,
+		AmountStats:                       p.AmountStats,
+		ModeAmountStats:                   p.ModeAmountStats,
 		TotalTransactions:                 p.TotalTransactions,
 		AllowedTransactions:               p.AllowedTransactions,
 		UpdatedAt:                         p.UpdatedAt,
@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenBulkFile(t *testing.T) {
+	t.Run("CSV rows", func(t *testing.T) {
+		next, closeFunc, err := OpenBulkFile(strings.NewReader("amount,mode,created_at\n500,upi,2023-10-01T10:00:00Z\n100,CARD\n"), "upload.csv")
+		assert.NoError(t, err)
+		defer closeFunc()
+
+		record, err := next()
+		assert.NoError(t, err)
+		req, err := ParseTransactionCSVRow(record, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 500.0, req.Amount)
+		assert.Equal(t, "UPI", req.Mode)
+
+		record, err = next()
+		assert.NoError(t, err)
+		_, err = ParseTransactionCSVRow(record, false)
+		assert.ErrorIs(t, err, errors.ErrBulkRowMalformed)
+
+		_, err = next()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("External reference column", func(t *testing.T) {
+		next, closeFunc, err := OpenBulkFile(strings.NewReader("amount,mode,created_at,external_reference\n500,UPI,2023-10-01T10:00:00Z, ref-1 \n"), "upload.csv")
+		assert.NoError(t, err)
+		defer closeFunc()
+
+		record, err := next()
+		assert.NoError(t, err)
+		req, err := ParseTransactionCSVRow(record, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "ref-1", req.ExternalReference)
+	})
+
+	t.Run("Unknown extra columns are dropped", func(t *testing.T) {
+		next, closeFunc, err := OpenBulkFile(strings.NewReader("amount,mode,created_at,note\n500,UPI,2023-10-01T10:00:00Z,rent\n"), "upload.csv")
+		assert.NoError(t, err)
+		defer closeFunc()
+
+		record, err := next()
+		assert.NoError(t, err)
+		assert.Len(t, record, 3)
+	})
+
+	t.Run("Unexpected headers", func(t *testing.T) {
+		_, _, err := OpenBulkFile(strings.NewReader("amount,mode\n500,UPI\n"), "upload.csv")
+		assert.ErrorIs(t, err, errors.ErrUnexpectedHeadersInFile)
+	})
+
+	t.Run("Invalid excel file", func(t *testing.T) {
+		_, _, err := OpenBulkFile(strings.NewReader("not a spreadsheet"), "upload.xlsx")
+		assert.ErrorIs(t, err, errors.ErrFailureInParsingExcel)
+	})
+}
+
+func TestParseTransactionCSVRow(t *testing.T) {
+	tests := []struct {
+		name   string
+		record []string
+		strict bool
+		err    error
+	}{
+		{"Valid row", []string{"500", "UPI", "2023-10-01T10:00:00Z"}, true, nil},
+		{"Missing column", []string{"500", "UPI"}, false, errors.ErrBulkRowMalformed},
+		{"Invalid amount", []string{"abc", "UPI", "2023-10-01T10:00:00Z"}, false, errors.ErrBulkRowInvalidAmount},
+		{"Negative amount", []string{"-5", "UPI", "2023-10-01T10:00:00Z"}, false, errors.ErrBulkRowInvalidAmount},
+		{"Unknown mode", []string{"500", "CASH", "2023-10-01T10:00:00Z"}, false, errors.ErrInvalidPaymentMode},
+		{"Bad timestamp defaults", []string{"500", "UPI", "yesterday"}, false, nil},
+		{"Bad timestamp in strict mode", []string{"500", "UPI", "yesterday"}, true, errors.ErrBulkRowInvalidTimestamp},
+		{"External reference", []string{"500", "UPI", "2023-10-01T10:00:00Z", "ref-1"}, false, nil},
+		{"External reference too long", []string{"500", "UPI", "2023-10-01T10:00:00Z", strings.Repeat("r", 256)}, false, errors.ErrInvalidExternalReference},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTransactionCSVRow(tt.record, tt.strict)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/model"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+// modelFeature is an input a model file can use, computed from what the
+// other factors see
+type modelFeature struct {
+	name  string
+	value func(txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory) float64
+}
+
+// modelFeatures are the model inputs in vector order. Names are part of the
+// model file format: rename none and add new ones at the end.
+var modelFeatures = []modelFeature{
+	{"log_amount", func(txn *specs.TransactionInput, _ *repository.UserProfileBehavior, _ *specs.TransactionHistory) float64 {
+		return math.Log1p(txn.Amount)
+	}},
+	{"amount_to_average", func(txn *specs.TransactionInput, profile *repository.UserProfileBehavior, _ *specs.TransactionHistory) float64 {
+		return amountRatio(txn.Amount, profile.AverageTransactionAmount.Float64, profile.AverageTransactionAmount.Valid)
+	}},
+	{"amount_to_max_seen", func(txn *specs.TransactionInput, profile *repository.UserProfileBehavior, _ *specs.TransactionHistory) float64 {
+		return amountRatio(txn.Amount, profile.MaxTransactionAmountSeen.Float64, profile.MaxTransactionAmountSeen.Valid)
+	}},
+	{"log_profile_transactions", func(_ *specs.TransactionInput, profile *repository.UserProfileBehavior, _ *specs.TransactionHistory) float64 {
+		return math.Log1p(float64(profile.TotalTransactions))
+	}},
+	{"new_mode", func(txn *specs.TransactionInput, profile *repository.UserProfileBehavior, _ *specs.TransactionHistory) float64 {
+		return indicator(!slices.Contains(profile.RegisteredPaymentModes, txn.Mode))
+	}},
+	{"hour_density", func(txn *specs.TransactionInput, profile *repository.UserProfileBehavior, _ *specs.TransactionHistory) float64 {
+		// without enough history every hour is usual
+		if density, ok := HistogramDensity(profile.HourHistogram, txn.CreatedAt.Hour(), constants.HourHistogramMinWeight); ok {
+			return density
+		}
+		return 1
+	}},
+	{"night", func(txn *specs.TransactionInput, _ *repository.UserProfileBehavior, _ *specs.TransactionHistory) float64 {
+		return indicator(txn.CreatedAt.Hour() < 6)
+	}},
+	{"count_1h", func(_ *specs.TransactionInput, _ *repository.UserProfileBehavior, history *specs.TransactionHistory) float64 {
+		return float64(history.VelocityWindow(constants.VelocityWindow1h).Count + 1)
+	}},
+	{"count_24h", func(_ *specs.TransactionInput, _ *repository.UserProfileBehavior, history *specs.TransactionHistory) float64 {
+		return float64(history.VelocityWindow(constants.VelocityWindow24h).Count + 1)
+	}},
+	{"log_amount_24h", func(txn *specs.TransactionInput, _ *repository.UserProfileBehavior, history *specs.TransactionHistory) float64 {
+		return math.Log1p(history.VelocityWindow(constants.VelocityWindow24h).Amount + txn.Amount)
+	}},
+	{"new_device", func(txn *specs.TransactionInput, _ *repository.UserProfileBehavior, history *specs.TransactionHistory) float64 {
+		return indicator(txn.DeviceID != "" && !history.Devices.Known && history.Devices.KnownDevices > 0)
+	}},
+	{"new_payee", func(txn *specs.TransactionInput, _ *repository.UserProfileBehavior, history *specs.TransactionHistory) float64 {
+		return indicator(txn.PayeeID != "" && history.Payees.Payments == 0 && history.Payees.KnownPayees > 0)
+	}},
+	{"log_travel_speed_kmh", func(txn *specs.TransactionInput, _ *repository.UserProfileBehavior, history *specs.TransactionHistory) float64 {
+		if txn.Location == nil || history.PreviousLocation == nil {
+			return 0
+		}
+		elapsed := txn.CreatedAt.Sub(history.PreviousLocation.CreatedAt).Hours()
+		if elapsed <= 0 {
+			return 0
+		}
+		return math.Log1p(DistanceKm(history.PreviousLocation.GeoPoint, *txn.Location) / elapsed)
+	}},
+}
+
+// amountRatio compares an amount with a profile amount, 1 when there is none
+func amountRatio(amount float64, baseline float64, valid bool) float64 {
+	if !valid || baseline <= 0 {
+		return 1
+	}
+	return amount / baseline
+}
+
+func indicator(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ModelFeatureNames lists the features a model file can use, in the order of
+// ModelFeatures
+func ModelFeatureNames() []string {
+	names := make([]string, len(modelFeatures))
+	for i, f := range modelFeatures {
+		names[i] = f.name
+	}
+	return names
+}
+
+// ModelFeatures builds the feature vector of a transaction
+func ModelFeatures(txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory) []float64 {
+	x := make([]float64, len(modelFeatures))
+	for i, f := range modelFeatures {
+		x[i] = f.value(txn, profile, history)
+	}
+	return x
+}
+
+// RegisterModelFactor adds the MODEL_SCORE factor, scoring transactions with
+// the model's fraud probability. The model must be bound to
+// ModelFeatureNames.
+func RegisterModelFactor(m *model.Model) error {
+	return RegisterRiskFactor(modelFactor{model: m}, constants.WeightModelScore, constants.ThresholdModelScore)
+}
+
+// RegisterModelFile loads a model file and registers it as the MODEL_SCORE
+// factor
+func RegisterModelFile(path string) error {
+	m, err := model.LoadFile(path, ModelFeatureNames())
+	if err != nil {
+		return err
+	}
+	return RegisterModelFactor(m)
+}
+
+type modelFactor struct {
+	model *model.Model
+}
+
+func (modelFactor) Name() string {
+	return constants.TriggerFactorsMODELSCORE
+}
+
+func (f modelFactor) Evaluate(_ context.Context, _ *specs.ScoringConfig, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory) specs.FactorResult {
+	prediction := f.model.Predict(ModelFeatures(txn, profile, history))
+	score := prediction.Probability * 100
+
+	code := constants.ReasonModelLikelyLegit
+	if score > constants.ThresholdModelScore {
+		code = constants.ReasonModelLikelyFraud
+	}
+
+	contributions := slices.Clone(prediction.Contributions)
+	slices.SortStableFunc(contributions, func(a, b model.Contribution) int {
+		return cmp.Compare(math.Abs(b.LogOdds), math.Abs(a.LogOdds))
+	})
+	top := make([]string, 0, constants.ModelExplanationFeatures)
+	for _, c := range contributions[:min(len(contributions), constants.ModelExplanationFeatures)] {
+		top = append(top, fmt.Sprintf("%s %.2f (%+.2f)", c.Feature, c.Input, c.LogOdds))
+	}
+
+	return compared(specs.FactorResult{
+		Score:       score,
+		ReasonCode:  code,
+		Explanation: fmt.Sprintf("fraud probability %.0f%%, mostly from %s", score, strings.Join(top, ", ")),
+	}, score, constants.ThresholdModelScore, constants.UnitPercent)
+}
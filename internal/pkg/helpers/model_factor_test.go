@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/model"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelFeatures(t *testing.T) {
+	profile := NewEmptyUserProfile(1)
+	profile.AverageTransactionAmount = pgtype.Float8{Float64: 200, Valid: true}
+	profile.RegisteredPaymentModes = []repository.Mode{repository.ModeUPI}
+	txn := &specs.TransactionInput{
+		Amount:    1000,
+		Mode:      repository.ModeCARD,
+		CreatedAt: time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC),
+		DeviceID:  "d1",
+		PayeeID:   "p1",
+	}
+	history := &specs.TransactionHistory{
+		Velocity: []specs.VelocityStats{{Window: constants.VelocityWindow1h, Count: 2}},
+		Devices:  specs.DeviceHistory{KnownDevices: 1},
+		Payees:   specs.PayeeHistory{Payments: 3, KnownPayees: 2},
+	}
+
+	features := map[string]float64{}
+	x := ModelFeatures(txn, profile, history)
+	for i, name := range ModelFeatureNames() {
+		features[name] = x[i]
+	}
+
+	assert.Len(t, features, len(x))
+	assert.Equal(t, 5.0, features["amount_to_average"])
+	assert.Equal(t, 1.0, features["amount_to_max_seen"])
+	assert.Equal(t, 1.0, features["new_mode"])
+	assert.Equal(t, 1.0, features["hour_density"])
+	assert.Equal(t, 1.0, features["night"])
+	assert.Equal(t, 3.0, features["count_1h"])
+	assert.Equal(t, 1.0, features["count_24h"])
+	assert.Equal(t, 1.0, features["new_device"])
+	assert.Zero(t, features["new_payee"])
+	assert.Zero(t, features["log_travel_speed_kmh"])
+}
+
+func TestModelFactor(t *testing.T) {
+	m, err := model.Load(strings.NewReader(`{
+		"type": "logistic",
+		"features": ["night", "new_device", "amount_to_average"],
+		"intercept": -2,
+		"weights": [1.5, 2, 0.1]
+	}`), ModelFeatureNames())
+	require.NoError(t, err)
+
+	require.NoError(t, RegisterModelFactor(m))
+	defer UnregisterRiskFactor(constants.TriggerFactorsMODELSCORE)
+
+	txn := &specs.TransactionInput{
+		Amount:    100,
+		Mode:      repository.ModeUPI,
+		CreatedAt: time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC),
+		DeviceID:  "d2",
+	}
+	history := &specs.TransactionHistory{Devices: specs.DeviceHistory{KnownDevices: 1}}
+
+	t.Run("explains without weight", func(t *testing.T) {
+		result := AnalyzeTransaction(context.Background(), DefaultScoringConfig(), txn, NewEmptyUserProfile(1), history)
+
+		assert.Len(t, result.Factors, 8)
+		var factor specs.FactorResult
+		for _, f := range result.Factors {
+			if f.Name == constants.TriggerFactorsMODELSCORE {
+				factor = f
+			}
+		}
+		// log-odds -2 + 1.5 + 2 + 0.1
+		assert.InDelta(t, 83.2, factor.Score, 0.1)
+		assert.Zero(t, factor.Contribution)
+		assert.True(t, factor.Triggered)
+		assert.Equal(t, constants.ReasonModelLikelyFraud, factor.ReasonCode)
+		assert.Equal(t, "fraud probability 83%, mostly from new_device 1.00 (+2.00), night 1.00 (+1.50), amount_to_average 1.00 (+0.10)", factor.Explanation)
+	})
+
+	t.Run("weighted by a scoring config", func(t *testing.T) {
+		cfg := DefaultScoringConfig()
+		cfg.Factors[constants.TriggerFactorsMODELSCORE] = specs.FactorConfig{Weight: 0.5, Threshold: 90}
+		require.NoError(t, ValidateScoringConfig(*cfg))
+
+		result := AnalyzeTransaction(context.Background(), cfg, txn, NewEmptyUserProfile(1), history)
+		assert.NotContains(t, result.TriggeredFactors, constants.TriggerFactorsMODELSCORE)
+		// renormalized over the factors that apply, the model's included
+		total := ApplicableWeight(result.Factors)
+		assert.InDelta(t, 83.2*0.5/total, result.Factors[len(result.Factors)-1].Contribution, 0.1)
+	})
+}
@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+// GenerateOTP returns a random numeric one-time password of the given length
+func GenerateOTP(length int) (string, error) {
+	otp := make([]byte, length)
+	for i := range otp {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		otp[i] = byte('0' + n.Int64())
+	}
+	return string(otp), nil
+}
+
+// HashOTP hashes an OTP together with the transaction it was issued for, so the
+// plain code never has to be stored
+func HashOTP(txnID int32, otp string) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d:%s", txnID, otp))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckOTP reports whether otp matches the stored hash for the transaction
+func CheckOTP(txnID int32, otp string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashOTP(txnID, otp)), []byte(hash)) == 1
+}
@@ -0,0 +1,20 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTPHelpers(t *testing.T) {
+	otp, err := GenerateOTP(6)
+	assert.NoError(t, err)
+	assert.Len(t, otp, 6)
+	assert.Regexp(t, "^[0-9]{6}$", otp)
+
+	hash := HashOTP(42, otp)
+	assert.NotContains(t, hash, otp)
+	assert.True(t, CheckOTP(42, otp, hash))
+	assert.False(t, CheckOTP(43, otp, hash))
+	assert.False(t, CheckOTP(42, "abcdef", hash))
+}
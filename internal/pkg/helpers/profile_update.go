@@ -1,13 +1,21 @@
 package helpers
 
 import (
+	"encoding/json"
+	"math"
 	"slices"
 	"time"
 
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
 	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// applyTransactionToProfile mutates the in-memory profile after a transaction
+// ApplyTransactionToProfile folds a stored transaction into the in-memory
+// profile: it is counted, and legitimate ones also update the amount mean and
+// standard deviation (overall and per mode), the modes and the hour
+// histograms. Median, MAD and percentiles are only recomputed by a rebuild.
 func ApplyTransactionToProfile(
 	profile *repository.UserProfileBehavior,
 	amount float64,
@@ -17,29 +25,43 @@ func ApplyTransactionToProfile(
 ) {
 	profile.TotalTransactions++
 
+	// like the rebuild, the largest amount counts every transaction
+	if !profile.MaxTransactionAmountSeen.Valid ||
+		amount > profile.MaxTransactionAmountSeen.Float64 {
+		profile.MaxTransactionAmountSeen.Float64 = amount
+		profile.MaxTransactionAmountSeen.Valid = true
+	}
+
 	if decision != repository.TransactionDecisionALLOW &&
 		decision != repository.TransactionDecisionFLAG {
 		return
 	}
 
-	profile.AllowedTransactions++
+	ApplyLegitimateTransactionToProfile(profile, amount, mode, createdAt)
+}
 
-	if profile.AllowedTransactions == 1 {
-		profile.AverageTransactionAmount.Float64 = amount
-		profile.AverageTransactionAmount.Valid = true
-	} else {
-		prevAvg := profile.AverageTransactionAmount.Float64
-		n := float64(profile.AllowedTransactions)
+// ApplyLegitimateTransactionToProfile folds a legitimate transaction into the
+// profile's allowed count, amount statistics, modes and hour histograms. It
+// does not count the transaction itself, so it also applies a transaction
+// counted earlier under another decision, such as one allowed after MFA.
+func ApplyLegitimateTransactionToProfile(
+	profile *repository.UserProfileBehavior,
+	amount float64,
+	mode repository.Mode,
+	createdAt time.Time,
+) {
+	profile.AllowedTransactions++
 
-		profile.AverageTransactionAmount.Float64 =
-			((prevAvg * (n - 1)) + amount) / n
-	}
+	mean, stdDev := welfordUpdate(
+		int64(profile.AllowedTransactions),
+		profile.AverageTransactionAmount.Float64,
+		profile.StdDevTransactionAmount.Float64,
+		amount,
+	)
+	profile.AverageTransactionAmount = pgtype.Float8{Float64: mean, Valid: true}
+	profile.StdDevTransactionAmount = pgtype.Float8{Float64: stdDev, Valid: true}
 
-	if !profile.MaxTransactionAmountSeen.Valid ||
-		amount > profile.MaxTransactionAmountSeen.Float64 {
-		profile.MaxTransactionAmountSeen.Float64 = amount
-		profile.MaxTransactionAmountSeen.Valid = true
-	}
+	addToModeAmountStats(profile, mode, amount)
 
 	if !slices.Contains(profile.RegisteredPaymentModes, mode) {
 		profile.RegisteredPaymentModes = append(
@@ -48,19 +70,97 @@ func ApplyTransactionToProfile(
 		)
 	}
 
-	hour := createdAt.Hour()
+	addToHourHistograms(profile, createdAt)
+}
+
+// welfordUpdate adds x to a running mean and sample standard deviation
+// (matching Postgres' STDDEV) that now cover count values, x included
+func welfordUpdate(count int64, mean, stdDev, x float64) (float64, float64) {
+	if count <= 1 {
+		return x, 0
+	}
+
+	n := float64(count)
+	// sum of squared differences from the mean of the count-1 previous values
+	m2 := stdDev * stdDev * (n - 2)
+	delta := x - mean
+	mean += delta / n
+	m2 += delta * (x - mean)
+	return mean, math.Sqrt(max(m2, 0) / (n - 1))
+}
+
+// addToModeAmountStats updates the count, mean and standard deviation of the
+// mode's amount statistics. The robust statistics of the mode are left as they
+// were at the last rebuild.
+func addToModeAmountStats(profile *repository.UserProfileBehavior, mode repository.Mode, amount float64) {
+	byMode := map[repository.Mode]specs.AmountStats{}
+	if len(profile.ModeAmountStats) > 0 && json.Unmarshal(profile.ModeAmountStats, &byMode) != nil {
+		byMode = map[repository.Mode]specs.AmountStats{}
+	}
+
+	stats := byMode[mode]
+	stats.Count++
+	stats.Mean, stats.StdDev = welfordUpdate(stats.Count, stats.Mean, stats.StdDev, amount)
+	byMode[mode] = stats
+
+	raw, err := json.Marshal(byMode)
+	if err != nil {
+		return
+	}
+	profile.ModeAmountStats = raw
+}
+
+// addToHourHistograms counts an allowed transaction in the profile's
+// time-decayed hour histograms, bucketed by the hour of createdAt's location.
+// Weights are relative to HistogramDecayedAt, which moves forward to the newest
+// transaction; older transactions are added already decayed. The histograms are
+// copied, never modified in place.
+func addToHourHistograms(profile *repository.UserProfileBehavior, createdAt time.Time) {
+	hours := resizeHistogram(profile.HourHistogram, constants.HourHistogramBuckets)
+	weekdayHours := resizeHistogram(profile.WeekdayHourHistogram, constants.WeekdayHourHistogramBuckets)
 
-	if !profile.UsualTransactionStartHour.Valid ||
-		hour < profile.UsualTransactionStartHour.Time.Hour() {
-		profile.UsualTransactionStartHour.Time =
-			time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)
-		profile.UsualTransactionStartHour.Valid = true
+	weight := 1.0
+	decayedAt := profile.HistogramDecayedAt.Time
+	switch {
+	case !profile.HistogramDecayedAt.Valid:
+		decayedAt = createdAt
+	case createdAt.After(decayedAt):
+		factor := histogramDecay(createdAt.Sub(decayedAt))
+		for i := range hours {
+			hours[i] *= factor
+		}
+		for i := range weekdayHours {
+			weekdayHours[i] *= factor
+		}
+		decayedAt = createdAt
+	default:
+		weight = histogramDecay(decayedAt.Sub(createdAt))
 	}
 
-	if !profile.UsualTransactionEndHour.Valid ||
-		hour > profile.UsualTransactionEndHour.Time.Hour() {
-		profile.UsualTransactionEndHour.Time =
-			time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)
-		profile.UsualTransactionEndHour.Valid = true
+	hours[createdAt.Hour()] += weight
+	weekdayHours[weekdayHourBucket(createdAt)] += weight
+
+	profile.HourHistogram = hours
+	profile.WeekdayHourHistogram = weekdayHours
+	// stored without a zone, so always in UTC
+	profile.HistogramDecayedAt = pgtype.Timestamp{Time: decayedAt.UTC(), Valid: true}
+}
+
+// histogramDecay is the factor a weight shrinks by over age
+func histogramDecay(age time.Duration) float64 {
+	return math.Pow(0.5, age.Hours()/constants.HourHistogramHalfLife.Hours())
+}
+
+// weekdayHourBucket indexes the 7x24 histogram, Sunday midnight first
+func weekdayHourBucket(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// resizeHistogram returns a copy of the histogram with the given number of
+// buckets, empty when the stored one has a different size
+func resizeHistogram(histogram []float64, buckets int) []float64 {
+	if len(histogram) != buckets {
+		return make([]float64, buckets)
 	}
+	return slices.Clone(histogram)
 }
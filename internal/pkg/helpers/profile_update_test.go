@@ -0,0 +1,118 @@
+package helpers
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTransactionToProfileHistograms(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC) // a Monday
+	profile := NewEmptyUserProfile(1)
+
+	ApplyTransactionToProfile(profile, 100, repository.ModeUPI, base, repository.TransactionDecisionALLOW)
+	assert.Len(t, profile.HourHistogram, 24)
+	assert.Len(t, profile.WeekdayHourHistogram, 168)
+	assert.Equal(t, 1.0, profile.HourHistogram[10])
+	assert.Equal(t, 1.0, profile.WeekdayHourHistogram[24+10])
+
+	// a newer transaction decays the existing weights by its age
+	first := profile.HourHistogram
+	ApplyTransactionToProfile(profile, 100, repository.ModeUPI, base.Add(30*24*time.Hour+4*time.Hour), repository.TransactionDecisionALLOW)
+	assert.InDelta(t, histogramDecay(30*24*time.Hour+4*time.Hour), profile.HourHistogram[10], 0.0001)
+	assert.Equal(t, 1.0, profile.HourHistogram[14])
+	assert.Equal(t, 1.0, first[10], "histograms are copied, not modified in place")
+
+	// an older transaction is added already decayed
+	ApplyTransactionToProfile(profile, 100, repository.ModeUPI, base.Add(4*time.Hour), repository.TransactionDecisionALLOW)
+	assert.InDelta(t, 1.5, profile.HourHistogram[14], 0.0001)
+	assert.Equal(t, base.Add(30*24*time.Hour+4*time.Hour), profile.HistogramDecayedAt.Time)
+
+	// blocked transactions do not count
+	before := profile.HourHistogram
+	ApplyTransactionToProfile(profile, 100, repository.ModeUPI, base.Add(31*24*time.Hour), repository.TransactionDecisionBLOCK)
+	assert.Equal(t, before, profile.HourHistogram)
+}
+
+func TestApplyTransactionToProfileAmountStats(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	profile := &repository.UserProfileBehavior{UserID: 1}
+
+	amounts := []float64{120, 80, 450, 300, 95}
+	for _, amount := range amounts {
+		ApplyTransactionToProfile(profile, amount, repository.ModeUPI, at, repository.TransactionDecisionALLOW)
+	}
+	ApplyTransactionToProfile(profile, 1000, repository.ModeCARD, at, repository.TransactionDecisionALLOW)
+	// blocked transactions only count towards the totals and the maximum
+	ApplyTransactionToProfile(profile, 5000, repository.ModeUPI, at, repository.TransactionDecisionBLOCK)
+
+	mean, stdDev := meanAndStdDev(append(slices.Clone(amounts), 1000))
+	assert.Equal(t, int32(7), profile.TotalTransactions)
+	assert.Equal(t, int32(6), profile.AllowedTransactions)
+	assert.InDelta(t, mean, profile.AverageTransactionAmount.Float64, 1e-9)
+	assert.InDelta(t, stdDev, profile.StdDevTransactionAmount.Float64, 1e-9)
+	assert.Equal(t, 5000.0, profile.MaxTransactionAmountSeen.Float64)
+
+	var byMode map[repository.Mode]specs.AmountStats
+	assert.NoError(t, json.Unmarshal(profile.ModeAmountStats, &byMode))
+	mean, stdDev = meanAndStdDev(amounts)
+	assert.Equal(t, int64(5), byMode[repository.ModeUPI].Count)
+	assert.InDelta(t, mean, byMode[repository.ModeUPI].Mean, 1e-9)
+	assert.InDelta(t, stdDev, byMode[repository.ModeUPI].StdDev, 1e-9)
+	assert.Equal(t, specs.AmountStats{Count: 1, Mean: 1000}, byMode[repository.ModeCARD])
+}
+
+func TestApplyTransactionToProfileKeepsRobustStats(t *testing.T) {
+	profile := &repository.UserProfileBehavior{
+		UserID:          1,
+		ModeAmountStats: []byte(`{"UPI":{"count":2,"mean":150,"std_dev":70.71067811865476,"median":150,"mad":50,"p90":190,"p99":199}}`),
+	}
+
+	ApplyTransactionToProfile(profile, 200, repository.ModeUPI, time.Now(), repository.TransactionDecisionFLAG)
+
+	var byMode map[repository.Mode]specs.AmountStats
+	assert.NoError(t, json.Unmarshal(profile.ModeAmountStats, &byMode))
+	mean, stdDev := meanAndStdDev([]float64{100, 200, 200})
+	assert.Equal(t, int64(3), byMode[repository.ModeUPI].Count)
+	assert.InDelta(t, mean, byMode[repository.ModeUPI].Mean, 1e-9)
+	assert.InDelta(t, stdDev, byMode[repository.ModeUPI].StdDev, 1e-9)
+	assert.Equal(t, 150.0, byMode[repository.ModeUPI].Median)
+	assert.Equal(t, 199.0, byMode[repository.ModeUPI].P99)
+}
+
+// meanAndStdDev computes the mean and sample standard deviation directly
+func meanAndStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
+
+func TestApplyLegitimateTransactionToProfile(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	profile := NewEmptyUserProfile(1)
+
+	// counted when stored as MFA_REQUIRED, learnt once allowed after MFA
+	ApplyTransactionToProfile(profile, 250, repository.ModeCARD, at, repository.TransactionDecisionMFAREQUIRED)
+	assert.Equal(t, int32(0), profile.AllowedTransactions)
+
+	ApplyLegitimateTransactionToProfile(profile, 250, repository.ModeCARD, at)
+	assert.Equal(t, int32(1), profile.TotalTransactions)
+	assert.Equal(t, int32(1), profile.AllowedTransactions)
+	assert.Equal(t, 250.0, profile.AverageTransactionAmount.Float64)
+	assert.Contains(t, profile.RegisteredPaymentModes, repository.ModeCARD)
+	assert.Equal(t, 1.0, profile.HourHistogram[10])
+}
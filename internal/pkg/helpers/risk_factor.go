@@ -0,0 +1,442 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+// RiskFactor is a single fraud signal evaluated against a transaction.
+// Factors are added to the scoring pipeline with RegisterRiskFactor.
+type RiskFactor interface {
+	// Name identifies the factor in triggered_factors and factor_scores
+	Name() string
+	// Evaluate scores the transaction on a 0-100 scale
+	Evaluate(ctx context.Context, cfg *specs.ScoringConfig, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory) specs.FactorResult
+}
+
+type registeredRiskFactor struct {
+	factor    RiskFactor
+	weight    float64
+	threshold float64
+}
+
+var (
+	riskFactors      []registeredRiskFactor
+	riskFactorsMutex = &sync.RWMutex{}
+)
+
+func init() {
+	mustRegisterRiskFactor(amountDeviationFactor{}, constants.WeightAmountDeviation, constants.ThresholdAmountDeviation)
+	mustRegisterRiskFactor(frequencySpikeFactor{}, constants.WeightFrequencySpike, constants.ThresholdFrequencySpike)
+	mustRegisterRiskFactor(modeDeviationFactor{}, constants.WeightModeDeviation, constants.ThresholdModeDeviation)
+	mustRegisterRiskFactor(timeAnomalyFactor{}, constants.WeightTimeAnomaly, constants.ThresholdTimeAnomaly)
+	mustRegisterRiskFactor(newDeviceFactor{}, constants.WeightNewDevice, constants.ThresholdNewDevice)
+	mustRegisterRiskFactor(impossibleTravelFactor{}, constants.WeightImpossibleTravel, constants.ThresholdImpossibleTravel)
+	mustRegisterRiskFactor(newPayeeFactor{}, constants.WeightNewPayee, constants.ThresholdNewPayee)
+}
+
+// mustRegisterRiskFactor registers a built-in factor, which cannot fail
+// without a programming error
+func mustRegisterRiskFactor(factor RiskFactor, weight float64, threshold float64) {
+	if err := RegisterRiskFactor(factor, weight, threshold); err != nil {
+		panic(fmt.Sprintf("registering risk factor %s: %v", factor.Name(), err))
+	}
+}
+
+// RegisterRiskFactor adds a factor to the scoring pipeline. weight is the
+// factor's default share of the aggregate score and threshold the default score
+// above which the factor is reported as triggered; both can be overridden per
+// scoring config version.
+func RegisterRiskFactor(factor RiskFactor, weight float64, threshold float64) error {
+	if factor.Name() == "" || weight < 0 || threshold < 0 || threshold > 100 {
+		return errors.ErrInvalidRiskFactor
+	}
+
+	riskFactorsMutex.Lock()
+	defer riskFactorsMutex.Unlock()
+
+	if slices.ContainsFunc(riskFactors, func(r registeredRiskFactor) bool {
+		return r.factor.Name() == factor.Name()
+	}) {
+		return errors.ErrDuplicateRiskFactor
+	}
+
+	riskFactors = append(riskFactors, registeredRiskFactor{
+		factor:    factor,
+		weight:    weight,
+		threshold: threshold,
+	})
+	return nil
+}
+
+// UnregisterRiskFactor removes a factor from the scoring pipeline
+func UnregisterRiskFactor(name string) {
+	riskFactorsMutex.Lock()
+	defer riskFactorsMutex.Unlock()
+
+	riskFactors = slices.DeleteFunc(riskFactors, func(r registeredRiskFactor) bool {
+		return r.factor.Name() == name
+	})
+}
+
+// registeredRiskFactors returns a snapshot of the registry in registration order
+func registeredRiskFactors() []registeredRiskFactor {
+	riskFactorsMutex.RLock()
+	defer riskFactorsMutex.RUnlock()
+
+	return slices.Clone(riskFactors)
+}
+
+// IsRiskFactorRegistered reports whether a factor with the given name is registered
+func IsRiskFactorRegistered(name string) bool {
+	return slices.ContainsFunc(registeredRiskFactors(), func(r registeredRiskFactor) bool {
+		return r.factor.Name() == name
+	})
+}
+
+// DefaultScoringConfig returns the built-in scoring config (version 0), made of
+// the registered factor defaults and the constants in constants/txn.go
+func DefaultScoringConfig() *specs.ScoringConfig {
+	factors := map[string]specs.FactorConfig{}
+	for _, r := range registeredRiskFactors() {
+		factors[r.factor.Name()] = specs.FactorConfig{
+			Weight:    r.weight,
+			Threshold: r.threshold,
+		}
+	}
+
+	return &specs.ScoringConfig{
+		Version:                     0,
+		Factors:                     factors,
+		RiskThresholdAllow:          constants.RiskThresholdAllow,
+		RiskThresholdFlag:           constants.RiskThresholdFlag,
+		RiskThresholdMFA:            constants.RiskThresholdMFA,
+		ColdStartThresholdAllow:     constants.ColdStartThresholdAllow,
+		ColdStartThresholdFlag:      constants.ColdStartThresholdFlag,
+		MinTransactionsForProfiling: constants.MinTransactionsForProfiling,
+		ThresholdFrequency:          constants.ThresholdFrequency,
+		RiskPerTxnAfterThreshold:    constants.RiskPerTxnAfterThreshold,
+		AmountScoring:               constants.DefaultAmountScoring,
+		VelocityLimits: map[string]specs.VelocityLimit{
+			constants.VelocityWindow1m:  {Count: constants.VelocityLimit1mCount},
+			constants.VelocityWindow10m: {ModeCount: constants.VelocityLimit10mModeCount},
+			constants.VelocityWindow24h: {Count: constants.VelocityLimit24hCount, ModeCount: constants.VelocityLimit24hModeCount},
+			constants.VelocityWindow7d:  {Count: constants.VelocityLimit7dCount},
+		},
+	}
+}
+
+// ValidateScoringConfig checks a config's values and that it only weights
+// registered factors
+func ValidateScoringConfig(cfg specs.ScoringConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	for name := range cfg.Factors {
+		if !IsRiskFactorRegistered(name) {
+			return errors.ErrUnknownRiskFactor
+		}
+	}
+	return nil
+}
+
+// EvaluateRiskFactors runs every registered factor against the transaction,
+// weighting each one with the config's value or the factor's registered default.
+// Contributions are renormalized over the factors that were not skipped.
+func EvaluateRiskFactors(
+	ctx context.Context,
+	cfg *specs.ScoringConfig,
+	txn *specs.TransactionInput,
+	profile *repository.UserProfileBehavior,
+	history *specs.TransactionHistory,
+) []specs.FactorResult {
+	registered := registeredRiskFactors()
+	results := make([]specs.FactorResult, 0, len(registered))
+
+	for _, r := range registered {
+		weight, threshold := r.weight, r.threshold
+		if fc, ok := cfg.Factors[r.factor.Name()]; ok {
+			weight, threshold = fc.Weight, fc.Threshold
+		}
+
+		result := r.factor.Evaluate(ctx, cfg, txn, profile, history)
+		result.Name = r.factor.Name()
+		result.Weight = weight
+		result.Threshold = threshold
+		result.Triggered = !result.Skipped && result.Score > threshold
+		results = append(results, result)
+	}
+
+	if total := ApplicableWeight(results); total > 0 {
+		for i := range results {
+			if !results[i].Skipped {
+				results[i].Contribution = results[i].Score * results[i].Weight / total
+			}
+		}
+	}
+	return results
+}
+
+// ApplicableWeight is the total weight of the factors that were not skipped
+func ApplicableWeight(factors []specs.FactorResult) float64 {
+	total := 0.0
+	for _, f := range factors {
+		if !f.Skipped {
+			total += f.Weight
+		}
+	}
+	return total
+}
+
+// compared records the values a factor compared on its result
+func compared(result specs.FactorResult, observed float64, baseline float64, unit string) specs.FactorResult {
+	result.Observed, result.Baseline, result.Unit = &observed, &baseline, unit
+	return result
+}
+
+type amountDeviationFactor struct{}
+
+func (amountDeviationFactor) Name() string {
+	return constants.TriggerFactorsAMOUNTDEVIATION
+}
+
+func (amountDeviationFactor) Evaluate(_ context.Context, cfg *specs.ScoringConfig, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, _ *specs.TransactionHistory) specs.FactorResult {
+	score := CalculateAmountDeviationRisk(txn.Amount, txn.Mode, profile, cfg)
+
+	// cold start: compared with the highest amount seen, as the score is
+	if !profile.AverageTransactionAmount.Valid || profile.TotalTransactions < cfg.MinTransactionsForProfiling {
+		if !profile.MaxTransactionAmountSeen.Valid {
+			return specs.FactorResult{
+				Score:       score,
+				ReasonCode:  constants.ReasonAmountNoHistory,
+				Explanation: fmt.Sprintf("amount %.2f with no amount history", txn.Amount),
+			}
+		}
+
+		maxSeen := profile.MaxTransactionAmountSeen.Float64
+		code := constants.ReasonAmountWithinMaxSeen
+		if txn.Amount > maxSeen {
+			code = constants.ReasonAmountAboveMaxSeen
+		}
+		return compared(specs.FactorResult{
+			Score:       score,
+			ReasonCode:  code,
+			Explanation: fmt.Sprintf("amount %.2f against the highest amount seen %.2f", txn.Amount, maxSeen),
+		}, txn.Amount, maxSeen, constants.UnitAmount)
+	}
+
+	baseline, mode := AmountBaseline(profile, txn.Mode, cfg)
+	scope := "overall"
+	if mode != "" {
+		scope = string(mode)
+	}
+
+	robust := cfg.AmountScoring == constants.AmountScoringRobust && baseline.Median > 0
+	center, spread, statistic := baseline.Mean, baseline.StdDev, "average"
+	if robust {
+		center, spread, statistic = baseline.Median, constants.MADScale*baseline.MAD, "median"
+	}
+
+	code := constants.ReasonAmountUsual
+	if score > 0 && txn.Amount > center {
+		code = constants.ReasonAmountAboveUsual
+	}
+
+	explanation := fmt.Sprintf("amount %.2f against the %s %s of %.2f", txn.Amount, scope, statistic, center)
+	switch {
+	case spread > 0:
+		deviation, direction := (txn.Amount-center)/spread, "above"
+		if deviation < 0 {
+			deviation, direction = -deviation, "below"
+		}
+		explanation = fmt.Sprintf("amount %.2f is %.1fσ %s the %s %s of %.2f",
+			txn.Amount, deviation, direction, scope, statistic, center)
+	case robust:
+		explanation += fmt.Sprintf(" (p90 %.2f, p99 %.2f)", baseline.P90, baseline.P99)
+	}
+
+	return compared(specs.FactorResult{
+		Score:       score,
+		ReasonCode:  code,
+		Explanation: explanation,
+	}, txn.Amount, center, constants.UnitAmount)
+}
+
+type frequencySpikeFactor struct{}
+
+func (frequencySpikeFactor) Name() string {
+	return constants.TriggerFactorsFREQUENCYSPIKE
+}
+
+func (frequencySpikeFactor) Evaluate(_ context.Context, cfg *specs.ScoringConfig, txn *specs.TransactionInput, _ *repository.UserProfileBehavior, history *specs.TransactionHistory) specs.FactorResult {
+	score, window := CalculateFrequencySpikeRisk(txn.Amount, history.Velocity, cfg)
+
+	code := constants.ReasonVelocityNormal
+	if score > 0 {
+		code = constants.ReasonVelocityOverLimit
+	}
+	result := specs.FactorResult{
+		Score:      score,
+		ReasonCode: code,
+		Explanation: fmt.Sprintf("%d transactions (%d by %s) totalling %.2f in the last %s",
+			window.Count+1, window.ModeCount+1, txn.Mode, window.Amount+txn.Amount, window.Window),
+	}
+
+	if observed, limit, unit := VelocityExcess(txn.Amount, window, cfg); limit > 0 {
+		result = compared(result, observed, limit, unit)
+	}
+	return result
+}
+
+type modeDeviationFactor struct{}
+
+func (modeDeviationFactor) Name() string {
+	return constants.TriggerFactorsNEWMODE
+}
+
+func (modeDeviationFactor) Evaluate(_ context.Context, _ *specs.ScoringConfig, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, _ *specs.TransactionHistory) specs.FactorResult {
+	score := CalculateModeDeviationRisk(txn.Mode, profile)
+
+	code := constants.ReasonModeRegistered
+	if score > 0 {
+		code = constants.ReasonModeUnregistered
+	}
+	return specs.FactorResult{
+		Score:      score,
+		ReasonCode: code,
+		Explanation: fmt.Sprintf("mode %s against registered modes %v",
+			txn.Mode, profile.RegisteredPaymentModes),
+	}
+}
+
+type timeAnomalyFactor struct{}
+
+func (timeAnomalyFactor) Name() string {
+	return constants.TriggerFactorsTIMEANOMALY
+}
+
+func (timeAnomalyFactor) Evaluate(_ context.Context, _ *specs.ScoringConfig, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, _ *specs.TransactionHistory) specs.FactorResult {
+	score := CalculateTimeAnomalyRisk(txn.CreatedAt, profile)
+
+	density, ok := HistogramDensity(profile.HourHistogram, txn.CreatedAt.Hour(), constants.HourHistogramMinWeight)
+	if !ok {
+		return specs.FactorResult{
+			Score:       score,
+			ReasonCode:  constants.ReasonHourNoHistory,
+			Explanation: fmt.Sprintf("transaction at hour %d", txn.CreatedAt.Hour()),
+		}
+	}
+
+	code := constants.ReasonHourUsual
+	if score > 0 {
+		code = constants.ReasonHourUnusual
+	}
+	return compared(specs.FactorResult{
+		Score:      score,
+		ReasonCode: code,
+		Explanation: fmt.Sprintf("transaction at hour %d, %.0f%% as busy as the user's average hour",
+			txn.CreatedAt.Hour(), density*100),
+	}, density*100, 100, constants.UnitPercent)
+}
+
+type newDeviceFactor struct{}
+
+func (newDeviceFactor) Name() string {
+	return constants.TriggerFactorsNEWDEVICE
+}
+
+func (newDeviceFactor) Evaluate(_ context.Context, _ *specs.ScoringConfig, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory) specs.FactorResult {
+	if txn.DeviceID == "" {
+		return specs.FactorResult{Skipped: true, ReasonCode: constants.ReasonDeviceMissing, Explanation: "no device fingerprint"}
+	}
+
+	var code, explanation string
+	switch {
+	case history.Devices.Known:
+		code, explanation = constants.ReasonDeviceKnown, fmt.Sprintf("device %s is known", txn.DeviceID)
+	case history.Devices.KnownDevices == 0:
+		code, explanation = constants.ReasonDeviceNoHistory, fmt.Sprintf("device %s with no known devices yet", txn.DeviceID)
+	default:
+		code, explanation = constants.ReasonDeviceNew, fmt.Sprintf("device %s against %d known devices", txn.DeviceID, history.Devices.KnownDevices)
+	}
+
+	return specs.FactorResult{
+		Score:       CalculateNewDeviceRisk(txn.DeviceID, history.Devices, profile),
+		ReasonCode:  code,
+		Explanation: explanation,
+	}
+}
+
+type impossibleTravelFactor struct{}
+
+func (impossibleTravelFactor) Name() string {
+	return constants.TriggerFactorsIMPOSSIBLETRAVEL
+}
+
+func (impossibleTravelFactor) Evaluate(_ context.Context, _ *specs.ScoringConfig, txn *specs.TransactionInput, _ *repository.UserProfileBehavior, history *specs.TransactionHistory) specs.FactorResult {
+	if txn.Location == nil {
+		return specs.FactorResult{Skipped: true, ReasonCode: constants.ReasonLocationMissing, Explanation: "transaction not located"}
+	}
+
+	score := CalculateImpossibleTravelRisk(txn.Location, txn.CreatedAt, history.PreviousLocation)
+	if history.PreviousLocation == nil {
+		return specs.FactorResult{Score: score, ReasonCode: constants.ReasonLocationNoHistory, Explanation: "no previous located transaction"}
+	}
+
+	distance := DistanceKm(history.PreviousLocation.GeoPoint, *txn.Location)
+	elapsed := txn.CreatedAt.Sub(history.PreviousLocation.CreatedAt)
+
+	code := constants.ReasonTravelPlausible
+	if score > 0 {
+		code = constants.ReasonTravelImpossible
+	}
+	result := specs.FactorResult{
+		Score:       score,
+		ReasonCode:  code,
+		Explanation: fmt.Sprintf("%.0f km from the previous transaction in %s", distance, elapsed.Round(time.Minute)),
+	}
+
+	// without elapsed time the speed is unbounded
+	if elapsed > 0 {
+		result = compared(result, distance/elapsed.Hours(), constants.ImpossibleTravelMaxSpeedKmh, constants.UnitKmPerHour)
+	}
+	return result
+}
+
+type newPayeeFactor struct{}
+
+func (newPayeeFactor) Name() string {
+	return constants.TriggerFactorsNEWPAYEE
+}
+
+func (newPayeeFactor) Evaluate(_ context.Context, _ *specs.ScoringConfig, txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory) specs.FactorResult {
+	if txn.PayeeID == "" {
+		return specs.FactorResult{Skipped: true, ReasonCode: constants.ReasonPayeeMissing, Explanation: "no payee"}
+	}
+
+	score := CalculateNewPayeeRisk(txn.PayeeID, txn.Amount, history.Payees, profile)
+	code := constants.ReasonPayeeUnfamiliar
+	switch payments := history.Payees.Payments; {
+	case history.Payees.KnownPayees == 0:
+		code = constants.ReasonPayeeNoHistory
+	case payments >= constants.TrustedPayeeMinPayments:
+		code = constants.ReasonPayeeTrusted
+	case payments == 0:
+		code = constants.ReasonPayeeNew
+	}
+
+	return compared(specs.FactorResult{
+		Score:       score,
+		ReasonCode:  code,
+		Explanation: fmt.Sprintf("payee %s paid %d times before, against %d known payees", txn.PayeeID, history.Payees.Payments, history.Payees.KnownPayees),
+	}, float64(history.Payees.Payments), constants.TrustedPayeeMinPayments, constants.UnitPayments)
+}
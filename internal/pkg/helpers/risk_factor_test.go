@@ -0,0 +1,402 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedRiskFactor struct {
+	name  string
+	score float64
+}
+
+func (f fixedRiskFactor) Name() string {
+	return f.name
+}
+
+func (f fixedRiskFactor) Evaluate(_ context.Context, _ *specs.ScoringConfig, _ *specs.TransactionInput, _ *repository.UserProfileBehavior, _ *specs.TransactionHistory) specs.FactorResult {
+	return specs.FactorResult{Score: f.score, Explanation: "fixed"}
+}
+
+func TestRegisterRiskFactor(t *testing.T) {
+	t.Run("duplicate name", func(t *testing.T) {
+		err := RegisterRiskFactor(fixedRiskFactor{name: constants.TriggerFactorsNEWMODE}, 0.1, 10)
+		assert.Equal(t, errors.ErrDuplicateRiskFactor, err)
+	})
+
+	t.Run("invalid weight or threshold", func(t *testing.T) {
+		err := RegisterRiskFactor(fixedRiskFactor{name: "TEST_FACTOR"}, -0.1, 10)
+		assert.Equal(t, errors.ErrInvalidRiskFactor, err)
+
+		err = RegisterRiskFactor(fixedRiskFactor{name: "TEST_FACTOR"}, 0.1, 110)
+		assert.Equal(t, errors.ErrInvalidRiskFactor, err)
+		assert.False(t, IsRiskFactorRegistered("TEST_FACTOR"))
+	})
+
+	t.Run("built-in registration panics on error", func(t *testing.T) {
+		assert.Panics(t, func() {
+			mustRegisterRiskFactor(amountDeviationFactor{}, constants.WeightAmountDeviation, constants.ThresholdAmountDeviation)
+		})
+	})
+
+	t.Run("custom factor is evaluated and weighted", func(t *testing.T) {
+		err := RegisterRiskFactor(fixedRiskFactor{name: "TEST_FACTOR", score: 80}, 0.5, 50)
+		assert.NoError(t, err)
+		defer UnregisterRiskFactor("TEST_FACTOR")
+
+		profile := NewEmptyUserProfile(1)
+		txn := &specs.TransactionInput{
+			Amount:    100,
+			Mode:      repository.ModeUPI,
+			CreatedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		}
+
+		result := AnalyzeTransaction(context.Background(), DefaultScoringConfig(), txn, profile, &specs.TransactionHistory{})
+
+		assert.Len(t, result.Factors, 8)
+		assert.Contains(t, result.TriggeredFactors, "TEST_FACTOR")
+		assert.Equal(t, 80.0, result.FactorScores()["TEST_FACTOR"])
+
+		weighted := 0.0
+		for _, f := range result.Factors {
+			if !f.Skipped {
+				weighted += f.Score * f.Weight
+			}
+		}
+		total := ApplicableWeight(result.Factors)
+		assert.InDelta(t, min(weighted/total, 100.0), result.RawRiskScore, 0.0001)
+	})
+
+	t.Run("unregistered factor is not evaluated", func(t *testing.T) {
+		result := AnalyzeTransaction(context.Background(), DefaultScoringConfig(), &specs.TransactionInput{
+			Amount: 100,
+			Mode:   repository.ModeUPI,
+		}, NewEmptyUserProfile(1), &specs.TransactionHistory{})
+
+		assert.Len(t, result.Factors, 7)
+		assert.NotContains(t, result.FactorScores(), "TEST_FACTOR")
+	})
+}
+
+func TestAnalyzeTransactionListEntry(t *testing.T) {
+	profile := NewEmptyUserProfile(1)
+	txn := &specs.TransactionInput{
+		Amount:    100,
+		Mode:      repository.ModeUPI,
+		CreatedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	deny := &specs.ListEntryResponse{ID: 3, ListType: repository.ListTypeDENY, Entity: repository.ListEntityPAYEE, Value: "mule@upi"}
+	result := AnalyzeTransaction(context.Background(), DefaultScoringConfig(), txn, profile, &specs.TransactionHistory{ListEntry: deny})
+	assert.Equal(t, repository.TransactionDecisionBLOCK, result.Decision)
+	assert.Equal(t, int32(100), result.FinalRiskScore)
+	assert.Empty(t, result.Factors, "factors are not evaluated")
+	assert.Equal(t, deny, result.ListEntry)
+
+	allow := &specs.ListEntryResponse{ID: 4, ListType: repository.ListTypeALLOW, Entity: repository.ListEntityUSER, Value: "1"}
+	result = AnalyzeTransaction(context.Background(), DefaultScoringConfig(), txn, profile, &specs.TransactionHistory{ListEntry: allow})
+	assert.Equal(t, repository.TransactionDecisionALLOW, result.Decision)
+	assert.Equal(t, int32(0), result.FinalRiskScore)
+}
+
+func TestFactorExplanations(t *testing.T) {
+	profile := NewEmptyUserProfile(1)
+	profile.TotalTransactions, profile.AllowedTransactions = 25, 25
+	profile.AverageTransactionAmount = pgtype.Float8{Float64: 500, Valid: true}
+	profile.StdDevTransactionAmount = pgtype.Float8{Float64: 100, Valid: true}
+	profile.MaxTransactionAmountSeen = pgtype.Float8{Float64: 900, Valid: true}
+	profile.RegisteredPaymentModes = []repository.Mode{repository.ModeUPI}
+	profile.AmountStats = []byte(`{"count":25,"mean":500,"std_dev":100,"median":480,"mad":40,"p90":600,"p99":800}`)
+
+	txn := &specs.TransactionInput{
+		Amount:    960,
+		Mode:      repository.ModeUPI,
+		CreatedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		PayeeID:   "shop@upi",
+	}
+	history := &specs.TransactionHistory{
+		Velocity: []specs.VelocityStats{{Window: constants.VelocityWindow1m, Count: 4, ModeCount: 4, Amount: 400}},
+		Payees:   specs.PayeeHistory{Payments: 1, KnownPayees: 3},
+	}
+
+	cfg := DefaultScoringConfig()
+	cfg.AmountScoring = constants.AmountScoringRobust
+
+	result := AnalyzeTransaction(context.Background(), cfg, txn, profile, history)
+	factors := map[string]specs.FactorResult{}
+	for _, f := range result.Factors {
+		factors[f.Name] = f
+		assert.InDelta(t, f.Score*f.Weight/ApplicableWeight(result.Factors), f.Contribution, 0.0001, f.Name)
+		assert.NotEmpty(t, f.ReasonCode, f.Name)
+	}
+
+	amount := factors[constants.TriggerFactorsAMOUNTDEVIATION]
+	assert.Equal(t, constants.ReasonAmountAboveUsual, amount.ReasonCode)
+	assert.Equal(t, "amount 960.00 is 8.1σ above the overall median of 480.00", amount.Explanation)
+	assert.Equal(t, 960.0, *amount.Observed)
+	assert.Equal(t, 480.0, *amount.Baseline)
+	assert.Equal(t, constants.UnitAmount, amount.Unit)
+	assert.Equal(t, constants.ThresholdAmountDeviation, amount.Threshold)
+
+	frequency := factors[constants.TriggerFactorsFREQUENCYSPIKE]
+	assert.Equal(t, constants.ReasonVelocityOverLimit, frequency.ReasonCode)
+	assert.Equal(t, 5.0, *frequency.Observed)
+	assert.Equal(t, float64(constants.VelocityLimit1mCount), *frequency.Baseline)
+	assert.Equal(t, constants.UnitTransactions, frequency.Unit)
+
+	mode := factors[constants.TriggerFactorsNEWMODE]
+	assert.Equal(t, constants.ReasonModeRegistered, mode.ReasonCode)
+	assert.Nil(t, mode.Observed)
+
+	assert.Equal(t, constants.ReasonDeviceMissing, factors[constants.TriggerFactorsNEWDEVICE].ReasonCode)
+	assert.True(t, factors[constants.TriggerFactorsNEWDEVICE].Skipped)
+	assert.Zero(t, factors[constants.TriggerFactorsNEWDEVICE].Contribution)
+	assert.Equal(t, constants.ReasonLocationMissing, factors[constants.TriggerFactorsIMPOSSIBLETRAVEL].ReasonCode)
+	assert.True(t, factors[constants.TriggerFactorsIMPOSSIBLETRAVEL].Skipped)
+
+	payee := factors[constants.TriggerFactorsNEWPAYEE]
+	assert.Equal(t, constants.ReasonPayeeUnfamiliar, payee.ReasonCode)
+	assert.Equal(t, 1.0, *payee.Observed)
+	assert.Equal(t, float64(constants.TrustedPayeeMinPayments), *payee.Baseline)
+
+	// 50% profile confidence dampens the raw score by a quarter
+	assert.InDelta(t, 0.75, result.DampeningFactor, 0.0001)
+	assert.InDelta(t, result.RawRiskScore*0.75, float64(result.FinalRiskScore), 1)
+}
+
+func TestAggregateRiskScoreSkipsMissingSignals(t *testing.T) {
+	profile := NewEmptyUserProfile(1)
+	profile.TotalTransactions, profile.AllowedTransactions = 10, 10
+	profile.AverageTransactionAmount = pgtype.Float8{Float64: 500, Valid: true}
+	profile.StdDevTransactionAmount = pgtype.Float8{Float64: 100, Valid: true}
+	profile.MaxTransactionAmountSeen = pgtype.Float8{Float64: 900, Valid: true}
+	profile.RegisteredPaymentModes = []repository.Mode{repository.ModeUPI}
+
+	txn := &specs.TransactionInput{
+		Amount:    5000,
+		Mode:      repository.ModeCARD,
+		CreatedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	history := &specs.TransactionHistory{
+		Velocity: []specs.VelocityStats{{Window: constants.VelocityWindow1h, Count: 8, ModeCount: 8, Amount: 4000}},
+	}
+
+	// without a device, location or payee the score is that of the four
+	// original factors, whose weights sum to 1.0, as if the others did not exist
+	result := AnalyzeTransaction(context.Background(), DefaultScoringConfig(), txn, profile, history)
+	baseline := 0.0
+	for _, f := range result.Factors {
+		switch f.Name {
+		case constants.TriggerFactorsAMOUNTDEVIATION, constants.TriggerFactorsFREQUENCYSPIKE,
+			constants.TriggerFactorsNEWMODE, constants.TriggerFactorsTIMEANOMALY:
+			baseline += f.Score * f.Weight
+		}
+	}
+	assert.InDelta(t, baseline, result.RawRiskScore, 0.0001)
+	assert.Greater(t, result.RawRiskScore, constants.RiskThresholdMFA, "BLOCK stays reachable")
+	assert.Equal(t, 0.0, CalculateAggregateRiskScore(nil))
+}
+
+func TestCalculateFrequencySpikeRisk(t *testing.T) {
+	cfg := DefaultScoringConfig()
+	velocity := func(window string, count, modeCount int, amount float64) []specs.VelocityStats {
+		return []specs.VelocityStats{{Window: window, Count: count, ModeCount: modeCount, Amount: amount}}
+	}
+
+	tests := []struct {
+		name     string
+		amount   float64
+		velocity []specs.VelocityStats
+		risk     float64
+		window   string
+	}{
+		{"No activity", 100, nil, 0, constants.VelocityWindow1h},
+		{"Within hourly limit", 100, velocity(constants.VelocityWindow1h, 2, 2, 200), 0, constants.VelocityWindow1h},
+		{"Fourth in an hour", 100, velocity(constants.VelocityWindow1h, 3, 0, 300), 20, constants.VelocityWindow1h},
+		{"Burst in a minute", 100, velocity(constants.VelocityWindow1m, 4, 0, 400), 60, constants.VelocityWindow1m},
+		{"Same mode in ten minutes", 100, velocity(constants.VelocityWindow10m, 3, 3, 300), 20, constants.VelocityWindow10m},
+		{"Capped", 100, velocity(constants.VelocityWindow7d, 80, 0, 8000), 100, constants.VelocityWindow7d},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			risk, window := CalculateFrequencySpikeRisk(tt.amount, tt.velocity, cfg)
+			assert.Equal(t, tt.risk, risk)
+			assert.Equal(t, tt.window, window.Window)
+		})
+	}
+
+	t.Run("Amount limit", func(t *testing.T) {
+		cfg := DefaultScoringConfig()
+		cfg.VelocityLimits[constants.VelocityWindow24h] = specs.VelocityLimit{Amount: 1000}
+
+		risk, window := CalculateFrequencySpikeRisk(500, velocity(constants.VelocityWindow24h, 1, 1, 1500), cfg)
+		assert.Equal(t, 100.0, risk)
+		assert.Equal(t, constants.VelocityWindow24h, window.Window)
+
+		risk, _ = CalculateFrequencySpikeRisk(500, velocity(constants.VelocityWindow24h, 1, 1, 500), cfg)
+		assert.Equal(t, 0.0, risk)
+	})
+}
+
+func TestCalculateAmountDeviationRisk(t *testing.T) {
+	// one 20,000 payment among ~500 ones inflated the mean and std dev
+	profile := func() *repository.UserProfileBehavior {
+		p := NewEmptyUserProfile(1)
+		p.TotalTransactions, p.AllowedTransactions = 40, 40
+		p.AverageTransactionAmount = pgtype.Float8{Float64: 1000, Valid: true}
+		p.StdDevTransactionAmount = pgtype.Float8{Float64: 3000, Valid: true}
+		p.MaxTransactionAmountSeen = pgtype.Float8{Float64: 20000, Valid: true}
+		p.AmountStats = []byte(`{"count":40,"mean":1000,"std_dev":3000,"median":500,"mad":50,"p90":600,"p99":13000}`)
+		p.ModeAmountStats = []byte(`{"UPI":{"count":35,"mean":480,"std_dev":60,"median":480,"mad":40,"p90":560,"p99":600},` +
+			`"CARD":{"count":5,"mean":5000,"std_dev":7000,"median":5000,"mad":500,"p90":6000,"p99":20000}}`)
+		return p
+	}
+
+	zscore := DefaultScoringConfig()
+	zscore.AmountScoring = constants.AmountScoringZScore
+	robust := DefaultScoringConfig()
+	robust.AmountScoring = constants.AmountScoringRobust
+
+	t.Run("Cold start compares with the largest amount", func(t *testing.T) {
+		p := profile()
+		p.TotalTransactions = 2
+		assert.Equal(t, 10.0, CalculateAmountDeviationRisk(1000, repository.ModeUPI, p, robust))
+		assert.Equal(t, 50.0, CalculateAmountDeviationRisk(40000, repository.ModeUPI, p, robust))
+	})
+
+	t.Run("Outlier hides a large amount from the z-score only", func(t *testing.T) {
+		p := profile()
+		p.ModeAmountStats = nil
+		assert.Equal(t, 0.0, CalculateAmountDeviationRisk(2000, repository.ModeUPI, p, zscore))
+		assert.Equal(t, 100.0, CalculateAmountDeviationRisk(2000, repository.ModeUPI, p, robust))
+		assert.Equal(t, 0.0, CalculateAmountDeviationRisk(550, repository.ModeUPI, p, robust))
+	})
+
+	t.Run("Modes keep their own distribution", func(t *testing.T) {
+		p := profile()
+		assert.Equal(t, 100.0, CalculateAmountDeviationRisk(5500, repository.ModeUPI, p, robust))
+		assert.Equal(t, 0.0, CalculateAmountDeviationRisk(5500, repository.ModeCARD, p, robust))
+
+		baseline, mode := AmountBaseline(p, repository.ModeNETBANKING, robust)
+		assert.Equal(t, repository.Mode(""), mode, "modes without history use the overall distribution")
+		assert.Equal(t, 500.0, baseline.Median)
+		assert.Equal(t, 3000.0, baseline.StdDev)
+	})
+
+	t.Run("Identical amounts fall back to percentiles", func(t *testing.T) {
+		p := profile()
+		p.ModeAmountStats = nil
+		p.AmountStats = []byte(`{"count":40,"median":500,"mad":0,"p90":500,"p99":1500}`)
+		assert.Equal(t, 0.0, CalculateAmountDeviationRisk(500, repository.ModeUPI, p, robust))
+		assert.Equal(t, 25.0, CalculateAmountDeviationRisk(1000, repository.ModeUPI, p, robust))
+		assert.Equal(t, 100.0, CalculateAmountDeviationRisk(3000, repository.ModeUPI, p, robust))
+	})
+
+	t.Run("Robust scoring needs rebuilt statistics", func(t *testing.T) {
+		p := profile()
+		p.AmountStats, p.ModeAmountStats = nil, nil
+		assert.Equal(t, CalculateAmountDeviationRisk(20000, repository.ModeUPI, p, zscore),
+			CalculateAmountDeviationRisk(20000, repository.ModeUPI, p, robust))
+	})
+}
+
+func TestCalculateNewDeviceRisk(t *testing.T) {
+	profile := NewEmptyUserProfile(1)
+	known := specs.DeviceHistory{Known: true, KnownDevices: 2}
+	unknown := specs.DeviceHistory{KnownDevices: 2}
+
+	assert.Equal(t, 0.0, CalculateNewDeviceRisk("", unknown, profile), "no fingerprint")
+	assert.Equal(t, 0.0, CalculateNewDeviceRisk("phone", known, profile))
+	assert.Equal(t, 0.0, CalculateNewDeviceRisk("phone", specs.DeviceHistory{}, profile), "first device of the user")
+	assert.Equal(t, 70.0, CalculateNewDeviceRisk("laptop", unknown, profile))
+
+	// dampened by profile confidence
+	profile.TotalTransactions, profile.AllowedTransactions = 50, 50
+	assert.Equal(t, 40.0, CalculateNewDeviceRisk("laptop", unknown, profile))
+}
+
+func TestCalculateNewPayeeRisk(t *testing.T) {
+	profile := NewEmptyUserProfile(1)
+	profile.AverageTransactionAmount = pgtype.Float8{Float64: 1000, Valid: true}
+	unknown := specs.PayeeHistory{KnownPayees: 4}
+
+	assert.Equal(t, 0.0, CalculateNewPayeeRisk("", 50000, unknown, profile), "no payee")
+	assert.Equal(t, 0.0, CalculateNewPayeeRisk("shop@upi", 50000, specs.PayeeHistory{}, profile), "first payee of the user")
+	assert.Equal(t, 0.0, CalculateNewPayeeRisk("shop@upi", 50000, specs.PayeeHistory{Payments: 3, KnownPayees: 4}, profile), "trusted payee")
+
+	assert.Equal(t, 50.0, CalculateNewPayeeRisk("shop@upi", 800, unknown, profile))
+	assert.Equal(t, 75.0, CalculateNewPayeeRisk("shop@upi", 2000, unknown, profile))
+	assert.Equal(t, 100.0, CalculateNewPayeeRisk("shop@upi", 50000, unknown, profile))
+
+	// earlier payments make the payee familiar
+	assert.InDelta(t, 100.0/3, CalculateNewPayeeRisk("shop@upi", 50000, specs.PayeeHistory{Payments: 2, KnownPayees: 4}, profile), 0.0001)
+}
+
+func TestCalculateImpossibleTravelRisk(t *testing.T) {
+	mumbai := specs.GeoPoint{Latitude: 19.076, Longitude: 72.8777}
+	delhi := specs.GeoPoint{Latitude: 28.6139, Longitude: 77.209}
+	thane := specs.GeoPoint{Latitude: 19.2183, Longitude: 72.9781}
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	previous := &specs.LocatedTransaction{GeoPoint: mumbai, CreatedAt: at}
+
+	assert.InDelta(t, 1150.0, DistanceKm(mumbai, delhi), 10.0)
+
+	assert.Equal(t, 0.0, CalculateImpossibleTravelRisk(nil, at.Add(time.Minute), previous), "not located")
+	assert.Equal(t, 0.0, CalculateImpossibleTravelRisk(&delhi, at.Add(time.Minute), nil), "no previous location")
+	assert.Equal(t, 0.0, CalculateImpossibleTravelRisk(&thane, at.Add(time.Minute), previous), "within the same city")
+	assert.Equal(t, 0.0, CalculateImpossibleTravelRisk(&delhi, at.Add(2*time.Hour), previous), "a flight away")
+
+	// ~1150 km in one hour is ~1.28x the max speed
+	assert.InDelta(t, 64.0, CalculateImpossibleTravelRisk(&delhi, at.Add(time.Hour), previous), 1.0)
+	assert.Equal(t, 100.0, CalculateImpossibleTravelRisk(&delhi, at.Add(10*time.Minute), previous))
+	assert.Equal(t, 100.0, CalculateImpossibleTravelRisk(&delhi, at, previous), "no time elapsed")
+}
+
+func TestCalculateTimeAnomalyRisk(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 1, 1, hour, 30, 0, 0, time.UTC)
+	}
+
+	t.Run("No history uses heuristics", func(t *testing.T) {
+		profile := NewEmptyUserProfile(1)
+		assert.Equal(t, 35.0, CalculateTimeAnomalyRisk(at(2), profile))
+		assert.Equal(t, 20.0, CalculateTimeAnomalyRisk(at(6), profile))
+		assert.Equal(t, 5.0, CalculateTimeAnomalyRisk(at(14), profile))
+	})
+
+	t.Run("Histogram", func(t *testing.T) {
+		profile := NewEmptyUserProfile(1)
+		profile.HourHistogram = make([]float64, 24)
+		profile.HourHistogram[9] = 10
+		profile.HourHistogram[10] = 10
+		profile.HourHistogram[2] = 0.2
+
+		assert.Equal(t, 0.0, CalculateTimeAnomalyRisk(at(10), profile))
+		assert.Equal(t, 70.0, CalculateTimeAnomalyRisk(at(16), profile), "never seen")
+		assert.Greater(t, CalculateTimeAnomalyRisk(at(3), profile), 80.0, "next to a rare night hour")
+
+		// a single 2 AM transaction does not make 2 AM usual
+		assert.Greater(t, CalculateTimeAnomalyRisk(at(2), profile), 75.0)
+	})
+
+	t.Run("Weekday histogram", func(t *testing.T) {
+		profile := NewEmptyUserProfile(1)
+		profile.HourHistogram = make([]float64, 24)
+		profile.HourHistogram[10] = 30
+		profile.WeekdayHourHistogram = make([]float64, 168)
+		profile.WeekdayHourHistogram[24+10] = 30 // Mondays at 10 AM
+
+		monday := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		assert.Equal(t, 0.0, CalculateTimeAnomalyRisk(monday, profile))
+		assert.InDelta(t, 21.0, CalculateTimeAnomalyRisk(monday.Add(-24*time.Hour), profile), 0.0001)
+	})
+}
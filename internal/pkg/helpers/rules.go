@@ -0,0 +1,162 @@
+package helpers
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/rules"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+var velocityWindows = []string{
+	constants.VelocityWindow1m,
+	constants.VelocityWindow10m,
+	constants.VelocityWindow1h,
+	constants.VelocityWindow24h,
+	constants.VelocityWindow7d,
+}
+
+// RuleFields returns the fields fraud rules can use: the transaction, the
+// user's profile, velocity and device/payee history, the weighted score and
+// the score of every registered risk factor as factors.<NAME>
+func RuleFields() rules.Fields {
+	fields := rules.Fields{
+		"txn.amount":                   rules.KindNumber,
+		"txn.mode":                     rules.KindString,
+		"txn.hour":                     rules.KindNumber,
+		"txn.weekday":                  rules.KindNumber,
+		"txn.device_id":                rules.KindString,
+		"txn.payee_id":                 rules.KindString,
+		"txn.located":                  rules.KindBool,
+		"profile.total_transactions":   rules.KindNumber,
+		"profile.allowed_transactions": rules.KindNumber,
+		"profile.average_amount":       rules.KindNumber,
+		"profile.max_amount":           rules.KindNumber,
+		"profile.confidence":           rules.KindNumber,
+		"device.known":                 rules.KindBool,
+		"device.known_devices":         rules.KindNumber,
+		"payee.payments":               rules.KindNumber,
+		"payee.known_payees":           rules.KindNumber,
+		"score.raw":                    rules.KindNumber,
+		"score.final":                  rules.KindNumber,
+	}
+	for _, w := range velocityWindows {
+		fields["velocity.count_"+w] = rules.KindNumber
+		fields["velocity.amount_"+w] = rules.KindNumber
+		fields["velocity.mode_count_"+w] = rules.KindNumber
+		fields["velocity.mode_amount_"+w] = rules.KindNumber
+	}
+	for _, r := range registeredRiskFactors() {
+		fields["factors."+r.factor.Name()] = rules.KindNumber
+	}
+	return fields
+}
+
+// CompileRule compiles a rule expression against RuleFields
+func CompileRule(expression string) (*rules.Expression, error) {
+	e, err := rules.Compile(expression, RuleFields())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidRuleExpression, err)
+	}
+	return e, nil
+}
+
+// RuleEnv returns the values of RuleFields for a scored transaction. Hour and
+// weekday (0 is Sunday) are in the user's local time, and velocity figures
+// include the transaction like the FREQUENCY_SPIKE factor's.
+func RuleEnv(txn *specs.TransactionInput, profile *repository.UserProfileBehavior, history *specs.TransactionHistory, result specs.FraudAnalysisResult) rules.Env {
+	env := rules.Env{
+		"txn.amount":                   txn.Amount,
+		"txn.mode":                     string(txn.Mode),
+		"txn.hour":                     txn.CreatedAt.Hour(),
+		"txn.weekday":                  int(txn.CreatedAt.Weekday()),
+		"txn.device_id":                txn.DeviceID,
+		"txn.payee_id":                 txn.PayeeID,
+		"txn.located":                  txn.Location != nil,
+		"profile.total_transactions":   profile.TotalTransactions,
+		"profile.allowed_transactions": profile.AllowedTransactions,
+		"profile.average_amount":       profile.AverageTransactionAmount.Float64,
+		"profile.max_amount":           profile.MaxTransactionAmountSeen.Float64,
+		"profile.confidence":           result.ProfileConfidence,
+		"device.known":                 history.Devices.Known,
+		"device.known_devices":         history.Devices.KnownDevices,
+		"payee.payments":               history.Payees.Payments,
+		"payee.known_payees":           history.Payees.KnownPayees,
+		"score.raw":                    result.RawRiskScore,
+		"score.final":                  result.FinalRiskScore,
+	}
+	for _, w := range velocityWindows {
+		v := history.VelocityWindow(w)
+		env["velocity.count_"+w] = v.Count + 1
+		env["velocity.amount_"+w] = v.Amount + txn.Amount
+		env["velocity.mode_count_"+w] = v.ModeCount + 1
+		env["velocity.mode_amount_"+w] = v.ModeAmount + txn.Amount
+	}
+	for _, f := range result.Factors {
+		env["factors."+f.Name] = f.Score
+	}
+	return env
+}
+
+// ApplyRules evaluates the enabled rules, highest priority first, against a
+// scored transaction. All matching rules see the weighted score: ADD_SCORE
+// deltas are summed into the final score, which then decides the transaction
+// again, ADD_TAG rules tag it and the highest priority matching SET_DECISION
+// rule overrides the decision; lower priority ones are not applied. A rule
+// that fails to evaluate does not match. Transactions decided by a list entry
+// are not evaluated.
+func ApplyRules(
+	cfg *specs.ScoringConfig,
+	compiled []specs.CompiledRule,
+	txn *specs.TransactionInput,
+	profile *repository.UserProfileBehavior,
+	history *specs.TransactionHistory,
+	result specs.FraudAnalysisResult,
+) specs.FraudAnalysisResult {
+	result.MatchedRules = []specs.MatchedRule{}
+	result.Tags = []string{}
+	if result.ListEntry != nil || len(compiled) == 0 {
+		return result
+	}
+
+	env := RuleEnv(txn, profile, history, result)
+
+	var decision *repository.TransactionDecision
+	scoreDelta := 0.0
+	for _, rule := range compiled {
+		match, err := rule.Expression.Match(env)
+		if err != nil || !match {
+			continue
+		}
+
+		switch rule.Action {
+		case repository.RuleActionSETDECISION:
+			if decision != nil {
+				continue
+			}
+			decision = &rule.Decision
+		case repository.RuleActionADDSCORE:
+			scoreDelta += rule.ScoreDelta
+		case repository.RuleActionADDTAG:
+			if !slices.Contains(result.Tags, rule.Tag) {
+				result.Tags = append(result.Tags, rule.Tag)
+			}
+		}
+		result.MatchedRules = append(result.MatchedRules, rule.MatchedRule)
+	}
+
+	if scoreDelta != 0 {
+		dampened := result.RawRiskScore * result.DampeningFactor
+		finalRiskScore := min(max(dampened+scoreDelta, 0.0), 100.0)
+		result.RuleScoreDelta = scoreDelta
+		result.FinalRiskScore = int32(finalRiskScore)
+		result.Decision = DetermineTransactionDecision(finalRiskScore, profile, cfg)
+	}
+	if decision != nil {
+		result.Decision = *decision
+	}
+	return result
+}
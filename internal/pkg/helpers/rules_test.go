@@ -0,0 +1,88 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compileRule(t *testing.T, id int32, expression string, rule specs.MatchedRule) specs.CompiledRule {
+	t.Helper()
+	e, err := CompileRule(expression)
+	require.NoError(t, err)
+	rule.ID, rule.Name = id, expression
+	return specs.CompiledRule{MatchedRule: rule, Expression: e}
+}
+
+func TestCompileRule(t *testing.T) {
+	_, err := CompileRule(`factors.NEW_PAYEE > 50 && velocity.count_24h >= 10 && profile.confidence < 20`)
+	assert.NoError(t, err)
+
+	_, err = CompileRule(`factors.UNKNOWN > 50`)
+	assert.ErrorIs(t, err, errors.ErrInvalidRuleExpression)
+}
+
+func TestApplyRules(t *testing.T) {
+	cfg := DefaultScoringConfig()
+	profile := NewEmptyUserProfile(1)
+	txn := &specs.TransactionInput{
+		Amount:    60000,
+		Mode:      repository.ModeCARD,
+		CreatedAt: time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC),
+	}
+	history := &specs.TransactionHistory{
+		Velocity: []specs.VelocityStats{{Window: constants.VelocityWindow1h, Count: 2}},
+	}
+	scored := AnalyzeTransaction(context.Background(), cfg, txn, profile, history)
+
+	nightCard := compileRule(t, 1, `txn.mode == "CARD" && txn.amount > 50_000 && txn.hour < 5`,
+		specs.MatchedRule{Action: repository.RuleActionSETDECISION, Decision: repository.TransactionDecisionBLOCK})
+	allowCards := compileRule(t, 2, `txn.mode == "CARD"`,
+		specs.MatchedRule{Action: repository.RuleActionSETDECISION, Decision: repository.TransactionDecisionALLOW})
+	burst := compileRule(t, 3, `velocity.count_1h == 3`,
+		specs.MatchedRule{Action: repository.RuleActionADDSCORE, ScoreDelta: 40})
+	tag := compileRule(t, 4, `txn.amount >= 10000`,
+		specs.MatchedRule{Action: repository.RuleActionADDTAG, Tag: "high_value"})
+	sameTag := compileRule(t, 5, `txn.amount >= 50000`,
+		specs.MatchedRule{Action: repository.RuleActionADDTAG, Tag: "high_value"})
+	upi := compileRule(t, 6, `txn.mode == "UPI"`,
+		specs.MatchedRule{Action: repository.RuleActionADDSCORE, ScoreDelta: 50})
+
+	t.Run("no rules", func(t *testing.T) {
+		result := ApplyRules(cfg, nil, txn, profile, history, scored)
+		assert.Equal(t, scored.Decision, result.Decision)
+		assert.Empty(t, result.MatchedRules)
+		assert.NotNil(t, result.Tags)
+	})
+
+	t.Run("highest priority decision wins", func(t *testing.T) {
+		result := ApplyRules(cfg, []specs.CompiledRule{nightCard, allowCards, upi, tag, sameTag}, txn, profile, history, scored)
+		assert.Equal(t, repository.TransactionDecisionBLOCK, result.Decision)
+		assert.Equal(t, []int32{1, 4, 5}, result.MatchedRuleIDs())
+		assert.Equal(t, []string{"high_value"}, result.Tags)
+		assert.Equal(t, scored.FinalRiskScore, result.FinalRiskScore)
+	})
+
+	t.Run("score deltas decide again", func(t *testing.T) {
+		result := ApplyRules(cfg, []specs.CompiledRule{burst}, txn, profile, history, scored)
+		assert.Equal(t, 40.0, result.RuleScoreDelta)
+		assert.Equal(t, scored.FinalRiskScore+40, result.FinalRiskScore)
+		assert.Equal(t, DetermineTransactionDecision(float64(result.FinalRiskScore), profile, cfg), result.Decision)
+		assert.Equal(t, scored.RawRiskScore, result.RawRiskScore)
+	})
+
+	t.Run("list entries skip rules", func(t *testing.T) {
+		entry := &specs.ListEntryResponse{ListType: repository.ListTypeALLOW}
+		decided := DecideByListEntry(cfg, profile, entry)
+		result := ApplyRules(cfg, []specs.CompiledRule{nightCard}, txn, profile, history, decided)
+		assert.Equal(t, repository.TransactionDecisionALLOW, result.Decision)
+		assert.Empty(t, result.MatchedRules)
+	})
+}
@@ -0,0 +1,39 @@
+package helpers
+
+import "time"
+
+// UserLocation loads the user's timezone, falling back to UTC when the stored
+// name cannot be loaded
+func UserLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LocalTime returns t in the client's local time: at the UTC offset the client
+// sent, or in the user's timezone when it sent none
+func LocalTime(t time.Time, loc *time.Location, utcOffsetMinutes *int) time.Time {
+	if utcOffsetMinutes != nil {
+		return t.In(time.FixedZone("", *utcOffsetMinutes*60))
+	}
+	return t.In(loc)
+}
+
+// BulkRowLocalTime returns a bulk row's created_at in the client's local time.
+// A numeric offset in the timestamp is the client's own; UTC ("Z") timestamps
+// are moved to the user's timezone.
+func BulkRowLocalTime(createdAt time.Time, loc *time.Location) time.Time {
+	if createdAt.Location() == time.UTC {
+		return createdAt.In(loc)
+	}
+	return createdAt
+}
+
+// UTCOffsetMinutes returns the offset of a local time from UTC, as stored with
+// the transaction
+func UTCOffsetMinutes(t time.Time) int32 {
+	_, offset := t.Zone()
+	return int32(offset / 60)
+}
@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalTime(t *testing.T) {
+	kolkata := UserLocation("Asia/Kolkata")
+	now := time.Date(2024, 1, 1, 21, 0, 0, 0, time.UTC)
+
+	t.Run("user timezone", func(t *testing.T) {
+		local := LocalTime(now, kolkata, nil)
+		assert.Equal(t, 2, local.Hour())
+		assert.Equal(t, time.Tuesday, local.Weekday())
+		assert.Equal(t, int32(330), UTCOffsetMinutes(local))
+		assert.True(t, local.Equal(now))
+	})
+
+	t.Run("client offset wins", func(t *testing.T) {
+		offset := -300
+		local := LocalTime(now, kolkata, &offset)
+		assert.Equal(t, 16, local.Hour())
+		assert.Equal(t, int32(-300), UTCOffsetMinutes(local))
+	})
+
+	t.Run("unknown timezone falls back to UTC", func(t *testing.T) {
+		assert.Equal(t, time.UTC, UserLocation("Mars/Olympus"))
+		assert.Equal(t, 21, LocalTime(now, UserLocation(""), nil).Hour())
+	})
+}
+
+func TestBulkRowLocalTime(t *testing.T) {
+	kolkata := UserLocation("Asia/Kolkata")
+
+	createdAt, ok := BulkRowTimestamp([]string{"100", "UPI", "2024-01-01T21:00:00Z"})
+	assert.True(t, ok)
+	assert.Equal(t, 2, BulkRowLocalTime(createdAt, kolkata).Hour(), "UTC rows use the user's timezone")
+
+	createdAt, ok = BulkRowTimestamp([]string{"100", "UPI", "2024-01-01T16:00:00-05:00"})
+	assert.True(t, ok)
+	local := BulkRowLocalTime(createdAt, kolkata)
+	assert.Equal(t, 16, local.Hour(), "an explicit offset is the client's")
+	assert.Equal(t, int32(-300), UTCOffsetMinutes(local))
+}
+
+func TestTimeAnomalyRiskInUserTimezone(t *testing.T) {
+	profile := NewEmptyUserProfile(1)
+	// 8 AM in Kolkata is 2:30 AM in UTC
+	now := time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, 35.0, CalculateTimeAnomalyRisk(now, profile))
+	assert.Equal(t, 5.0, CalculateTimeAnomalyRisk(LocalTime(now, UserLocation("Asia/Kolkata"), nil), profile))
+
+	// the profile learns the local hour, and stores its decay time in UTC
+	ApplyTransactionToProfile(profile, 100, repository.ModeUPI, LocalTime(now, UserLocation("Asia/Kolkata"), nil), repository.TransactionDecisionALLOW)
+	assert.Equal(t, 1.0, profile.HourHistogram[8])
+	assert.Equal(t, time.UTC, profile.HistogramDecayedAt.Time.Location())
+	assert.True(t, profile.HistogramDecayedAt.Time.Equal(now))
+}
@@ -1,6 +1,9 @@
 package helpers
 
 import (
+	"context"
+	"encoding/json"
+	"math"
 	"slices"
 	"time"
 
@@ -21,29 +24,41 @@ func CalculateProfileConfidence(profile *repository.UserProfileBehavior) float64
 }
 
 // CalculateAmountDeviationRisk calculates risk based on how
-// much the transaction amount deviates from user's average
-// spending patterns using Z-Score
-func CalculateAmountDeviationRisk(transactionAmount int32, profile *repository.UserProfileBehavior) float64 {
+// much the transaction amount deviates from user's usual
+// spending, using the distribution of the transaction's mode
+// once it has enough history (see AmountBaseline).
+// cfg.AmountScoring selects a Z-Score on the mean and standard
+// deviation or a robust score on the median and MAD.
+func CalculateAmountDeviationRisk(transactionAmount float64, mode repository.Mode, profile *repository.UserProfileBehavior, cfg *specs.ScoringConfig) float64 {
 	// If not enough data or profile incomplete, use heuristic based on Max seen
 	if !profile.AverageTransactionAmount.Valid ||
-		profile.TotalTransactions < constants.MinTransactionsForProfiling {
+		profile.TotalTransactions < cfg.MinTransactionsForProfiling {
 
 		if !profile.MaxTransactionAmountSeen.Valid {
 			return 30.0
 		}
 
 		// comparing with maximum transaction seen till now
-		if transactionAmount > int32(profile.MaxTransactionAmountSeen.Float64) {
-			ratio := float64(transactionAmount) / float64(profile.MaxTransactionAmountSeen.Float64)
+		if transactionAmount > profile.MaxTransactionAmountSeen.Float64 {
+			ratio := transactionAmount / profile.MaxTransactionAmountSeen.Float64
 			risk := 20.0 + (ratio-1.0)*30.0
 			return min(risk, 100.0)
 		}
 		return 10.0
 	}
 
-	avgAmount := float64(profile.AverageTransactionAmount.Float64)
-	stdDev := float64(profile.StdDevTransactionAmount.Int32)
-	txAmount := float64(transactionAmount)
+	baseline, _ := AmountBaseline(profile, mode, cfg)
+
+	// profiles only updated incrementally have no robust statistics yet
+	if cfg.AmountScoring == constants.AmountScoringRobust && baseline.Median > 0 {
+		return robustAmountRisk(transactionAmount, baseline)
+	}
+	return zScoreAmountRisk(transactionAmount, baseline)
+}
+
+func zScoreAmountRisk(txAmount float64, baseline specs.AmountStats) float64 {
+	avgAmount := baseline.Mean
+	stdDev := baseline.StdDev
 
 	// If StdDev is 0, it means all previous transactions were the exact same amount.
 	// Any deviation is technically "infinite" Z-score.
@@ -79,33 +94,129 @@ func CalculateAmountDeviationRisk(transactionAmount int32, profile *repository.U
 	return min(risk, 100.0)
 }
 
-// CalculateFrequencySpikeRisk calculates risk based on transaction frequency
-// Cumulative logic: after 3 consecutive transactions in 1 hour window,
-// each transaction adds X (20) to risk score.
+// robustAmountRisk maps the robust Z-Score (X - median) / (1.4826 * MAD) like
+// the Z-Score, so a single past outlier cannot widen the baseline. When more
+// than half the amounts are identical the MAD is 0 and the upper percentiles
+// are used instead: up to p90 is usual, p90 to p99 scores up to 50 and beyond
+// p99 it is 50 plus 50 per extra multiple of p99.
+func robustAmountRisk(txAmount float64, baseline specs.AmountStats) float64 {
+	if txAmount <= baseline.Median {
+		return 0.0
+	}
+
+	if baseline.MAD > 0 {
+		zScore := (txAmount - baseline.Median) / (constants.MADScale * baseline.MAD)
+		if zScore <= 1.0 {
+			return 0.0
+		}
+		return min((zScore-1.0)*25.0, 100.0)
+	}
+
+	switch {
+	case txAmount <= baseline.P90:
+		return 0.0
+	case txAmount <= baseline.P99:
+		return 50.0 * (txAmount - baseline.P90) / (baseline.P99 - baseline.P90)
+	default:
+		return min(50.0+(txAmount/baseline.P99-1.0)*50.0, 100.0)
+	}
+}
+
+// AmountBaseline returns the amount distribution a transaction of the given
+// mode is compared against, and the mode it belongs to: the mode's own once it
+// has MinTransactionsForProfiling legitimate transactions, the user's overall
+// one (mode "") otherwise. The overall mean and standard deviation are read
+// from the profile columns, which stay current between profile rebuilds.
+func AmountBaseline(profile *repository.UserProfileBehavior, mode repository.Mode, cfg *specs.ScoringConfig) (specs.AmountStats, repository.Mode) {
+	var byMode map[repository.Mode]specs.AmountStats
+	if len(profile.ModeAmountStats) > 0 && json.Unmarshal(profile.ModeAmountStats, &byMode) == nil {
+		if stats, ok := byMode[mode]; ok && stats.Count > 0 && stats.Count >= int64(cfg.MinTransactionsForProfiling) {
+			return stats, mode
+		}
+	}
+
+	var stats specs.AmountStats
+	if len(profile.AmountStats) > 0 && json.Unmarshal(profile.AmountStats, &stats) != nil {
+		stats = specs.AmountStats{}
+	}
+	stats.Count = int64(profile.AllowedTransactions)
+	stats.Mean = profile.AverageTransactionAmount.Float64
+	stats.StdDev = profile.StdDevTransactionAmount.Float64
+	return stats, ""
+}
+
+// CalculateFrequencySpikeRisk scores the user's velocity against the config's
+// limits. Every window is checked per user and per user+mode: each transaction
+// past a count limit adds cfg.RiskPerTxnAfterThreshold (20), and going over an
+// amount limit scores 50 plus 50 per extra multiple of the limit. The riskiest
+// window decides the score and is returned for the explanation.
 func CalculateFrequencySpikeRisk(
-	profile *repository.UserProfileBehavior,
-	recentTransactionCount int,
-) float64 {
+	transactionAmount float64,
+	velocity []specs.VelocityStats,
+	cfg *specs.ScoringConfig,
+) (float64, specs.VelocityStats) {
+	risk := 0.0
+	worst := specs.TransactionHistory{Velocity: velocity}.VelocityWindow(constants.VelocityWindow1h)
+
+	for _, v := range velocity {
+		limit := velocityLimit(v.Window, cfg)
+
+		// counts include the transaction being analyzed
+		windowRisk := max(
+			countExcessRisk(v.Count+1, limit.Count, cfg),
+			countExcessRisk(v.ModeCount+1, limit.ModeCount, cfg),
+			amountExcessRisk(v.Amount+transactionAmount, limit.Amount),
+		)
+		if windowRisk > risk {
+			risk, worst = windowRisk, v
+		}
+	}
 
-	// "After 3 consecutive transactions... add X into risk score"
-	// recentTransactionCount includes the current one being analyzed?
-	// Usually countRecentTransactions excludes current/pending, but the handler passed count.
-	// Let's assume 'recentTransactionCount' is the count of *past* transactions in the window.
-	// So if recent=3, this is the 4th transaction.
+	return min(risk, 100.0), worst
+}
 
-	// Total including current
-	currentTxnCount := recentTransactionCount + 1
+// velocityLimit returns the config's limits of a window; the 1h count limit is
+// cfg.ThresholdFrequency
+func velocityLimit(window string, cfg *specs.ScoringConfig) specs.VelocityLimit {
+	limit := cfg.VelocityLimits[window]
+	if window == constants.VelocityWindow1h {
+		limit.Count = cfg.ThresholdFrequency
+	}
+	return limit
+}
 
-	if currentTxnCount <= constants.ThresholdFrequency {
-		return 0.0
+// VelocityExcess returns the figure of a velocity window that is furthest over
+// its limit, with the limit and the figure's unit: the window's count, its
+// count for the transaction's mode or its amount, all including the
+// transaction. Within every limit the window's count is returned.
+func VelocityExcess(transactionAmount float64, window specs.VelocityStats, cfg *specs.ScoringConfig) (float64, float64, string) {
+	limit := velocityLimit(window.Window, cfg)
+
+	observed, baseline, unit := float64(window.Count+1), float64(limit.Count), constants.UnitTransactions
+	risk := countExcessRisk(window.Count+1, limit.Count, cfg)
+
+	if r := countExcessRisk(window.ModeCount+1, limit.ModeCount, cfg); r > risk {
+		observed, baseline, risk = float64(window.ModeCount+1), float64(limit.ModeCount), r
+	}
+	if r := amountExcessRisk(window.Amount+transactionAmount, limit.Amount); r > risk {
+		observed, baseline, unit = window.Amount+transactionAmount, limit.Amount, constants.UnitAmount
 	}
+	return observed, baseline, unit
+}
 
-	// For 4th txn (count=3+1=4): risk = (4-3)*20 = 20
-	// For 5th txn: risk = 40
-	excess := float64(currentTxnCount - constants.ThresholdFrequency)
-	risk := excess * constants.RiskPerTxnAfterThreshold
+// countExcessRisk: for the 4th txn with a limit of 3, risk = (4-3)*20 = 20
+func countExcessRisk(count int, limit int, cfg *specs.ScoringConfig) float64 {
+	if limit <= 0 || count <= limit {
+		return 0.0
+	}
+	return float64(count-limit) * cfg.RiskPerTxnAfterThreshold
+}
 
-	return min(risk, 100.0)
+func amountExcessRisk(amount float64, limit float64) float64 {
+	if limit <= 0 || amount <= limit {
+		return 0.0
+	}
+	return 50.0 + (amount/limit-1.0)*50.0
 }
 
 // CalculateModeDeviationRisk calculates risk when user uses a payment mode
@@ -134,13 +245,93 @@ func CalculateModeDeviationRisk(transactionMode repository.Mode, profile *reposi
 	return max(risk, 20.0)
 }
 
-// CalculateTimeAnomalyRisk calculates risk based on transaction time
-// compared to user's usual trasaction hours
+// CalculateNewDeviceRisk calculates risk when the user pays from a device
+// they never made a legitimate transaction from. Transactions without a
+// device_id and users without any known device yet carry no device risk.
+func CalculateNewDeviceRisk(deviceID string, devices specs.DeviceHistory, profile *repository.UserProfileBehavior) float64 {
+	if deviceID == "" || devices.Known || devices.KnownDevices == 0 {
+		return 0.0
+	}
+
+	// Like a new mode, a new device is less suspicious for users with high
+	// profile confidence
+	// Formula: 70 - (profile_confidence * 0.3)
+	// 100% confidence: 70 - 30 = 40 risk
+	// 0% confidence: 70 - 0 = 70 risk
+	baseRisk := 70.0
+	reduction := (CalculateProfileConfidence(profile) / 100.0) * 30.0
+	return baseRisk - reduction
+}
+
+// CalculateNewPayeeRisk calculates risk when the user pays a payee they paid
+// fewer than TrustedPayeeMinPayments times before
+func CalculateNewPayeeRisk(payeeID string, amount float64, payees specs.PayeeHistory, profile *repository.UserProfileBehavior) float64 {
+	if payeeID == "" || payees.KnownPayees == 0 || payees.Payments >= constants.TrustedPayeeMinPayments {
+		return 0.0
+	}
+
+	// A first payment above the user's average is riskier
+	// Formula: 50 + 25 * (amount / average - 1), capped at 100
+	// At or below the average: 50 risk
+	// 3x the average or more: 100 risk
+	risk := 50.0
+	if average := profile.AverageTransactionAmount.Float64; average > 0 && amount > average {
+		risk = math.Min(100.0, risk+25.0*(amount/average-1))
+	}
+
+	// Every earlier payment makes the payee more familiar, until it is trusted
+	return risk * (1 - float64(payees.Payments)/constants.TrustedPayeeMinPayments)
+}
+
+// CalculateImpossibleTravelRisk calculates risk when the user would have had
+// to travel faster than an airliner since their previous located transaction
+func CalculateImpossibleTravelRisk(location *specs.GeoPoint, at time.Time, previous *specs.LocatedTransaction) float64 {
+	if location == nil || previous == nil {
+		return 0.0
+	}
+
+	distance := DistanceKm(previous.GeoPoint, *location)
+	if distance < constants.ImpossibleTravelMinDistanceKm {
+		return 0.0
+	}
+
+	hours := at.Sub(previous.CreatedAt).Hours()
+	if hours <= 0 {
+		return 100.0
+	}
+
+	// Formula: 50 * (speed / max_speed), from the max speed up
+	// 1x max speed: 50 risk
+	// 2x max speed or more: 100 risk
+	ratio := distance / hours / constants.ImpossibleTravelMaxSpeedKmh
+	if ratio <= 1 {
+		return 0.0
+	}
+	return math.Min(100.0, 50.0*ratio)
+}
+
+// DistanceKm returns the great-circle distance between two points using the
+// haversine formula
+func DistanceKm(a, b specs.GeoPoint) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * constants.EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// CalculateTimeAnomalyRisk calculates risk based on how rare the transaction's
+// hour is in the user's hour-of-day histogram, blended with the weekday+hour
+// histogram once that one holds enough history
 func CalculateTimeAnomalyRisk(transactionTime time.Time, profile *repository.UserProfileBehavior) float64 {
 	currentHour := transactionTime.Hour()
 
+	hourDensity, ok := HistogramDensity(profile.HourHistogram, currentHour, constants.HourHistogramMinWeight)
+
 	// No pattern established - use general heuristics
-	if !profile.UsualTransactionStartHour.Valid || !profile.UsualTransactionEndHour.Valid {
+	if !ok {
 		// Late night/early morning (12 AM - 5 AM) is riskier
 		if currentHour >= 0 && currentHour < 5 {
 			return 35.0
@@ -152,57 +343,79 @@ func CalculateTimeAnomalyRisk(transactionTime time.Time, profile *repository.Use
 		return 5.0
 	}
 
-	// Extract hours from timestamps
-	startHour := profile.UsualTransactionStartHour.Time.Hour()
-	endHour := profile.UsualTransactionEndHour.Time.Hour()
+	risk := hourRarityRisk(hourDensity, currentHour)
 
-	// check if within usual hours
-	isWithinUsualHours := false
-	if startHour <= endHour {
-		isWithinUsualHours = currentHour >= startHour && currentHour <= endHour
-	} else {
-		isWithinUsualHours = currentHour >= startHour || currentHour <= endHour
+	weekdayDensity, ok := HistogramDensity(profile.WeekdayHourHistogram, weekdayHourBucket(transactionTime), constants.WeekdayHistogramMinWeight)
+	if ok {
+		risk = 0.7*risk + 0.3*hourRarityRisk(weekdayDensity, currentHour)
 	}
 
-	if isWithinUsualHours {
+	return min(risk, 100.0)
+}
+
+// hourRarityRisk maps a histogram density to risk: hours at least as busy as
+// an average hour score 0, never-seen hours score 70, plus 15 between 12 AM and 4 AM
+func hourRarityRisk(density float64, hour int) float64 {
+	if density >= 1.0 {
 		return 0.0
 	}
 
-	var hoursOutside int
-	if startHour <= endHour {
-		if currentHour < startHour {
-			hoursOutside = startHour - currentHour
-		} else {
-			hoursOutside = currentHour - endHour
-		}
-	} else {
-		if currentHour > endHour && currentHour < startHour {
-			distFromEnd := currentHour - endHour
-			distFromStart := startHour - currentHour
-			hoursOutside = min(distFromEnd, distFromStart)
-		}
+	risk := (1.0 - density) * 70.0
+	if hour >= 0 && hour < 4 {
+		risk += 15.0
 	}
+	return risk
+}
 
-	risk := float64(hoursOutside) * 10.0
+// HistogramDensity compares a bucket's weight, smoothed with half of each
+// neighbouring bucket, to the weight of an average bucket: 1 means as busy as
+// usual, 0 never seen. It reports false while the histogram holds less than minWeight.
+func HistogramDensity(histogram []float64, bucket int, minWeight float64) (float64, bool) {
+	n := len(histogram)
+	if n == 0 || bucket < 0 || bucket >= n {
+		return 0, false
+	}
 
-	if currentHour >= 0 && currentHour < 4 {
-		risk += 15.0
+	total := 0.0
+	for _, w := range histogram {
+		total += w
+	}
+	if total < minWeight {
+		return 0, false
 	}
 
-	return min(risk, 100.0)
+	smoothed := histogram[bucket] + 0.5*(histogram[(bucket+n-1)%n]+histogram[(bucket+1)%n])
+	// smoothing doubles the total weight
+	average := 2 * total / float64(n)
+	return smoothed / average, true
 }
 
-// CalculateAggregateRiskScore combines all facor scores into final risk score
-// using weighted sum
-func CalculateAggregateRiskScore(
-	amountRisk float64,
-	frequencyRisk float64,
-	modeRisk float64,
-	timeRisk float64,
-) float64 {
-	aggregateRisk := (amountRisk * constants.WeightAmountDeviation) + (frequencyRisk * constants.WeightFrequencySpike) + (modeRisk * constants.WeightModeDeviation) + (timeRisk * constants.WeightTimeAnomaly)
-
-	return min(aggregateRisk, 100.0)
+// CalculateAggregateRiskScore combines all factor scores into final risk score
+// using weighted sum, renormalized over the factors that apply so a missing
+// device, location or payee does not dilute the others
+func CalculateAggregateRiskScore(factors []specs.FactorResult) float64 {
+	total := ApplicableWeight(factors)
+	if total <= 0 {
+		return 0
+	}
+
+	aggregateRisk := 0.0
+	for _, f := range factors {
+		if !f.Skipped {
+			aggregateRisk += f.Score * f.Weight
+		}
+	}
+
+	return min(aggregateRisk/total, 100.0)
+}
+
+// DampeningFactor returns the ratio of the dampened to the raw risk score, 1
+// when there is no risk to dampen
+func DampeningFactor(rawRiskScore float64, dampenedRiskScore float64) float64 {
+	if rawRiskScore <= 0 {
+		return 1.0
+	}
+	return dampenedRiskScore / rawRiskScore
 }
 
 // DampenRiskWithProfileConfidence reduces risk score based on
@@ -229,117 +442,123 @@ func DampenRiskWithProfileConfidence(rawRiskScore float64, profileConfidence flo
 }
 
 // DetermineTriggeredFactors identifies which factors exceeded their thresholds
-func DetermineTriggeredFactors(
-	amountRisk float64,
-	frequencyRisk float64,
-	modeRisk float64,
-	timeRisk float64,
-) []string {
+func DetermineTriggeredFactors(factors []specs.FactorResult) []string {
 	triggered := []string{}
 
-	if amountRisk > constants.ThresholdAmountDeviation {
-		triggered = append(triggered, constants.TriggerFactorsAMOUNTDEVIATION)
-	}
-	if frequencyRisk > constants.ThresholdFrequencySpike {
-		triggered = append(triggered, constants.TriggerFactorsFREQUENCYSPIKE)
-	}
-	if modeRisk > constants.ThresholdModeDeviation {
-		triggered = append(triggered, constants.TriggerFactorsNEWMODE)
-	}
-	if timeRisk > constants.ThresholdTimeAnomaly {
-		triggered = append(triggered, constants.TriggerFactorsTIMEANOMALY)
+	for _, f := range factors {
+		if f.Triggered {
+			triggered = append(triggered, f.Name)
+		}
 	}
 	return triggered
 }
 
 // DetermineTransactionDecision decides the action based on final risk score
-func DetermineTransactionDecision(finalRiskScore float64, profile *repository.UserProfileBehavior) repository.TransactionDecision {
-	if profile.TotalTransactions < constants.MinTransactionsForProfiling {
-		if finalRiskScore < 60.0 {
+func DetermineTransactionDecision(finalRiskScore float64, profile *repository.UserProfileBehavior, cfg *specs.ScoringConfig) repository.TransactionDecision {
+	if profile.TotalTransactions < cfg.MinTransactionsForProfiling {
+		if finalRiskScore < cfg.ColdStartThresholdAllow {
 			return repository.TransactionDecisionALLOW
-		} else if finalRiskScore < 75.0 {
+		} else if finalRiskScore < cfg.ColdStartThresholdFlag {
 			return repository.TransactionDecisionFLAG
 		}
 		return repository.TransactionDecisionMFAREQUIRED
 	}
 
-	if finalRiskScore < constants.RiskThresholdAllow {
+	if finalRiskScore < cfg.RiskThresholdAllow {
 		return repository.TransactionDecisionALLOW
-	} else if finalRiskScore < constants.RiskThresholdFlag {
+	} else if finalRiskScore < cfg.RiskThresholdFlag {
 		return repository.TransactionDecisionFLAG
-	} else if finalRiskScore < constants.RiskThresholdMFA {
+	} else if finalRiskScore < cfg.RiskThresholdMFA {
 		return repository.TransactionDecisionMFAREQUIRED
 	}
 	return repository.TransactionDecisionBLOCK
 }
 
+// AnalyzeBulkTransactions analyzes a row of a bulk upload, which carries its
+// own timestamp, and applies the fraud rules to it. A row of a user on the
+// allow or deny list is decided by listEntry instead.
 func AnalyzeBulkTransactions(
+	ctx context.Context,
+	cfg *specs.ScoringConfig,
+	compiled []specs.CompiledRule,
 	req *specs.CreateBulkTransactionRequest,
 	profile *repository.UserProfileBehavior,
-	recentTransactionCount int,
+	velocity []specs.VelocityStats,
+	listEntry *specs.ListEntryResponse,
 ) specs.FraudAnalysisResult {
-	amountRisk := CalculateAmountDeviationRisk(int32(req.Amount), profile)
-	frequencyRisk := CalculateFrequencySpikeRisk(profile, recentTransactionCount)
-	modeRisk := CalculateModeDeviationRisk(repository.Mode(req.Mode), profile)
-	timeRisk := CalculateTimeAnomalyRisk(req.CreatedAt, profile)
-
-	rawRiskScore := CalculateAggregateRiskScore(amountRisk, frequencyRisk, modeRisk, timeRisk)
-
-	profileConfidence := CalculateProfileConfidence(profile)
-
-	finalRiskScore := DampenRiskWithProfileConfidence(rawRiskScore, profileConfidence)
-
-	triggeredFactors := DetermineTriggeredFactors(amountRisk, frequencyRisk, modeRisk, timeRisk)
+	txn := &specs.TransactionInput{
+		Amount:    req.Amount,
+		Mode:      repository.Mode(req.Mode),
+		CreatedAt: req.CreatedAt,
+	}
+	history := &specs.TransactionHistory{
+		Velocity:  velocity,
+		ListEntry: listEntry,
+	}
+	result := AnalyzeTransaction(ctx, cfg, txn, profile, history)
+	return ApplyRules(cfg, compiled, txn, profile, history, result)
+}
 
-	decision := DetermineTransactionDecision(finalRiskScore, profile)
+// DecideByListEntry skips scoring for a transaction matching an allow or deny
+// list entry: it is blocked with the highest risk score, or allowed with none
+func DecideByListEntry(cfg *specs.ScoringConfig, profile *repository.UserProfileBehavior, entry *specs.ListEntryResponse) specs.FraudAnalysisResult {
+	decision, riskScore := repository.TransactionDecisionALLOW, 0.0
+	if entry.ListType == repository.ListTypeDENY {
+		decision, riskScore = repository.TransactionDecisionBLOCK, 100.0
+	}
 
 	return specs.FraudAnalysisResult{
 		Message:           "analysis result",
+		ConfigVersion:     cfg.Version,
 		Decision:          decision,
-		FinalRiskScore:    int32(finalRiskScore),
-		RawRiskScore:      rawRiskScore,
-		ProfileConfidence: profileConfidence,
-		TriggeredFactors:  triggeredFactors,
-		AmountRisk:        amountRisk,
-		FrequencyRisk:     frequencyRisk,
-		ModeRisk:          modeRisk,
-		TimeRisk:          timeRisk,
+		FinalRiskScore:    int32(riskScore),
+		RawRiskScore:      riskScore,
+		ProfileConfidence: CalculateProfileConfidence(profile),
+		DampeningFactor:   1.0,
+		TriggeredFactors:  []string{},
+		Factors:           []specs.FactorResult{},
+		MatchedRules:      []specs.MatchedRule{},
+		Tags:              []string{},
+		ListEntry:         entry,
 	}
-
 }
 
-// AnalyzeTransaction performs complete fraud analysis and returns specs.FraudAnalysisResult
+// AnalyzeTransaction performs complete fraud analysis under the given scoring
+// config and returns specs.FraudAnalysisResult
 func AnalyzeTransaction(
-	req *specs.CreateTransactionRequest,
+	ctx context.Context,
+	cfg *specs.ScoringConfig,
+	txn *specs.TransactionInput,
 	profile *repository.UserProfileBehavior,
-	recentTransactionCount int,
-	transactionTime time.Time,
+	history *specs.TransactionHistory,
 ) specs.FraudAnalysisResult {
-	amountRisk := CalculateAmountDeviationRisk(int32(req.Amount), profile)
-	frequencyRisk := CalculateFrequencySpikeRisk(profile, recentTransactionCount)
-	modeRisk := CalculateModeDeviationRisk(repository.Mode(req.Mode), profile)
-	timeRisk := CalculateTimeAnomalyRisk(transactionTime, profile)
+	if history.ListEntry != nil {
+		return DecideByListEntry(cfg, profile, history.ListEntry)
+	}
+
+	factors := EvaluateRiskFactors(ctx, cfg, txn, profile, history)
 
-	rawRiskScore := CalculateAggregateRiskScore(amountRisk, frequencyRisk, modeRisk, timeRisk)
+	rawRiskScore := CalculateAggregateRiskScore(factors)
 
 	profileConfidence := CalculateProfileConfidence(profile)
 
 	finalRiskScore := DampenRiskWithProfileConfidence(rawRiskScore, profileConfidence)
 
-	triggeredFactors := DetermineTriggeredFactors(amountRisk, frequencyRisk, modeRisk, timeRisk)
+	triggeredFactors := DetermineTriggeredFactors(factors)
 
-	decision := DetermineTransactionDecision(finalRiskScore, profile)
+	decision := DetermineTransactionDecision(finalRiskScore, profile, cfg)
 
 	return specs.FraudAnalysisResult{
 		Message:           "analysis result",
+		ConfigVersion:     cfg.Version,
 		Decision:          decision,
 		FinalRiskScore:    int32(finalRiskScore),
 		RawRiskScore:      rawRiskScore,
 		ProfileConfidence: profileConfidence,
+		DampeningFactor:   DampeningFactor(rawRiskScore, finalRiskScore),
 		TriggeredFactors:  triggeredFactors,
-		AmountRisk:        amountRisk,
-		FrequencyRisk:     frequencyRisk,
-		ModeRisk:          modeRisk,
-		TimeRisk:          timeRisk,
+		Factors:           factors,
+		MatchedRules:      []specs.MatchedRule{},
+		Tags:              []string{},
 	}
 }
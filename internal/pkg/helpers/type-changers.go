@@ -17,11 +17,3 @@ func GetModeSliceFromStringSlice(stringModes []string) []repository.Mode {
 	}
 	return modes
 }
-
-func GetStringSliceFromTriggerFactorsSlice(factors []repository.TriggerFactors) []string {
-	stringFactors := []string{}
-	for _, factor := range factors {
-		stringFactors = append(stringFactors, string(factor))
-	}
-	return stringFactors
-}
@@ -2,15 +2,19 @@ package middleware
 
 import (
 	"context"
+	stderrors "errors"
 	"net/http"
+	"slices"
 
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 	"github.com/redis/go-redis/v9"
 )
 
 type redisClient interface {
 	Exists(ctx context.Context, keys ...string) *redis.IntCmd
+	Get(ctx context.Context, key string) *redis.StringCmd
 }
 
 func AuthMiddleware(RD redisClient) func(http.Handler) http.Handler {
@@ -36,8 +40,42 @@ func AuthMiddleware(RD redisClient) func(http.Handler) http.Handler {
 				return
 			}
 
+			// Tokens issued before the user's role changed carry the old role
+			revokedAt, err := RD.Get(r.Context(), helpers.RevokedTokensKey(claims.UserID)).Int64()
+			switch {
+			case stderrors.Is(err, redis.Nil):
+			case err != nil:
+				ErrorResponse(w, http.StatusInternalServerError, errors.ErrAuthServiceUnavailable)
+				return
+			case claims.IssuedAt == nil || claims.IssuedAt.Unix() <= revokedAt:
+				ErrorResponse(w, http.StatusUnauthorized, errors.ErrExpiredToken)
+				return
+			}
+
 			// Attach user identity to request context
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// RequireRole only lets requests through whose token carries one of the given
+// roles. It is meant to run after AuthMiddleware, which rejects tokens issued
+// before the user's role last changed.
+func RequireRole(roles ...repository.UserRole) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, err := helpers.GetRoleFromRequest(r)
+			if err != nil {
+				ErrorResponse(w, http.StatusUnauthorized, errors.ErrInvalidToken)
+				return
+			}
+
+			if !slices.Contains(roles, role) {
+				ErrorResponse(w, http.StatusForbidden, errors.ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
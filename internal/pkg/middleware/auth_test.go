@@ -5,10 +5,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -24,6 +26,11 @@ func (m *mockRedis) Exists(ctx context.Context, keys ...string) *redis.IntCmd {
 	return args.Get(0).(*redis.IntCmd)
 }
 
+func (m *mockRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	args := m.Called(ctx, key)
+	return args.Get(0).(*redis.StringCmd)
+}
+
 type mockHandler struct {
 	mock.Mock
 }
@@ -40,7 +47,7 @@ func TestAuthMiddleware(t *testing.T) {
 	middleware := AuthMiddleware(mockRD)(mockNext)
 
 	t.Run("Success - valid token", func(t *testing.T) {
-		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
@@ -49,6 +56,10 @@ func TestAuthMiddleware(t *testing.T) {
 		cmd := redis.NewIntCmd(context.Background())
 		cmd.SetVal(0)
 		mockRD.On("Exists", mock.Anything, mock.Anything).Return(cmd).Once()
+		// and no revocation of the user's tokens
+		revoked := redis.NewStringCmd(context.Background())
+		revoked.SetErr(redis.Nil)
+		mockRD.On("Get", mock.Anything, helpers.RevokedTokensKey(1)).Return(revoked).Once()
 
 		mockNext.On("ServeHTTP", w, mock.Anything).Return().Once()
 
@@ -60,7 +71,7 @@ func TestAuthMiddleware(t *testing.T) {
 	})
 
 	t.Run("Failure - blacklisted token", func(t *testing.T) {
-		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "testsecret", time.Hour)
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "CUSTOMER", "testsecret", time.Hour)
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
@@ -76,6 +87,25 @@ func TestAuthMiddleware(t *testing.T) {
 		mockRD.AssertExpectations(t)
 	})
 
+	t.Run("Failure - token issued before a role change", func(t *testing.T) {
+		token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", "ADMIN", "testsecret", time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		cmd := redis.NewIntCmd(context.Background())
+		cmd.SetVal(0)
+		mockRD.On("Exists", mock.Anything, mock.Anything).Return(cmd).Once()
+		revoked := redis.NewStringCmd(context.Background())
+		revoked.SetVal(strconv.FormatInt(time.Now().Unix(), 10))
+		mockRD.On("Get", mock.Anything, helpers.RevokedTokensKey(1)).Return(revoked).Once()
+
+		middleware.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockRD.AssertExpectations(t)
+	})
+
 	t.Run("Failure - missing token", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
 		w := httptest.NewRecorder()
@@ -95,3 +125,36 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
+
+func TestRequireRole(t *testing.T) {
+	os.Setenv("JWT_SECRET", "testsecret")
+
+	tests := []struct {
+		name string
+		role repository.UserRole
+		code int
+	}{
+		{"analyst allowed", repository.UserRoleANALYST, http.StatusOK},
+		{"admin allowed", repository.UserRoleADMIN, http.StatusOK},
+		{"customer forbidden", repository.UserRoleCUSTOMER, http.StatusForbidden},
+		{"token without role treated as customer", "", http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := RequireRole(repository.UserRoleANALYST, repository.UserRoleADMIN)(next)
+
+			token, _ := helpers.MakeJWT(1, "Test User", "test@example.com", tc.role, "testsecret", time.Hour)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+}
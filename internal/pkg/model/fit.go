@@ -0,0 +1,152 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+)
+
+// FitOptions tune FitLogistic
+type FitOptions struct {
+	// Epochs is the number of full-batch gradient descent steps
+	Epochs       int
+	LearningRate float64
+	// L2 penalizes large weights
+	L2 float64
+	// Balanced weighs fraud and legitimate transactions equally in the loss,
+	// however rare fraud is
+	Balanced bool
+}
+
+// DefaultFitOptions suit a few thousand labeled transactions
+func DefaultFitOptions() FitOptions {
+	return FitOptions{
+		Epochs:       2000,
+		LearningRate: 0.5,
+		L2:           0.001,
+		Balanced:     true,
+	}
+}
+
+// FitLogistic fits a logistic regression predicting y (fraud) from the rows
+// of x, laid out like features. Inputs are standardized and the model keeps
+// their means and scales, so its weights compare the features' influence.
+func FitLogistic(features []string, x [][]float64, y []bool, opts FitOptions) (*Model, error) {
+	if len(x) != len(y) {
+		return nil, fmt.Errorf("%d rows for %d labels", len(x), len(y))
+	}
+	for i, row := range x {
+		if len(row) != len(features) {
+			return nil, fmt.Errorf("row %d has %d features, want %d", i, len(row), len(features))
+		}
+	}
+	positives := 0
+	for _, fraud := range y {
+		if fraud {
+			positives++
+		}
+	}
+	if positives == 0 || positives == len(y) {
+		return nil, errors.ErrInsufficientTrainingData
+	}
+
+	n, k := len(x), len(features)
+	means := make([]float64, k)
+	scales := make([]float64, k)
+	for j := range k {
+		for _, row := range x {
+			means[j] += row[j]
+		}
+		means[j] /= float64(n)
+		for _, row := range x {
+			scales[j] += (row[j] - means[j]) * (row[j] - means[j])
+		}
+		scales[j] = math.Sqrt(scales[j] / float64(n))
+		// a constant feature carries no signal, its weight stays zero
+		if scales[j] == 0 {
+			scales[j] = 1
+		}
+	}
+
+	z := make([][]float64, n)
+	for i, row := range x {
+		z[i] = make([]float64, k)
+		for j := range k {
+			z[i][j] = (row[j] - means[j]) / scales[j]
+		}
+	}
+
+	sampleWeights := make([]float64, n)
+	total := 0.0
+	for i, fraud := range y {
+		sampleWeights[i] = 1
+		if opts.Balanced {
+			count := positives
+			if !fraud {
+				count = n - positives
+			}
+			sampleWeights[i] = float64(n) / float64(2*count)
+		}
+		total += sampleWeights[i]
+	}
+
+	weights := make([]float64, k)
+	intercept := 0.0
+	gradient := make([]float64, k)
+	for range opts.Epochs {
+		clear(gradient)
+		interceptGradient := 0.0
+		for i, row := range z {
+			logOdds := intercept
+			for j, v := range row {
+				logOdds += weights[j] * v
+			}
+			residual := sigmoid(logOdds)
+			if y[i] {
+				residual--
+			}
+			residual *= sampleWeights[i] / total
+			for j, v := range row {
+				gradient[j] += residual * v
+			}
+			interceptGradient += residual
+		}
+		for j := range weights {
+			weights[j] -= opts.LearningRate * (gradient[j] + opts.L2*weights[j])
+		}
+		intercept -= opts.LearningRate * interceptGradient
+	}
+
+	m := &Model{
+		Type:      TypeLogistic,
+		Features:  slices.Clone(features),
+		Intercept: intercept,
+		Weights:   weights,
+		Means:     means,
+		Scales:    scales,
+	}
+	if err := m.Bind(features); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LogLoss is the model's average log loss on labeled rows
+func (m *Model) LogLoss(x [][]float64, y []bool) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	const eps = 1e-15
+	loss := 0.0
+	for i, row := range x {
+		p := math.Min(math.Max(m.Predict(row).Probability, eps), 1-eps)
+		if y[i] {
+			loss -= math.Log(p)
+		} else {
+			loss -= math.Log(1 - p)
+		}
+	}
+	return loss / float64(len(x))
+}
@@ -0,0 +1,213 @@
+// Package model evaluates fraud models exported to a JSON file: a logistic
+// regression or an ensemble of regression trees, as exported by gradient
+// boosting libraries. Both predict the log-odds of fraud from a feature
+// vector, and every prediction is explained by each feature's contribution
+// to the log-odds.
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+)
+
+const (
+	TypeLogistic = "logistic"
+	TypeTrees    = "trees"
+)
+
+// Model is a fraud model as stored in its JSON file
+type Model struct {
+	Type string `json:"type"`
+	// Features names the model's inputs, in the order of Weights, Means and
+	// Scales and as indexed by tree nodes
+	Features []string `json:"features"`
+
+	// A logistic regression predicts
+	// Intercept + sum(Weights[i] * (x[i] - Means[i]) / Scales[i]).
+	// Means and Scales may be omitted for unscaled inputs.
+	Intercept float64   `json:"intercept,omitempty"`
+	Weights   []float64 `json:"weights,omitempty"`
+	Means     []float64 `json:"means,omitempty"`
+	Scales    []float64 `json:"scales,omitempty"`
+
+	// A tree ensemble predicts BaseScore plus the value of the leaf each tree
+	// reaches
+	BaseScore float64 `json:"base_score,omitempty"`
+	Trees     []Tree  `json:"trees,omitempty"`
+
+	// inputs holds the position of each feature in the vectors passed to
+	// Predict
+	inputs []int
+}
+
+// Tree is a regression tree; its first node is the root
+type Tree struct {
+	Nodes []Node `json:"nodes"`
+}
+
+// Node is a leaf or a split sending inputs below Threshold to Left and the
+// others to Right. Children come after their parent. Value is a leaf's output
+// and, on a split, the average output of the training rows reaching it, which
+// attributes the prediction to the features split on.
+type Node struct {
+	Leaf      bool    `json:"leaf,omitempty"`
+	Feature   int     `json:"feature,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Left      int     `json:"left,omitempty"`
+	Right     int     `json:"right,omitempty"`
+	Value     float64 `json:"value"`
+}
+
+// Contribution is a feature's share of a prediction's log-odds
+type Contribution struct {
+	Feature string  `json:"feature"`
+	Input   float64 `json:"input"`
+	LogOdds float64 `json:"log_odds"`
+}
+
+// Prediction is a model's fraud probability with its explanation: Bias plus
+// the contributions add up to the log-odds of Probability
+type Prediction struct {
+	Probability float64
+	Bias        float64
+	// Contributions are in the order of the model's features
+	Contributions []Contribution
+}
+
+// Load reads a model and binds its features to positions in available, the
+// feature names of the vectors it will be given
+func Load(r io.Reader, available []string) (*Model, error) {
+	var m Model
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidModel, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidModel, err)
+	}
+	if err := m.Bind(available); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// LoadFile reads a model from a file, see Load
+func LoadFile(path string, available []string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f, available)
+}
+
+// Bind maps the model's features to positions in available
+func (m *Model) Bind(available []string) error {
+	inputs := make([]int, len(m.Features))
+	for i, name := range m.Features {
+		inputs[i] = slices.Index(available, name)
+		if inputs[i] < 0 {
+			return fmt.Errorf("%w: %s", errors.ErrUnknownModelFeature, name)
+		}
+	}
+	m.inputs = inputs
+	return nil
+}
+
+func (m *Model) validate() error {
+	if len(m.Features) == 0 {
+		return fmt.Errorf("no features")
+	}
+
+	switch m.Type {
+	case TypeLogistic:
+		n := len(m.Features)
+		if len(m.Weights) != n {
+			return fmt.Errorf("%d weights for %d features", len(m.Weights), n)
+		}
+		if len(m.Means) != 0 && len(m.Means) != n || len(m.Scales) != 0 && len(m.Scales) != n {
+			return fmt.Errorf("means and scales should be omitted or have one value per feature")
+		}
+		if slices.Contains(m.Scales, 0) {
+			return fmt.Errorf("scales should not be zero")
+		}
+	case TypeTrees:
+		if len(m.Trees) == 0 {
+			return fmt.Errorf("no trees")
+		}
+		for t, tree := range m.Trees {
+			if len(tree.Nodes) == 0 {
+				return fmt.Errorf("tree %d has no nodes", t)
+			}
+			for i, node := range tree.Nodes {
+				if node.Leaf {
+					continue
+				}
+				if node.Feature < 0 || node.Feature >= len(m.Features) {
+					return fmt.Errorf("tree %d node %d splits on unknown feature %d", t, i, node.Feature)
+				}
+				if node.Left <= i || node.Left >= len(tree.Nodes) || node.Right <= i || node.Right >= len(tree.Nodes) {
+					return fmt.Errorf("tree %d node %d has children out of order", t, i)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("type should be %s or %s", TypeLogistic, TypeTrees)
+	}
+	return nil
+}
+
+// Predict scores a feature vector laid out like the names the model was bound
+// to
+func (m *Model) Predict(x []float64) Prediction {
+	p := Prediction{Contributions: make([]Contribution, len(m.Features))}
+	for i, name := range m.Features {
+		p.Contributions[i] = Contribution{Feature: name, Input: x[m.inputs[i]]}
+	}
+
+	switch m.Type {
+	case TypeLogistic:
+		p.Bias = m.Intercept
+		for i := range m.Features {
+			z := p.Contributions[i].Input
+			if len(m.Means) > 0 {
+				z -= m.Means[i]
+			}
+			if len(m.Scales) > 0 {
+				z /= m.Scales[i]
+			}
+			p.Contributions[i].LogOdds = m.Weights[i] * z
+		}
+	case TypeTrees:
+		p.Bias = m.BaseScore
+		for _, tree := range m.Trees {
+			node := tree.Nodes[0]
+			p.Bias += node.Value
+			for !node.Leaf {
+				next := tree.Nodes[node.Right]
+				if p.Contributions[node.Feature].Input < node.Threshold {
+					next = tree.Nodes[node.Left]
+				}
+				p.Contributions[node.Feature].LogOdds += next.Value - node.Value
+				node = next
+			}
+		}
+	}
+
+	logOdds := p.Bias
+	for _, c := range p.Contributions {
+		logOdds += c.LogOdds
+	}
+	p.Probability = sigmoid(logOdds)
+	return p
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logOdds(p Prediction) float64 {
+	total := p.Bias
+	for _, c := range p.Contributions {
+		total += c.LogOdds
+	}
+	return total
+}
+
+func TestLoad(t *testing.T) {
+	available := []string{"a", "b", "c"}
+
+	t.Run("logistic", func(t *testing.T) {
+		m, err := Load(strings.NewReader(`{
+			"type": "logistic",
+			"features": ["c", "a"],
+			"intercept": -1,
+			"weights": [2, 0.5],
+			"means": [1, 0],
+			"scales": [2, 1]
+		}`), available)
+		require.NoError(t, err)
+
+		p := m.Predict([]float64{4, 100, 5})
+		require.Len(t, p.Contributions, 2)
+		assert.Equal(t, Contribution{Feature: "c", Input: 5, LogOdds: 4}, p.Contributions[0])
+		assert.Equal(t, Contribution{Feature: "a", Input: 4, LogOdds: 2}, p.Contributions[1])
+		assert.Equal(t, -1.0, p.Bias)
+		assert.InDelta(t, 1/(1+math.Exp(-5)), p.Probability, 1e-9)
+	})
+
+	t.Run("trees", func(t *testing.T) {
+		m, err := Load(strings.NewReader(`{
+			"type": "trees",
+			"features": ["a", "b"],
+			"base_score": -2,
+			"trees": [
+				{"nodes": [
+					{"feature": 0, "threshold": 10, "left": 1, "right": 2, "value": 0.2},
+					{"leaf": true, "value": -0.5},
+					{"feature": 1, "threshold": 1, "left": 3, "right": 4, "value": 1},
+					{"leaf": true, "value": 0.5},
+					{"leaf": true, "value": 2}
+				]},
+				{"nodes": [{"leaf": true, "value": 0.3}]}
+			]
+		}`), available)
+		require.NoError(t, err)
+
+		p := m.Predict([]float64{50, 3, 0})
+		assert.InDelta(t, -1.5, p.Bias, 1e-9)
+		assert.InDelta(t, 0.8, p.Contributions[0].LogOdds, 1e-9)
+		assert.InDelta(t, 1.0, p.Contributions[1].LogOdds, 1e-9)
+		// the leaves reached add up to the log-odds
+		assert.InDelta(t, -2+2+0.3, logOdds(p), 1e-9)
+
+		p = m.Predict([]float64{5, 3, 0})
+		assert.InDelta(t, -0.7, p.Contributions[0].LogOdds, 1e-9)
+		assert.Zero(t, p.Contributions[1].LogOdds)
+		assert.InDelta(t, -2-0.5+0.3, logOdds(p), 1e-9)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, file := range map[string]string{
+			"json":          `{"type": `,
+			"type":          `{"type": "forest", "features": ["a"]}`,
+			"no features":   `{"type": "logistic"}`,
+			"weights":       `{"type": "logistic", "features": ["a", "b"], "weights": [1]}`,
+			"zero scale":    `{"type": "logistic", "features": ["a"], "weights": [1], "scales": [0]}`,
+			"no trees":      `{"type": "trees", "features": ["a"]}`,
+			"split feature": `{"type": "trees", "features": ["a"], "trees": [{"nodes": [{"feature": 1, "left": 1, "right": 2}, {"leaf": true}, {"leaf": true}]}]}`,
+			"cycle":         `{"type": "trees", "features": ["a"], "trees": [{"nodes": [{"feature": 0, "left": 0, "right": 1}, {"leaf": true}]}]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Load(strings.NewReader(file), available)
+				assert.ErrorIs(t, err, errors.ErrInvalidModel)
+			})
+		}
+	})
+
+	t.Run("unknown feature", func(t *testing.T) {
+		_, err := Load(strings.NewReader(`{"type": "logistic", "features": ["d"], "weights": [1]}`), available)
+		assert.ErrorIs(t, err, errors.ErrUnknownModelFeature)
+	})
+}
+
+func TestFitLogistic(t *testing.T) {
+	features := []string{"amount", "noise"}
+	var x [][]float64
+	var y []bool
+	for i := range 200 {
+		amount := float64(i % 20)
+		x = append(x, []float64{amount, float64(i % 7)})
+		y = append(y, amount >= 17)
+	}
+
+	m, err := FitLogistic(features, x, y, DefaultFitOptions())
+	require.NoError(t, err)
+	assert.Equal(t, TypeLogistic, m.Type)
+	assert.Greater(t, m.Weights[0], 1.0)
+	assert.Less(t, math.Abs(m.Weights[1]), m.Weights[0]/10)
+
+	assert.Greater(t, m.Predict([]float64{19, 3}).Probability, 0.9)
+	assert.Less(t, m.Predict([]float64{2, 3}).Probability, 0.1)
+	assert.Less(t, m.LogLoss(x, y), 0.2)
+
+	t.Run("one class", func(t *testing.T) {
+		_, err := FitLogistic(features, x[:10], y[:10], DefaultFitOptions())
+		assert.ErrorIs(t, err, errors.ErrInsufficientTrainingData)
+	})
+
+	t.Run("ragged rows", func(t *testing.T) {
+		_, err := FitLogistic(features, [][]float64{{1}}, []bool{true}, DefaultFitOptions())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message is a notification addressed to a single user
+type Message struct {
+	UserID  int32
+	Email   string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users. Implementations for real channels
+// (email, SMS, push) can be swapped in without touching the services.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// New returns the notifier selected by kind: "file" appends messages to path,
+// anything else writes them to the application log
+func New(kind string, path string, logger *zap.Logger) Notifier {
+	if kind == "file" && path != "" {
+		return NewFileNotifier(path)
+	}
+	return NewLogNotifier(logger)
+}
+
+// LogNotifier writes messages to the application log, for local development
+type LogNotifier struct {
+	logger *zap.Logger
+}
+
+func NewLogNotifier(logger *zap.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, msg Message) error {
+	n.logger.Info("notification",
+		zap.Int32("user_id", msg.UserID),
+		zap.String("email", msg.Email),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
+
+// FileNotifier appends messages to a file, one line per message
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Notify(_ context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\tuser=%d\temail=%s\tsubject=%q\tbody=%q\n",
+		time.Now().Format(time.RFC3339), msg.UserID, msg.Email, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,37 @@
+package notifier
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	n := NewFileNotifier(path)
+
+	err := n.Notify(context.Background(), Message{UserID: 1, Email: "a@b.com", Subject: "otp", Body: "code 123456"})
+	assert.NoError(t, err)
+	err = n.Notify(context.Background(), Message{UserID: 2, Email: "c@d.com", Subject: "otp", Body: "code 654321"})
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "user=1")
+	assert.Contains(t, lines[0], "code 123456")
+	assert.Contains(t, lines[1], "user=2")
+}
+
+func TestNew(t *testing.T) {
+	assert.IsType(t, &FileNotifier{}, New("file", "/tmp/x.log", zap.NewNop()))
+	assert.IsType(t, &LogNotifier{}, New("file", "", zap.NewNop()))
+	assert.IsType(t, &LogNotifier{}, New("log", "", zap.NewNop()))
+}
@@ -0,0 +1,161 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var errDivisionByZero = errors.New("division by zero")
+
+// node is a type checked expression node
+type node interface {
+	kind() Kind
+	eval(env Env) (any, error)
+}
+
+type literal struct {
+	value any
+	k     Kind
+}
+
+func (n *literal) kind() Kind { return n.k }
+
+func (n *literal) eval(Env) (any, error) {
+	return n.value, nil
+}
+
+type field struct {
+	name string
+	k    Kind
+}
+
+func (n *field) kind() Kind { return n.k }
+
+func (n *field) eval(env Env) (any, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("field %s is missing", n.name)
+	}
+
+	switch n.k {
+	case KindNumber:
+		switch num := v.(type) {
+		case float64:
+			return num, nil
+		case int:
+			return float64(num), nil
+		case int32:
+			return float64(num), nil
+		case int64:
+			return float64(num), nil
+		}
+	case KindString:
+		if _, ok := v.(string); ok {
+			return v, nil
+		}
+	case KindBool:
+		if _, ok := v.(bool); ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("field %s is not a %s", n.name, n.k)
+}
+
+type unary struct {
+	op      string
+	operand node
+	k       Kind
+}
+
+func (n *unary) kind() Kind { return n.k }
+
+func (n *unary) eval(env Env) (any, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !v.(bool), nil
+	}
+	return -v.(float64), nil
+}
+
+type binary struct {
+	op          string
+	left, right node
+	k           Kind
+}
+
+func (n *binary) kind() Kind { return n.k }
+
+func (n *binary) eval(env Env) (any, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// logical operators short-circuit
+	switch n.op {
+	case "&&":
+		if !l.(bool) {
+			return false, nil
+		}
+		return n.right.eval(env)
+	case "||":
+		if l.(bool) {
+			return true, nil
+		}
+		return n.right.eval(env)
+	}
+
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+
+	a, b := l.(float64), r.(float64)
+	switch n.op {
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	default:
+		if b == 0 {
+			return nil, errDivisionByZero
+		}
+		return a / b, nil
+	}
+}
+
+type inList struct {
+	operand node
+	values  []any
+}
+
+func (n *inList) kind() Kind { return KindBool }
+
+func (n *inList) eval(env Env) (any, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Contains(n.values, v), nil
+}
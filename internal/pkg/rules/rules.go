@@ -0,0 +1,411 @@
+// Package rules implements the expression language of fraud rules, such as
+//
+//	txn.mode == "CARD" && txn.amount > 50_000 && txn.hour < 5
+//
+// Expressions combine numbers, 'single' or "double" quoted strings, true,
+// false and fields with these operators, from the lowest precedence up:
+//
+//	||  or
+//	&&  and
+//	!   not
+//	==  !=  <  <=  >  >=  in
+//	+   -
+//	*   /
+//	-   (negation)
+//
+// in tests membership of a list of literals, as in
+// txn.mode in ["CARD", "NETBANKING"]. Expressions are type checked against
+// the available fields when compiled and must evaluate to a boolean.
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kind is the type of a field or expression
+type Kind int
+
+const (
+	KindNumber Kind = iota + 1
+	KindString
+	KindBool
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindBool:
+		return "bool"
+	default:
+		return "unknown"
+	}
+}
+
+// Fields declares the fields expressions may use and their kinds
+type Fields map[string]Kind
+
+// Env holds the field values an expression is evaluated with: float64 for
+// numbers, string and bool
+type Env map[string]any
+
+// Error is a compile error at a byte offset of the expression
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("at position %d: %s", e.Pos, e.Msg)
+}
+
+// Expression is a compiled boolean expression
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile parses and type checks an expression against the fields
+func Compile(source string, fields Fields) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: fields}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	if root.kind() != KindBool {
+		return nil, &Error{Pos: 0, Msg: fmt.Sprintf("expression is a %s, not a bool", root.kind())}
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Match evaluates the expression. It fails when a field is missing from env
+// or holds a value of the wrong kind, and on division by zero.
+func (e *Expression) Match(env Env) (bool, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	// num holds the value of number tokens, text the unquoted value of strings
+	num float64
+}
+
+// operators, two character ones first so they win over their prefixes
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", ","}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == '_') {
+				i++
+			}
+			num, err := strconv.ParseFloat(strings.ReplaceAll(src[start:i], "_", ""), 64)
+			if err != nil {
+				return nil, &Error{Pos: start, Msg: fmt.Sprintf("invalid number %q", src[start:i])}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], pos: start, num: num})
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			for i++; i < len(src) && rune(src[i]) != c; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				b.WriteByte(src[i])
+			}
+			if i >= len(src) {
+				return nil, &Error{Pos: start, Msg: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: b.String(), pos: start})
+		case isLetter(src[i]):
+			start := i
+			for i < len(src) && (isLetter(src[i]) || src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			idx := slices.IndexFunc(operators, func(op string) bool { return strings.HasPrefix(src[i:], op) })
+			if idx < 0 {
+				return nil, &Error{Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+			tokens = append(tokens, token{kind: tokOp, text: operators[idx], pos: i})
+			i += len(operators[idx])
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// isLetter reports whether b can start a field name or keyword
+func isLetter(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// parser
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields Fields
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token when it is one of the operators or keywords
+func (p *parser) accept(ops ...string) (token, bool) {
+	t := p.peek()
+	text := t.text
+	if t.kind == tokIdent {
+		text = strings.ToLower(text)
+	} else if t.kind != tokOp {
+		return t, false
+	}
+	if !slices.Contains(ops, text) {
+		return t, false
+	}
+	p.next()
+	t.text = text
+	return t, true
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		return &Error{Pos: t.pos, Msg: fmt.Sprintf("expected %q, found %q", op, t.text)}
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLogical(p.parseAnd, "||", "or")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogical(p.parseNot, "&&", "and")
+}
+
+func (p *parser) parseLogical(operand func() (node, error), op string, keyword string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept(op, keyword)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != KindBool || right.kind() != KindBool {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("%s needs bool operands", t.text)}
+		}
+		left = &binary{op: op, left: left, right: right, k: KindBool}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	t, ok := p.accept("!", "not")
+	if !ok {
+		return p.parseComparison()
+	}
+	operand, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if operand.kind() != KindBool {
+		return nil, &Error{Pos: t.pos, Msg: "not needs a bool operand"}
+	}
+	return &unary{op: "!", operand: operand, k: KindBool}, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := p.accept("in"); ok {
+		return p.parseIn(t, left)
+	}
+
+	t, ok := p.accept("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if left.kind() != right.kind() {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("cannot compare %s with %s", left.kind(), right.kind())}
+	}
+	if left.kind() != KindNumber && t.text != "==" && t.text != "!=" {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("%s needs number operands", t.text)}
+	}
+	return &binary{op: t.text, left: left, right: right, k: KindBool}, nil
+}
+
+func (p *parser) parseIn(t token, operand node) (node, error) {
+	if operand.kind() == KindBool {
+		return nil, &Error{Pos: t.pos, Msg: "in needs a number or string operand"}
+	}
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+
+	var values []any
+	for {
+		v := p.next()
+		switch {
+		case v.kind == tokNumber && operand.kind() == KindNumber:
+			values = append(values, v.num)
+		case v.kind == tokString && operand.kind() == KindString:
+			values = append(values, v.text)
+		default:
+			return nil, &Error{Pos: v.pos, Msg: fmt.Sprintf("expected a %s literal, found %q", operand.kind(), v.text)}
+		}
+		if _, ok := p.accept(","); !ok {
+			break
+		}
+	}
+
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return &inList{operand: operand, values: values}, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	return p.parseArithmetic(p.parseTerm, "+", "-")
+}
+
+func (p *parser) parseTerm() (node, error) {
+	return p.parseArithmetic(p.parseNegation, "*", "/")
+}
+
+func (p *parser) parseArithmetic(operand func() (node, error), ops ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != KindNumber || right.kind() != KindNumber {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("%s needs number operands", t.text)}
+		}
+		left = &binary{op: t.text, left: left, right: right, k: KindNumber}
+	}
+}
+
+func (p *parser) parseNegation() (node, error) {
+	t, ok := p.accept("-")
+	if !ok {
+		return p.parsePrimary()
+	}
+	operand, err := p.parseNegation()
+	if err != nil {
+		return nil, err
+	}
+	if operand.kind() != KindNumber {
+		return nil, &Error{Pos: t.pos, Msg: "- needs a number operand"}
+	}
+	return &unary{op: "-", operand: operand, k: KindNumber}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &literal{value: t.num, k: KindNumber}, nil
+	case tokString:
+		return &literal{value: t.text, k: KindString}, nil
+	case tokIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return &literal{value: true, k: KindBool}, nil
+		case "false":
+			return &literal{value: false, k: KindBool}, nil
+		}
+		k, ok := p.fields[t.text]
+		if !ok {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unknown field %q", t.text)}
+		}
+		return &field{name: t.text, k: k}, nil
+	case tokOp:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	case tokEOF:
+		return nil, &Error{Pos: t.pos, Msg: "unexpected end of expression"}
+	}
+	return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+}
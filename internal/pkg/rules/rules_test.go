@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFields = Fields{
+	"txn.amount":   KindNumber,
+	"txn.mode":     KindString,
+	"txn.hour":     KindNumber,
+	"device.known": KindBool,
+	"avg":          KindNumber,
+}
+
+func TestMatch(t *testing.T) {
+	env := Env{
+		"txn.amount":   60000.0,
+		"txn.mode":     "CARD",
+		"txn.hour":     3,
+		"device.known": false,
+		"avg":          0.0,
+	}
+
+	tests := []struct {
+		expression string
+		match      bool
+	}{
+		{`txn.mode == "CARD" && txn.amount > 50_000 && txn.hour < 5`, true},
+		{`txn.mode == 'UPI' || txn.amount >= 60000`, true},
+		{`txn.mode in ["UPI", "NETBANKING"]`, false},
+		{`txn.hour in [1, 2, 3]`, true},
+		{`not device.known and txn.amount / 2 > 29999.5`, true},
+		{`!(txn.amount - 10000 * 5 > 0)`, false},
+		{`-txn.hour < -2`, true},
+		{`device.known == false`, true},
+		// || short-circuits before the division by zero
+		{`txn.amount > 0 || txn.amount / avg > 3`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e, err := Compile(tt.expression, testFields)
+			require.NoError(t, err)
+
+			match, err := e.Match(env)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, match)
+		})
+	}
+
+	t.Run("runtime errors", func(t *testing.T) {
+		e, err := Compile(`txn.amount / avg > 3`, testFields)
+		require.NoError(t, err)
+		_, err = e.Match(env)
+		assert.ErrorIs(t, err, errDivisionByZero)
+
+		_, err = e.Match(Env{"avg": 1.0})
+		assert.Error(t, err, "missing field")
+
+		_, err = e.Match(Env{"txn.amount": "60000", "avg": 1.0})
+		assert.Error(t, err, "field of the wrong kind")
+	})
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		pos        int
+	}{
+		{`txn.amount`, 0},
+		{`txn.amount > "5"`, 11},
+		{`txn.mode < "CARD"`, 9},
+		{`txn.country == "IN"`, 0},
+		{`txn.amount > 5 &&`, 17},
+		{`txn.mode == "CARD`, 12},
+		{`txn.mode in ["CARD", 5]`, 21},
+		{`(txn.amount > 5`, 15},
+		{`txn.amount > 5 $`, 15},
+		{`txn.amount + device.known > 1`, 11},
+		{`txn.amount > 5 txn.hour`, 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := Compile(tt.expression, testFields)
+			var compileErr *Error
+			require.ErrorAs(t, err, &compileErr)
+			assert.Equal(t, tt.pos, compileErr.Pos, compileErr.Msg)
+		})
+	}
+}
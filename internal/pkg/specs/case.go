@@ -0,0 +1,75 @@
+package specs
+
+import (
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+// ListFraudCasesRequest filters the review queue; empty fields match every case
+type ListFraudCasesRequest struct {
+	Status     string
+	AssignedTo *int32
+	Limit      int32
+	Offset     int32
+}
+
+func (r ListFraudCasesRequest) Validate() error {
+	switch repository.CaseStatus(r.Status) {
+	case "", repository.CaseStatusOPEN, repository.CaseStatusASSIGNED, repository.CaseStatusRESOLVED:
+		return nil
+	default:
+		return errors.ErrInvalidCaseStatus
+	}
+}
+
+// AssignFraudCaseRequest assigns a case to an analyst, the caller if AnalystID is omitted
+type AssignFraudCaseRequest struct {
+	AnalystID int32  `json:"analyst_id"`
+	Notes     string `json:"notes"`
+}
+
+type ResolveFraudCaseRequest struct {
+	Disposition string `json:"disposition"`
+	Notes       string `json:"notes"`
+}
+
+func (r ResolveFraudCaseRequest) Validate() error {
+	switch repository.FraudLabel(r.Disposition) {
+	case repository.FraudLabelCONFIRMEDFRAUD, repository.FraudLabelFALSEPOSITIVE:
+		return nil
+	default:
+		return errors.ErrInvalidDisposition
+	}
+}
+
+type FraudCaseResponse struct {
+	ID            int32                 `json:"id"`
+	TransactionID int32                 `json:"transaction_id"`
+	UserID        int32                 `json:"user_id"`
+	Status        repository.CaseStatus `json:"status"`
+	AssignedTo    *int32                `json:"assigned_to,omitempty"`
+	Disposition   repository.FraudLabel `json:"disposition,omitempty"`
+	Notes         string                `json:"notes"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	ResolvedAt    *time.Time            `json:"resolved_at,omitempty"`
+}
+
+// FraudCaseEventResponse is a single state change in a case's history
+type FraudCaseEventResponse struct {
+	ActorID     *int32                `json:"actor_id,omitempty"`
+	FromStatus  repository.CaseStatus `json:"from_status,omitempty"`
+	ToStatus    repository.CaseStatus `json:"to_status"`
+	AssignedTo  *int32                `json:"assigned_to,omitempty"`
+	Disposition repository.FraudLabel `json:"disposition,omitempty"`
+	Notes       string                `json:"notes"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+type FraudCaseDetailResponse struct {
+	FraudCaseResponse
+	Transaction repository.Transaction   `json:"transaction"`
+	Events      []FraudCaseEventResponse `json:"events"`
+}
@@ -0,0 +1,110 @@
+package specs
+
+import (
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+)
+
+// VelocityLimit caps the activity inside one velocity window. Zero values
+// disable a limit.
+type VelocityLimit struct {
+	// Count and ModeCount are the transactions allowed per user and per
+	// user+mode before each further one adds RiskPerTxnAfterThreshold
+	Count     int `json:"count"`
+	ModeCount int `json:"mode_count"`
+	// Amount is the total spend allowed per user, including the transaction
+	Amount float64 `json:"amount"`
+}
+
+// FactorConfig holds the tunable parameters of a single risk factor
+type FactorConfig struct {
+	Weight    float64 `json:"weight"`
+	Threshold float64 `json:"threshold"`
+}
+
+// ScoringConfig is a versioned set of weights, thresholds and decision cutoffs
+// used to score transactions. Version 0 is the built-in default.
+type ScoringConfig struct {
+	Version                     int32                   `json:"version"`
+	Factors                     map[string]FactorConfig `json:"factors"`
+	RiskThresholdAllow          float64                 `json:"risk_threshold_allow"`
+	RiskThresholdFlag           float64                 `json:"risk_threshold_flag"`
+	RiskThresholdMFA            float64                 `json:"risk_threshold_mfa"`
+	ColdStartThresholdAllow     float64                 `json:"cold_start_threshold_allow"`
+	ColdStartThresholdFlag      float64                 `json:"cold_start_threshold_flag"`
+	MinTransactionsForProfiling int32                   `json:"min_transactions_for_profiling"`
+	ThresholdFrequency          int                     `json:"threshold_frequency"`
+	RiskPerTxnAfterThreshold    float64                 `json:"risk_per_txn_after_threshold"`
+	// VelocityLimits are keyed by velocity window (1m, 10m, 1h, 24h, 7d); the
+	// per-user 1h count is limited by ThresholdFrequency
+	VelocityLimits map[string]VelocityLimit `json:"velocity_limits"`
+	// AmountScoring is the method of the amount factor, "zscore" or "robust"
+	AmountScoring string `json:"amount_scoring"`
+}
+
+func (c ScoringConfig) Validate() error {
+	for _, f := range c.Factors {
+		if f.Weight < 0 || f.Threshold < 0 || f.Threshold > 100 {
+			return errors.ErrInvalidScoringConfig
+		}
+	}
+
+	if c.RiskThresholdAllow <= 0 ||
+		c.RiskThresholdAllow > c.RiskThresholdFlag ||
+		c.RiskThresholdFlag > c.RiskThresholdMFA ||
+		c.RiskThresholdMFA > 100 {
+		return errors.ErrInvalidScoringConfig
+	}
+
+	if c.ColdStartThresholdAllow <= 0 ||
+		c.ColdStartThresholdAllow > c.ColdStartThresholdFlag ||
+		c.ColdStartThresholdFlag > 100 {
+		return errors.ErrInvalidScoringConfig
+	}
+
+	if c.MinTransactionsForProfiling < 0 || c.ThresholdFrequency < 0 || c.RiskPerTxnAfterThreshold < 0 {
+		return errors.ErrInvalidScoringConfig
+	}
+
+	if c.AmountScoring != constants.AmountScoringZScore && c.AmountScoring != constants.AmountScoringRobust {
+		return errors.ErrInvalidScoringConfig
+	}
+
+	for window, l := range c.VelocityLimits {
+		if !isVelocityWindow(window) || l.Count < 0 || l.ModeCount < 0 || l.Amount < 0 {
+			return errors.ErrInvalidScoringConfig
+		}
+	}
+
+	return nil
+}
+
+func isVelocityWindow(name string) bool {
+	switch name {
+	case constants.VelocityWindow1m, constants.VelocityWindow10m, constants.VelocityWindow1h,
+		constants.VelocityWindow24h, constants.VelocityWindow7d:
+		return true
+	}
+	return false
+}
+
+// CreateScoringConfigRequest represents a request to store a new config version.
+// Fields missing from Config keep the values of the built-in default.
+type CreateScoringConfigRequest struct {
+	Description string        `json:"description"`
+	Config      ScoringConfig `json:"config"`
+}
+
+// ScoringConfigResponse represents a stored config version
+type ScoringConfigResponse struct {
+	Version     int32         `json:"version"`
+	Description string        `json:"description"`
+	IsActive    bool          `json:"is_active"`
+	IsShadow    bool          `json:"is_shadow"`
+	Config      ScoringConfig `json:"config"`
+	CreatedBy   *int32        `json:"created_by,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ActivatedAt *time.Time    `json:"activated_at,omitempty"`
+}
@@ -0,0 +1,65 @@
+package specs
+
+import (
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+// CreateListEntryRequest adds a value to the allow or deny list. Entries
+// without ExpiresAt stay until they are removed.
+type CreateListEntryRequest struct {
+	ListType  string     `json:"list_type"`
+	Entity    string     `json:"entity"`
+	Value     string     `json:"value"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (r CreateListEntryRequest) Validate() error {
+	switch repository.ListType(r.ListType) {
+	case repository.ListTypeALLOW, repository.ListTypeDENY:
+	default:
+		return errors.ErrInvalidListType
+	}
+
+	if r.Value == "" || len(r.Value) > constants.MaxListValueLength {
+		return errors.ErrInvalidListValue
+	}
+
+	switch repository.ListEntity(r.Entity) {
+	case repository.ListEntityUSER:
+		if id, err := strconv.ParseInt(r.Value, 10, 32); err != nil || id <= 0 {
+			return errors.ErrInvalidListValue
+		}
+	case repository.ListEntityIP:
+		if _, err := netip.ParseAddr(r.Value); err != nil {
+			return errors.ErrInvalidListValue
+		}
+	case repository.ListEntityPAYEE, repository.ListEntityDEVICE:
+	default:
+		return errors.ErrInvalidListEntity
+	}
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return errors.ErrInvalidListExpiry
+	}
+	return nil
+}
+
+// ListEntryResponse is an allow or deny list entry. Transactions decided by
+// an entry carry it as list_entry.
+type ListEntryResponse struct {
+	ID        int32                 `json:"id"`
+	ListType  repository.ListType   `json:"list_type"`
+	Entity    repository.ListEntity `json:"entity"`
+	Value     string                `json:"value"`
+	Reason    string                `json:"reason"`
+	CreatedBy *int32                `json:"created_by,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	ExpiresAt *time.Time            `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package specs
+
+import (
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+type VerifyMFARequest struct {
+	OTP string `json:"otp"`
+}
+
+func (r VerifyMFARequest) Validate() error {
+	if r.OTP == "" {
+		return errors.ErrMissingOTPInRequest
+	}
+	return nil
+}
+
+type VerifyMFAResponse struct {
+	TransactionID int32                          `json:"id"`
+	Decision      repository.TransactionDecision `json:"decision"`
+	Message       string                         `json:"message"`
+}
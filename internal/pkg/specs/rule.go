@@ -0,0 +1,92 @@
+package specs
+
+import (
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/rules"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+// RuleRequest creates or replaces a fraud rule. Expression is compiled by the
+// service, as the fields it may use depend on the registered risk factors.
+type RuleRequest struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Action     string `json:"action"`
+	// Decision is set by SET_DECISION rules
+	Decision string `json:"decision,omitempty"`
+	// ScoreDelta is added to the risk score by ADD_SCORE rules
+	ScoreDelta float64 `json:"score_delta,omitempty"`
+	// Tag is added to the transaction by ADD_TAG rules
+	Tag string `json:"tag,omitempty"`
+	// Rules are evaluated highest priority first
+	Priority int32 `json:"priority"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+func (r RuleRequest) Validate() error {
+	if r.Name == "" || len(r.Name) > constants.MaxRuleNameLength {
+		return errors.ErrInvalidRuleName
+	}
+
+	if r.Expression == "" || len(r.Expression) > constants.MaxRuleExpressionLength {
+		return errors.ErrInvalidRuleExpression
+	}
+
+	switch repository.RuleAction(r.Action) {
+	case repository.RuleActionSETDECISION:
+		switch repository.TransactionDecision(r.Decision) {
+		case repository.TransactionDecisionALLOW, repository.TransactionDecisionFLAG,
+			repository.TransactionDecisionMFAREQUIRED, repository.TransactionDecisionBLOCK:
+			return nil
+		default:
+			return errors.ErrInvalidRuleDecision
+		}
+	case repository.RuleActionADDSCORE:
+		if r.ScoreDelta == 0 || r.ScoreDelta < -constants.MaxRuleScoreDelta || r.ScoreDelta > constants.MaxRuleScoreDelta {
+			return errors.ErrInvalidRuleScoreDelta
+		}
+		return nil
+	case repository.RuleActionADDTAG:
+		if r.Tag == "" || len(r.Tag) > constants.MaxRuleTagLength {
+			return errors.ErrInvalidRuleTag
+		}
+		return nil
+	default:
+		return errors.ErrInvalidRuleAction
+	}
+}
+
+type RuleResponse struct {
+	ID         int32                           `json:"id"`
+	Name       string                          `json:"name"`
+	Expression string                          `json:"expression"`
+	Action     repository.RuleAction           `json:"action"`
+	Decision   *repository.TransactionDecision `json:"decision,omitempty"`
+	ScoreDelta float64                         `json:"score_delta,omitempty"`
+	Tag        string                          `json:"tag,omitempty"`
+	Priority   int32                           `json:"priority"`
+	Enabled    bool                            `json:"enabled"`
+	CreatedBy  *int32                          `json:"created_by,omitempty"`
+	CreatedAt  time.Time                       `json:"created_at"`
+	UpdatedAt  time.Time                       `json:"updated_at"`
+}
+
+// MatchedRule is a rule that matched a transaction and the action it took
+type MatchedRule struct {
+	ID         int32                          `json:"id"`
+	Name       string                         `json:"name"`
+	Action     repository.RuleAction          `json:"action"`
+	Decision   repository.TransactionDecision `json:"decision,omitempty"`
+	ScoreDelta float64                        `json:"score_delta,omitempty"`
+	Tag        string                         `json:"tag,omitempty"`
+}
+
+// CompiledRule is an enabled rule ready to be evaluated
+type CompiledRule struct {
+	MatchedRule
+	Expression *rules.Expression
+}
@@ -0,0 +1,65 @@
+package specs
+
+import "github.com/cheemx5395/fraud-detection-lite/internal/repository"
+
+// LabelMetrics measure decisions against analyst labels: CONFIRMED_FRAUD
+// transactions should not be allowed and FALSE_POSITIVE ones should.
+// Unlabeled transactions are not counted.
+type LabelMetrics struct {
+	Labeled        int     `json:"labeled"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+}
+
+// Add counts n transactions with the given label and decision
+func (m *LabelMetrics) Add(label repository.FraudLabel, decision repository.TransactionDecision, n int) {
+	if label == "" {
+		return
+	}
+	caught := decision != repository.TransactionDecisionALLOW
+	fraud := label == repository.FraudLabelCONFIRMEDFRAUD
+
+	m.Labeled += n
+	switch {
+	case fraud && caught:
+		m.TruePositives += n
+	case fraud:
+		m.FalseNegatives += n
+	case caught:
+		m.FalsePositives += n
+	}
+}
+
+// Finish computes precision and recall; it returns nil when nothing is labeled
+func (m *LabelMetrics) Finish() *LabelMetrics {
+	if m.Labeled == 0 {
+		return nil
+	}
+	m.Precision = ratio(m.TruePositives, m.TruePositives+m.FalsePositives)
+	m.Recall = ratio(m.TruePositives, m.TruePositives+m.FalseNegatives)
+	return m
+}
+
+func ratio(n int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// ShadowSummaryResponse compares a shadowed config (the challenger) with the
+// configs that decided the same live transactions (the champion)
+type ShadowSummaryResponse struct {
+	Version      int32 `json:"version"`
+	Transactions int   `json:"transactions"`
+	Changed      int   `json:"changed"`
+	// Deltas counts transactions by champion, then challenger decision
+	Deltas               map[repository.TransactionDecision]map[repository.TransactionDecision]int `json:"deltas"`
+	AverageLatencyMicros int64                                                                     `json:"average_latency_micros"`
+	// Champion and Challenger are nil while no shadowed transaction is labeled
+	Champion   *LabelMetrics `json:"champion,omitempty"`
+	Challenger *LabelMetrics `json:"challenger,omitempty"`
+}
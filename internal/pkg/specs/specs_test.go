@@ -1,9 +1,12 @@
 package specs
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 )
 
 func TestUserSignupRequestValidate(t *testing.T) {
@@ -68,6 +71,26 @@ func TestUserSignupRequestValidate(t *testing.T) {
 			},
 			ExpectedError: errors.ErrInvalidEmail,
 		},
+		{
+			Name: "with timezone",
+			Req: UserSignupRequest{
+				Name:     "test",
+				Email:    "test@gmail.com",
+				Password: "password!123",
+				Timezone: "America/New_York",
+			},
+			ExpectedError: nil,
+		},
+		{
+			Name: "unknown timezone",
+			Req: UserSignupRequest{
+				Name:     "test",
+				Email:    "test@gmail.com",
+				Password: "password!123",
+				Timezone: "Asia/Atlantis",
+			},
+			ExpectedError: errors.ErrInvalidTimezone,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -197,6 +220,245 @@ func TestCreateTransactionRequestValidate(t *testing.T) {
 			},
 			ExpectedError: errors.ErrInvalidPaymentMode,
 		},
+		{
+			Name: "client utc offset",
+			Req: CreateTransactionRequest{
+				Amount:           500.0,
+				Mode:             "UPI",
+				UTCOffsetMinutes: intPtr(330),
+			},
+			ExpectedError: nil,
+		},
+		{
+			Name: "utc offset out of range",
+			Req: CreateTransactionRequest{
+				Amount:           500.0,
+				Mode:             "UPI",
+				UTCOffsetMinutes: intPtr(-15 * 60),
+			},
+			ExpectedError: errors.ErrInvalidUTCOffset,
+		},
+		{
+			Name: "Valid device fingerprint",
+			Req: CreateTransactionRequest{
+				Amount:    500.0,
+				Mode:      "UPI",
+				DeviceID:  "device-1",
+				IPAddress: "2001:db8::1",
+				UserAgent: "Mozilla/5.0",
+			},
+			ExpectedError: nil,
+		},
+		{
+			Name: "Invalid IP address",
+			Req: CreateTransactionRequest{
+				Amount:    500.0,
+				Mode:      "UPI",
+				IPAddress: "300.1.1.1",
+			},
+			ExpectedError: errors.ErrInvalidIPAddress,
+		},
+		{
+			Name: "Device ID too long",
+			Req: CreateTransactionRequest{
+				Amount:   500.0,
+				Mode:     "UPI",
+				DeviceID: strings.Repeat("d", 129),
+			},
+			ExpectedError: errors.ErrInvalidDeviceID,
+		},
+		{
+			Name: "Payee ID too long",
+			Req: CreateTransactionRequest{
+				Amount:  500.0,
+				Mode:    "UPI",
+				PayeeID: strings.Repeat("p", 256),
+			},
+			ExpectedError: errors.ErrInvalidPayeeID,
+		},
+		{
+			Name: "Valid coordinates",
+			Req: CreateTransactionRequest{
+				Amount:    500.0,
+				Mode:      "UPI",
+				Latitude:  floatPtr(19.076),
+				Longitude: floatPtr(-180),
+			},
+			ExpectedError: nil,
+		},
+		{
+			Name: "Latitude without longitude",
+			Req: CreateTransactionRequest{
+				Amount:   500.0,
+				Mode:     "UPI",
+				Latitude: floatPtr(19.076),
+			},
+			ExpectedError: errors.ErrInvalidCoordinates,
+		},
+		{
+			Name: "Latitude out of range",
+			Req: CreateTransactionRequest{
+				Amount:    500.0,
+				Mode:      "UPI",
+				Latitude:  floatPtr(91),
+				Longitude: floatPtr(72.87),
+			},
+			ExpectedError: errors.ErrInvalidCoordinates,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Req.Validate()
+			if err != tc.ExpectedError {
+				t.Errorf("Expected Error: %v, Got: %v\n", tc.ExpectedError, err)
+			}
+		})
+	}
+}
+
+func TestScoringConfigValidate(t *testing.T) {
+	valid := ScoringConfig{
+		Factors: map[string]FactorConfig{
+			"AMOUNT_DEVIATION": {Weight: 0.4, Threshold: 30},
+		},
+		RiskThresholdAllow:          30,
+		RiskThresholdFlag:           60,
+		RiskThresholdMFA:            80,
+		ColdStartThresholdAllow:     60,
+		ColdStartThresholdFlag:      75,
+		MinTransactionsForProfiling: 5,
+		ThresholdFrequency:          3,
+		RiskPerTxnAfterThreshold:    20,
+		AmountScoring:               "robust",
+	}
+
+	testCases := []struct {
+		Name          string
+		Modify        func(c *ScoringConfig)
+		ExpectedError error
+	}{
+		{
+			Name:          "valid config",
+			Modify:        func(c *ScoringConfig) {},
+			ExpectedError: nil,
+		},
+		{
+			Name: "negative weight",
+			Modify: func(c *ScoringConfig) {
+				c.Factors = map[string]FactorConfig{"AMOUNT_DEVIATION": {Weight: -1, Threshold: 30}}
+			},
+			ExpectedError: errors.ErrInvalidScoringConfig,
+		},
+		{
+			Name:          "unordered decision thresholds",
+			Modify:        func(c *ScoringConfig) { c.RiskThresholdFlag = 90 },
+			ExpectedError: errors.ErrInvalidScoringConfig,
+		},
+		{
+			Name:          "unordered cold start thresholds",
+			Modify:        func(c *ScoringConfig) { c.ColdStartThresholdAllow = 80 },
+			ExpectedError: errors.ErrInvalidScoringConfig,
+		},
+		{
+			Name:          "negative frequency threshold",
+			Modify:        func(c *ScoringConfig) { c.ThresholdFrequency = -1 },
+			ExpectedError: errors.ErrInvalidScoringConfig,
+		},
+		{
+			Name: "velocity limit",
+			Modify: func(c *ScoringConfig) {
+				c.VelocityLimits = map[string]VelocityLimit{"24h": {Count: 10, Amount: 50000}}
+			},
+			ExpectedError: nil,
+		},
+		{
+			Name: "unknown velocity window",
+			Modify: func(c *ScoringConfig) {
+				c.VelocityLimits = map[string]VelocityLimit{"2h": {Count: 10}}
+			},
+			ExpectedError: errors.ErrInvalidScoringConfig,
+		},
+		{
+			Name: "negative velocity limit",
+			Modify: func(c *ScoringConfig) {
+				c.VelocityLimits = map[string]VelocityLimit{"1m": {ModeCount: -1}}
+			},
+			ExpectedError: errors.ErrInvalidScoringConfig,
+		},
+		{
+			Name:          "z-score amount scoring",
+			Modify:        func(c *ScoringConfig) { c.AmountScoring = "zscore" },
+			ExpectedError: nil,
+		},
+		{
+			Name:          "unknown amount scoring",
+			Modify:        func(c *ScoringConfig) { c.AmountScoring = "median" },
+			ExpectedError: errors.ErrInvalidScoringConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg := valid
+			tc.Modify(&cfg)
+			err := cfg.Validate()
+			if err != tc.ExpectedError {
+				t.Errorf("Expected Error: %v, Got: %v\n", tc.ExpectedError, err)
+			}
+		})
+	}
+}
+
+func TestCreateListEntryRequestValidate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		Name          string
+		Req           CreateListEntryRequest
+		ExpectedError error
+	}{
+		{
+			Name:          "deny payee",
+			Req:           CreateListEntryRequest{ListType: "DENY", Entity: "PAYEE", Value: "mule@upi", ExpiresAt: &future},
+			ExpectedError: nil,
+		},
+		{
+			Name:          "allow user",
+			Req:           CreateListEntryRequest{ListType: "ALLOW", Entity: "USER", Value: "42"},
+			ExpectedError: nil,
+		},
+		{
+			Name:          "unknown list",
+			Req:           CreateListEntryRequest{ListType: "GREY", Entity: "PAYEE", Value: "mule@upi"},
+			ExpectedError: errors.ErrInvalidListType,
+		},
+		{
+			Name:          "unknown entity",
+			Req:           CreateListEntryRequest{ListType: "DENY", Entity: "EMAIL", Value: "a@example.com"},
+			ExpectedError: errors.ErrInvalidListEntity,
+		},
+		{
+			Name:          "empty value",
+			Req:           CreateListEntryRequest{ListType: "DENY", Entity: "DEVICE"},
+			ExpectedError: errors.ErrInvalidListValue,
+		},
+		{
+			Name:          "user value is not an id",
+			Req:           CreateListEntryRequest{ListType: "DENY", Entity: "USER", Value: "bob"},
+			ExpectedError: errors.ErrInvalidListValue,
+		},
+		{
+			Name:          "invalid IP address",
+			Req:           CreateListEntryRequest{ListType: "DENY", Entity: "IP", Value: "10.0.0.0/8"},
+			ExpectedError: errors.ErrInvalidListValue,
+		},
+		{
+			Name:          "already expired",
+			Req:           CreateListEntryRequest{ListType: "DENY", Entity: "IP", Value: "203.0.113.7", ExpiresAt: &past},
+			ExpectedError: errors.ErrInvalidListExpiry,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -208,3 +470,152 @@ func TestCreateTransactionRequestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleRequestValidate(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Req           RuleRequest
+		ExpectedError error
+	}{
+		{
+			Name:          "set decision",
+			Req:           RuleRequest{Name: "night card", Expression: "txn.hour < 5", Action: "SET_DECISION", Decision: "BLOCK"},
+			ExpectedError: nil,
+		},
+		{
+			Name:          "add score",
+			Req:           RuleRequest{Name: "discount", Expression: "payee.payments > 20", Action: "ADD_SCORE", ScoreDelta: -15},
+			ExpectedError: nil,
+		},
+		{
+			Name:          "add tag",
+			Req:           RuleRequest{Name: "big", Expression: "txn.amount > 100000", Action: "ADD_TAG", Tag: "high_value"},
+			ExpectedError: nil,
+		},
+		{
+			Name:          "empty name",
+			Req:           RuleRequest{Expression: "txn.hour < 5", Action: "SET_DECISION", Decision: "BLOCK"},
+			ExpectedError: errors.ErrInvalidRuleName,
+		},
+		{
+			Name:          "empty expression",
+			Req:           RuleRequest{Name: "night card", Action: "SET_DECISION", Decision: "BLOCK"},
+			ExpectedError: errors.ErrInvalidRuleExpression,
+		},
+		{
+			Name:          "unknown action",
+			Req:           RuleRequest{Name: "night card", Expression: "txn.hour < 5", Action: "NOTIFY"},
+			ExpectedError: errors.ErrInvalidRuleAction,
+		},
+		{
+			Name:          "unknown decision",
+			Req:           RuleRequest{Name: "night card", Expression: "txn.hour < 5", Action: "SET_DECISION", Decision: "REVIEW"},
+			ExpectedError: errors.ErrInvalidRuleDecision,
+		},
+		{
+			Name:          "score delta out of range",
+			Req:           RuleRequest{Name: "discount", Expression: "txn.hour < 5", Action: "ADD_SCORE", ScoreDelta: 150},
+			ExpectedError: errors.ErrInvalidRuleScoreDelta,
+		},
+		{
+			Name:          "missing tag",
+			Req:           RuleRequest{Name: "big", Expression: "txn.amount > 100000", Action: "ADD_TAG"},
+			ExpectedError: errors.ErrInvalidRuleTag,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Req.Validate()
+			if err != tc.ExpectedError {
+				t.Errorf("Expected Error: %v, Got: %v\n", tc.ExpectedError, err)
+			}
+		})
+	}
+}
+
+func TestFraudAnalysisResultExplain(t *testing.T) {
+	result := FraudAnalysisResult{
+		Decision:          repository.TransactionDecisionFLAG,
+		FinalRiskScore:    45,
+		RawRiskScore:      60,
+		ProfileConfidence: 50,
+		DampeningFactor:   0.75,
+		Factors: []FactorResult{
+			{Name: "AMOUNT_DEVIATION", Score: 100, Weight: 0.25, Contribution: 25, Triggered: true, ReasonCode: "AMOUNT_ABOVE_USUAL", Explanation: "amount 960.00 is 8.1σ above the overall median of 480.00"},
+			{Name: "NEW_MODE", ReasonCode: "MODE_REGISTERED", Explanation: "mode UPI against registered modes [UPI]"},
+		},
+	}
+
+	e := result.Explain()
+	if len(e.ReasonCodes) != 1 || e.ReasonCodes[0] != "AMOUNT_ABOVE_USUAL" {
+		t.Errorf("Expected reason codes of the triggered factors, Got: %v\n", e.ReasonCodes)
+	}
+	expected := "FLAG at risk score 45, raw 60.0 dampened by 0.75 for 50% profile confidence; " +
+		"AMOUNT_DEVIATION (25.0 points): amount 960.00 is 8.1σ above the overall median of 480.00"
+	if e.Summary != expected {
+		t.Errorf("Expected Summary: %q, Got: %q\n", expected, e.Summary)
+	}
+
+	result = FraudAnalysisResult{
+		Decision:       repository.TransactionDecisionBLOCK,
+		FinalRiskScore: 100,
+		ListEntry:      &ListEntryResponse{ID: 3, ListType: repository.ListTypeDENY, Entity: repository.ListEntityPAYEE, Value: "mule@upi", Reason: "confirmed mule"},
+	}
+
+	e = result.Explain()
+	if len(e.ReasonCodes) != 1 || e.ReasonCodes[0] != "LIST_DENY" {
+		t.Errorf("Expected the list reason code, Got: %v\n", e.ReasonCodes)
+	}
+	expected = "BLOCK by DENY list entry 3 on PAYEE mule@upi: confirmed mule"
+	if e.Summary != expected {
+		t.Errorf("Expected Summary: %q, Got: %q\n", expected, e.Summary)
+	}
+
+	result = FraudAnalysisResult{
+		Decision:        repository.TransactionDecisionBLOCK,
+		FinalRiskScore:  30,
+		DampeningFactor: 1,
+		RuleScoreDelta:  30,
+		MatchedRules: []MatchedRule{
+			{ID: 1, Name: "night card", Action: repository.RuleActionSETDECISION, Decision: repository.TransactionDecisionBLOCK},
+			{ID: 2, Name: "burst", Action: repository.RuleActionADDSCORE, ScoreDelta: 30},
+			{ID: 3, Name: "big", Action: repository.RuleActionADDTAG, Tag: "high_value"},
+		},
+		Tags: []string{"high_value"},
+	}
+
+	e = result.Explain()
+	expected = "BLOCK at risk score 30, raw 0.0 dampened by 1.00 for 0% profile confidence; no factor triggered; " +
+		`rule "night card" set BLOCK; rule "burst" added +30.0 points; rule "big" tagged high_value`
+	if e.Summary != expected {
+		t.Errorf("Expected Summary: %q, Got: %q\n", expected, e.Summary)
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestLabelMetrics(t *testing.T) {
+	var m LabelMetrics
+	if m.Finish() != nil {
+		t.Errorf("Expected no metrics without labels\n")
+	}
+
+	m.Add(repository.FraudLabelCONFIRMEDFRAUD, repository.TransactionDecisionBLOCK, 3)
+	m.Add(repository.FraudLabelCONFIRMEDFRAUD, repository.TransactionDecisionALLOW, 1)
+	m.Add(repository.FraudLabelFALSEPOSITIVE, repository.TransactionDecisionFLAG, 1)
+	m.Add(repository.FraudLabelFALSEPOSITIVE, repository.TransactionDecisionALLOW, 5)
+	m.Add("", repository.TransactionDecisionBLOCK, 7)
+
+	got := m.Finish()
+	want := LabelMetrics{Labeled: 10, TruePositives: 3, FalsePositives: 1, FalseNegatives: 1, Precision: 0.75, Recall: 0.75}
+	if got == nil || *got != want {
+		t.Errorf("Expected: %+v, Got: %+v\n", want, got)
+	}
+}
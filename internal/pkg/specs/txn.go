@@ -1,8 +1,12 @@
 package specs
 
 import (
+	"fmt"
+	"net/netip"
+	"strings"
 	"time"
 
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
 	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 )
@@ -10,6 +14,28 @@ import (
 type CreateTransactionRequest struct {
 	Amount float64 `json:"amount"`
 	Mode   string  `json:"mode"`
+
+	// UTCOffsetMinutes is the client's current offset from UTC. When omitted
+	// the transaction is scored in the user's timezone.
+	UTCOffsetMinutes *int `json:"utc_offset_minutes,omitempty"`
+
+	// Optional fingerprint of the client the payment is made from. A device_id
+	// the user never paid from raises the NEW_DEVICE factor.
+	DeviceID  string `json:"device_id,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// Optional counterparty: a UPI VPA, card merchant ID or account number. A
+	// payee the user paid fewer than a few times raises the NEW_PAYEE factor.
+	PayeeID string `json:"payee_id,omitempty"`
+
+	// Optional client coordinates. Without them the transaction is located
+	// through the GeoIP database, when ip_address is sent.
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+
+	// IdempotencyKey is read from the Idempotency-Key header
+	IdempotencyKey string `json:"-"`
 }
 
 func (r CreateTransactionRequest) Validate() error {
@@ -26,6 +52,38 @@ func (r CreateTransactionRequest) Validate() error {
 		return errors.ErrAmountOutOfRange
 	}
 
+	if len(r.IdempotencyKey) > constants.MaxExternalReferenceLength {
+		return errors.ErrInvalidExternalReference
+	}
+
+	if r.UTCOffsetMinutes != nil &&
+		(*r.UTCOffsetMinutes < constants.MinUTCOffsetMinutes || *r.UTCOffsetMinutes > constants.MaxUTCOffsetMinutes) {
+		return errors.ErrInvalidUTCOffset
+	}
+
+	if len(r.DeviceID) > constants.MaxDeviceIDLength {
+		return errors.ErrInvalidDeviceID
+	}
+
+	if len(r.PayeeID) > constants.MaxPayeeIDLength {
+		return errors.ErrInvalidPayeeID
+	}
+
+	if r.IPAddress != "" {
+		if _, err := netip.ParseAddr(r.IPAddress); err != nil {
+			return errors.ErrInvalidIPAddress
+		}
+	}
+
+	if len(r.UserAgent) > constants.MaxUserAgentLength {
+		return errors.ErrInvalidUserAgent
+	}
+
+	if (r.Latitude == nil) != (r.Longitude == nil) ||
+		(r.Latitude != nil && (*r.Latitude < -90 || *r.Latitude > 90 || *r.Longitude < -180 || *r.Longitude > 180)) {
+		return errors.ErrInvalidCoordinates
+	}
+
 	switch repository.Mode(r.Mode) {
 	case repository.ModeUPI, repository.ModeCARD, repository.ModeNETBANKING:
 		return nil
@@ -35,22 +93,245 @@ func (r CreateTransactionRequest) Validate() error {
 }
 
 type CreateBulkTransactionRequest struct {
-	Amount    float64   `json:"amount"`
-	Mode      string    `json:"mode"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount            float64   `json:"amount"`
+	Mode              string    `json:"mode"`
+	CreatedAt         time.Time `json:"created_at"`
+	ExternalReference string    `json:"external_reference"`
+}
+
+// TransactionInput is the transaction under evaluation, as seen by risk factors
+type TransactionInput struct {
+	Amount float64
+	Mode   repository.Mode
+	// CreatedAt is in the user's local time, so Hour and Weekday are local
+	CreatedAt time.Time
+	// DeviceID is empty when the client sent no device fingerprint
+	DeviceID string
+	// Location is nil when the transaction could not be located
+	Location *GeoPoint
+	// PayeeID is empty when the client sent no payee
+	PayeeID string
+}
+
+// GeoPoint is a position in decimal degrees
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// LocatedTransaction is where and when a past transaction was made
+type LocatedTransaction struct {
+	GeoPoint
+	CreatedAt time.Time
+}
+
+// AmountStats is the amount distribution of a user's legitimate transactions,
+// as stored in the profile's amount_stats and mode_amount_stats
+type AmountStats struct {
+	Count  int64   `json:"count"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	Median float64 `json:"median"`
+	// MAD is the median absolute deviation from the median
+	MAD float64 `json:"mad"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+}
+
+// TransactionHistory carries the user's recent activity that risk factors
+// compare the transaction against
+type TransactionHistory struct {
+	// Velocity holds one entry per velocity window, shortest first
+	Velocity []VelocityStats
+	// Devices is the user's history with the transaction's device, empty when
+	// the transaction has no device_id
+	Devices DeviceHistory
+	// PreviousLocation is the user's latest located legitimate transaction,
+	// nil when there is none
+	PreviousLocation *LocatedTransaction
+	// Payees is the user's history with the transaction's payee, empty when
+	// the transaction has no payee_id
+	Payees PayeeHistory
+	// ListEntry is the allow or deny list entry matching the transaction, nil
+	// when none does. A matching entry decides the transaction unscored.
+	ListEntry *ListEntryResponse
+}
+
+// DeviceHistory tells whether the user made legitimate transactions from a
+// device before, and from how many devices in total
+type DeviceHistory struct {
+	Known        bool
+	KnownDevices int64
+}
+
+// PayeeHistory tells how many legitimate payments the user made to a payee,
+// and to how many payees in total
+type PayeeHistory struct {
+	Payments    int32
+	KnownPayees int64
+}
+
+// VelocityWindow returns the stats of the named window, or empty stats when
+// the window was not collected
+func (h TransactionHistory) VelocityWindow(name string) VelocityStats {
+	for _, v := range h.Velocity {
+		if v.Window == name {
+			return v
+		}
+	}
+	return VelocityStats{Window: name}
+}
+
+// VelocityStats is the user's activity inside one velocity window before the
+// transaction under evaluation, across all modes and for its mode only
+type VelocityStats struct {
+	Window     string  `json:"window"`
+	Count      int     `json:"count"`
+	Amount     float64 `json:"amount"`
+	ModeCount  int     `json:"mode_count"`
+	ModeAmount float64 `json:"mode_amount"`
+}
+
+// FactorResult is the outcome of evaluating a single risk factor
+type FactorResult struct {
+	Name      string  `json:"name"`
+	Score     float64 `json:"score"`
+	Weight    float64 `json:"weight"`
+	Threshold float64 `json:"threshold"`
+	// Contribution is the factor's share of the raw risk score: Score * Weight
+	// over the total weight of the factors that apply
+	Contribution float64 `json:"contribution"`
+	Triggered    bool    `json:"triggered"`
+	// Skipped factors had nothing to score on the transaction, such as
+	// NEW_DEVICE without a device_id. They are left out of the raw risk score.
+	Skipped bool `json:"skipped,omitempty"`
+	// ReasonCode names what the factor found, one of the constants.Reason* codes
+	ReasonCode  string `json:"reason_code"`
+	Explanation string `json:"explanation"`
+	// Observed is the transaction's value of the signal and Baseline the user's
+	// usual value or limit it is compared against, both in Unit. They are nil
+	// when the factor found nothing to compare.
+	Observed *float64 `json:"observed,omitempty"`
+	Baseline *float64 `json:"baseline,omitempty"`
+	Unit     string   `json:"unit,omitempty"`
 }
 
 type FraudAnalysisResult struct {
 	Message           string                         `json:"message"`
+	ConfigVersion     int32                          `json:"config_version"`
 	Decision          repository.TransactionDecision `json:"decision"`
 	FinalRiskScore    int32                          `json:"final_risk_score"`
 	RawRiskScore      float64                        `json:"raw_risk_score"`
 	ProfileConfidence float64                        `json:"profile_confidence"`
-	TriggeredFactors  []string                       `json:"triggered_factors"`
-	AmountRisk        float64                        `json:"amount_risk"`
-	FrequencyRisk     float64                        `json:"frequency_risk"`
-	ModeRisk          float64                        `json:"mode_risk"`
-	TimeRisk          float64                        `json:"time_risk"`
+	// DampeningFactor is FinalRiskScore / RawRiskScore before rounding, the
+	// benefit of doubt given for the profile confidence
+	DampeningFactor float64 `json:"dampening_factor"`
+	// RuleScoreDelta is the sum of the score deltas of the matched ADD_SCORE
+	// rules, already included in FinalRiskScore
+	RuleScoreDelta   float64            `json:"rule_score_delta"`
+	TriggeredFactors []string           `json:"triggered_factors"`
+	Factors          []FactorResult     `json:"factors"`
+	MatchedRules     []MatchedRule      `json:"matched_rules"`
+	Tags             []string           `json:"tags"`
+	ListEntry        *ListEntryResponse `json:"list_entry,omitempty"`
+}
+
+// MatchedRuleIDs returns the ids of the matched rules in evaluation order
+func (r FraudAnalysisResult) MatchedRuleIDs() []int32 {
+	ids := make([]int32, 0, len(r.MatchedRules))
+	for _, m := range r.MatchedRules {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// FactorScores returns the score of every evaluated factor keyed by factor name
+func (r FraudAnalysisResult) FactorScores() map[string]float64 {
+	scores := make(map[string]float64, len(r.Factors))
+	for _, f := range r.Factors {
+		scores[f.Name] = f.Score
+	}
+	return scores
+}
+
+// Explain returns the explanation of the decision that is stored with the
+// transaction
+func (r FraudAnalysisResult) Explain() DecisionExplanation {
+	e := DecisionExplanation{
+		ConfigVersion:     r.ConfigVersion,
+		Decision:          r.Decision,
+		FinalRiskScore:    r.FinalRiskScore,
+		RawRiskScore:      r.RawRiskScore,
+		ProfileConfidence: r.ProfileConfidence,
+		DampeningFactor:   r.DampeningFactor,
+		RuleScoreDelta:    r.RuleScoreDelta,
+		ReasonCodes:       []string{},
+		Factors:           r.Factors,
+		MatchedRules:      r.MatchedRules,
+		Tags:              r.Tags,
+		ListEntry:         r.ListEntry,
+	}
+
+	if l := r.ListEntry; l != nil {
+		code := constants.ReasonListAllow
+		if l.ListType == repository.ListTypeDENY {
+			code = constants.ReasonListDeny
+		}
+		e.ReasonCodes = append(e.ReasonCodes, code)
+		e.Summary = fmt.Sprintf("%s by %s list entry %d on %s %s", r.Decision, l.ListType, l.ID, l.Entity, l.Value)
+		if l.Reason != "" {
+			e.Summary += ": " + l.Reason
+		}
+		return e
+	}
+
+	findings := []string{}
+	for _, f := range r.Factors {
+		if f.Triggered {
+			e.ReasonCodes = append(e.ReasonCodes, f.ReasonCode)
+			findings = append(findings, fmt.Sprintf("%s (%.1f points): %s", f.Name, f.Contribution, f.Explanation))
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, "no factor triggered")
+	}
+
+	for _, m := range r.MatchedRules {
+		switch m.Action {
+		case repository.RuleActionSETDECISION:
+			findings = append(findings, fmt.Sprintf("rule %q set %s", m.Name, m.Decision))
+		case repository.RuleActionADDSCORE:
+			findings = append(findings, fmt.Sprintf("rule %q added %+.1f points", m.Name, m.ScoreDelta))
+		case repository.RuleActionADDTAG:
+			findings = append(findings, fmt.Sprintf("rule %q tagged %s", m.Name, m.Tag))
+		}
+	}
+
+	e.Summary = fmt.Sprintf("%s at risk score %d, raw %.1f dampened by %.2f for %.0f%% profile confidence; %s",
+		r.Decision, r.FinalRiskScore, r.RawRiskScore, r.DampeningFactor, r.ProfileConfidence, strings.Join(findings, "; "))
+	return e
+}
+
+// DecisionExplanation breaks a decision down into the evaluated factors and the
+// dampening turning their weighted sum into the final risk score. It is stored
+// with the transaction and returned by the create and detail endpoints.
+type DecisionExplanation struct {
+	ConfigVersion     int32                          `json:"config_version"`
+	Decision          repository.TransactionDecision `json:"decision"`
+	FinalRiskScore    int32                          `json:"final_risk_score"`
+	RawRiskScore      float64                        `json:"raw_risk_score"`
+	ProfileConfidence float64                        `json:"profile_confidence"`
+	DampeningFactor   float64                        `json:"dampening_factor"`
+	RuleScoreDelta    float64                        `json:"rule_score_delta"`
+	// ReasonCodes are the codes of the triggered factors, or the list code of
+	// the list entry deciding the transaction
+	ReasonCodes []string `json:"reason_codes"`
+	// Summary is a one-line account of the decision for analysts and support
+	Summary      string             `json:"summary"`
+	Factors      []FactorResult     `json:"factors"`
+	MatchedRules []MatchedRule      `json:"matched_rules"`
+	Tags         []string           `json:"tags"`
+	ListEntry    *ListEntryResponse `json:"list_entry,omitempty"`
 }
 
 type CreateTransactionResponse struct {
@@ -59,12 +340,70 @@ type CreateTransactionResponse struct {
 	RiskScore        int32                          `json:"risk_score"`
 	TriggeredFactors []string                       `json:"triggered_factors"`
 	CreatedAt        time.Time                      `json:"created_at"`
+	MFAExpiresAt     *time.Time                     `json:"mfa_expires_at,omitempty"`
+	ListEntry        *ListEntryResponse             `json:"list_entry,omitempty"`
+	Tags             []string                       `json:"tags"`
+	// Explanation is nil for transactions stored before explanations were recorded
+	Explanation *DecisionExplanation `json:"explanation,omitempty"`
+}
+
+// TransactionDetailResponse is a stored transaction with its decoded
+// explanation. The fields after Explanation, and the rules, tags and list
+// entry of the explanation, are only returned to analysts and admins.
+type TransactionDetailResponse struct {
+	ID                int32                          `json:"id"`
+	UserID            int32                          `json:"user_id"`
+	Amount            float64                        `json:"amount"`
+	Mode              repository.Mode                `json:"mode"`
+	RiskScore         int32                          `json:"risk_score"`
+	TriggeredFactors  []string                       `json:"triggered_factors"`
+	Decision          repository.TransactionDecision `json:"decision"`
+	CreatedAt         time.Time                      `json:"created_at"`
+	UpdatedAt         time.Time                      `json:"updated_at"`
+	UTCOffsetMinutes  int32                          `json:"utc_offset_minutes"`
+	ConfigVersion     *int32                         `json:"config_version,omitempty"`
+	ExternalReference string                         `json:"external_reference,omitempty"`
+	DeviceID          string                         `json:"device_id,omitempty"`
+	PayeeID           string                         `json:"payee_id,omitempty"`
+	Latitude          *float64                       `json:"latitude,omitempty"`
+	Longitude         *float64                       `json:"longitude,omitempty"`
+	FactorScores      map[string]float64             `json:"factor_scores"`
+	// Explanation is nil for transactions stored before explanations were recorded
+	Explanation *DecisionExplanation `json:"explanation"`
+
+	IPAddress    string                `json:"ip_address,omitempty"`
+	UserAgent    string                `json:"user_agent,omitempty"`
+	ListEntryID  *int32                `json:"list_entry_id,omitempty"`
+	MatchedRules []int32               `json:"matched_rules,omitempty"`
+	Tags         []string              `json:"tags,omitempty"`
+	FraudLabel   repository.FraudLabel `json:"fraud_label,omitempty"`
+}
+
+type BulkJobResponse struct {
+	JobID      string                   `json:"job_id"`
+	Filename   string                   `json:"filename"`
+	Status     repository.BulkJobStatus `json:"status"`
+	Strict     bool                     `json:"strict"`
+	Progress   BulkJobProgress          `json:"progress"`
+	Error      string                   `json:"error,omitempty"`
+	CreatedAt  time.Time                `json:"created_at"`
+	StartedAt  *time.Time               `json:"started_at,omitempty"`
+	FinishedAt *time.Time               `json:"finished_at,omitempty"`
+}
+
+type BulkJobProgress struct {
+	Total     int32 `json:"total"`
+	Processed int32 `json:"processed"`
+	Success   int32 `json:"success"`
+	Failed    int32 `json:"failed"`
+	Percent   int32 `json:"percent"`
 }
 
-type BulkProcessResponse struct {
-	JobID     string `json:"job_id"`
-	Status    string `json:"status"`
-	Processed int    `json:"processed"`
-	Success   int    `json:"success"`
-	Failed    int    `json:"failed"`
+// BulkRowErrorResponse describes a row rejected by a bulk job. Line counts the
+// header as line 1.
+type BulkRowErrorResponse struct {
+	Line    int32                         `json:"line"`
+	Values  []string                      `json:"values"`
+	Reason  repository.BulkRowErrorReason `json:"reason"`
+	Message string                        `json:"message"`
 }
@@ -3,9 +3,12 @@ package specs
 import (
 	"regexp"
 	"time"
+	// embedded so timezones validate even where the host has no zoneinfo
+	_ "time/tzdata"
 
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -14,6 +17,8 @@ type UserSignupRequest struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// Timezone is an optional IANA name, UTC when omitted
+	Timezone string `json:"timezone"`
 }
 
 func (r UserSignupRequest) Validate() error {
@@ -37,25 +42,32 @@ func (r UserSignupRequest) Validate() error {
 		return errors.ErrInvalidBody
 	}
 
+	if r.Timezone != "" && !ValidTimezone(r.Timezone) {
+		return errors.ErrInvalidTimezone
+	}
+
 	return nil
 }
 
 // UserSignupResponse to represent signup response
 type UserSignupResponse struct {
-	Message string `json:"message"`
-	ID      int32  `json:"id"`
-	Name    string `json:"name"`
-	Email   string `json:"email"`
+	Message  string `json:"message"`
+	ID       int32  `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Timezone string `json:"timezone"`
 }
 
 // User struct represents details of a user profile.
 type UserResponse struct {
-	Message   string    `json:"message"`
-	ID        int32     `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Message   string              `json:"message"`
+	ID        int32               `json:"id"`
+	Name      string              `json:"name"`
+	Email     string              `json:"email"`
+	Role      repository.UserRole `json:"role"`
+	Timezone  string              `json:"timezone"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
 }
 
 // UserLoginRequest struct represents a request to log-in the user
@@ -89,8 +101,45 @@ type UserLoginResponse struct {
 }
 
 type UserTokenClaims struct {
-	UserID int32  `json:"user_id"`
-	Name   string `json:"name"`
-	Email  string `json:"email"`
+	UserID int32               `json:"user_id"`
+	Name   string              `json:"name"`
+	Email  string              `json:"email"`
+	Role   repository.UserRole `json:"role"`
 	jwt.RegisteredClaims
 }
+
+// UpdateUserRoleRequest struct represents a request to change a user's role
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func (r UpdateUserRoleRequest) Validate() error {
+	switch repository.UserRole(r.Role) {
+	case repository.UserRoleCUSTOMER, repository.UserRoleANALYST, repository.UserRoleADMIN:
+		return nil
+	default:
+		return errors.ErrInvalidRole
+	}
+}
+
+// UpdateTimezoneRequest struct represents a request to change the caller's timezone
+type UpdateTimezoneRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+func (r UpdateTimezoneRequest) Validate() error {
+	if !ValidTimezone(r.Timezone) {
+		return errors.ErrInvalidTimezone
+	}
+	return nil
+}
+
+// ValidTimezone reports whether name is a loadable IANA timezone. "Local" is
+// rejected since it names the server's zone, not the user's.
+func ValidTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
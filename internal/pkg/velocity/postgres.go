@@ -0,0 +1,59 @@
+package velocity
+
+import (
+	"context"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PostgresStore reads velocity straight from the transactions table. It is
+// slower than RedisStore but always complete, so it backs the Redis store.
+type PostgresStore struct {
+	queries *repository.Queries
+}
+
+func NewPostgresStore(queries *repository.Queries) *PostgresStore {
+	return &PostgresStore{queries: queries}
+}
+
+// Record is a no-op: the transaction is already stored in the table
+func (s *PostgresStore) Record(context.Context, int32, Event) error {
+	return nil
+}
+
+func (s *PostgresStore) Snapshot(ctx context.Context, userID int32, mode repository.Mode, at time.Time) ([]specs.VelocityStats, error) {
+	events, err := s.Events(ctx, userID, at)
+	if err != nil {
+		return nil, err
+	}
+	return Aggregate(events, mode, at), nil
+}
+
+// Events lists the user's transactions of the Retention before at
+func (s *PostgresStore) Events(ctx context.Context, userID int32, at time.Time) ([]Event, error) {
+	// created_at is a timestamp without time zone holding UTC
+	at = at.UTC()
+
+	rows, err := s.queries.ListTransactionActivity(ctx, repository.ListTransactionActivityParams{
+		UserID: userID,
+		Since:  pgtype.Timestamp{Time: at.Add(-Retention), Valid: true},
+		Until:  pgtype.Timestamp{Time: at, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, Event{
+			TxnID:     row.ID,
+			Mode:      row.Mode,
+			Amount:    row.Amount,
+			CreatedAt: row.CreatedAt.Time,
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,136 @@
+package velocity
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps each user's transactions of the last Retention in a sorted
+// set scored by creation time in milliseconds. Members encode the transaction
+// id, mode and amount, so both dimensions are read with a single range query.
+// A marker key next to the set records that it was seeded with the user's
+// complete activity; without it, e.g. after Redis was flushed or restarted,
+// the set may be missing transactions and Snapshot reports ErrNotCached.
+type RedisStore struct {
+	rd *redis.Client
+}
+
+func NewRedisStore(rd *redis.Client) *RedisStore {
+	return &RedisStore{rd: rd}
+}
+
+func velocityKey(userID int32) string {
+	return fmt.Sprintf("%s%d", constants.VelocityKeyPrefix, userID)
+}
+
+func seededKey(userID int32) string {
+	return fmt.Sprintf("%sseeded:%d", constants.VelocityKeyPrefix, userID)
+}
+
+// Record adds the event to the user's set. It does not create the marker, so
+// a set recorded into after a flush stays a cache miss until it is seeded.
+func (s *RedisStore) Record(ctx context.Context, userID int32, e Event) error {
+	_, err := s.rd.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		add(ctx, pipe, userID, e)
+		trim(ctx, pipe, userID)
+		pipe.Expire(ctx, seededKey(userID), Retention)
+		return nil
+	})
+	return err
+}
+
+// Seed fills the user's set with their complete activity and marks it as
+// complete. Events already in the set are kept once.
+func (s *RedisStore) Seed(ctx context.Context, userID int32, events []Event) error {
+	_, err := s.rd.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, e := range events {
+			add(ctx, pipe, userID, e)
+		}
+		trim(ctx, pipe, userID)
+		pipe.Set(ctx, seededKey(userID), "1", Retention)
+		return nil
+	})
+	return err
+}
+
+func add(ctx context.Context, pipe redis.Pipeliner, userID int32, e Event) {
+	pipe.ZAdd(ctx, velocityKey(userID), redis.Z{
+		Score:  float64(e.CreatedAt.UnixMilli()),
+		Member: encodeMember(e),
+	})
+}
+
+// trim drops the events older than Retention and extends the set's TTL
+func trim(ctx context.Context, pipe redis.Pipeliner, userID int32) {
+	key := velocityKey(userID)
+	cutoff := time.Now().Add(-Retention).UnixMilli()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(cutoff, 10))
+	pipe.Expire(ctx, key, Retention)
+}
+
+func (s *RedisStore) Snapshot(ctx context.Context, userID int32, mode repository.Mode, at time.Time) ([]specs.VelocityStats, error) {
+	var seeded *redis.IntCmd
+	var rangeCmd *redis.ZSliceCmd
+	_, err := s.rd.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		seeded = pipe.Exists(ctx, seededKey(userID))
+		rangeCmd = pipe.ZRangeByScoreWithScores(ctx, velocityKey(userID), &redis.ZRangeBy{
+			Min: "(" + strconv.FormatInt(at.Add(-Retention).UnixMilli(), 10),
+			Max: strconv.FormatInt(at.UnixMilli(), 10),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if seeded.Val() == 0 {
+		return nil, ErrNotCached
+	}
+
+	members := rangeCmd.Val()
+
+	events := make([]Event, 0, len(members))
+	for _, m := range members {
+		e, err := decodeMember(fmt.Sprint(m.Member))
+		if err != nil {
+			return nil, err
+		}
+		e.CreatedAt = time.UnixMilli(int64(m.Score))
+		events = append(events, e)
+	}
+	return Aggregate(events, mode, at), nil
+}
+
+// encodeMember formats an event as "txn_id:mode:amount"
+func encodeMember(e Event) string {
+	return fmt.Sprintf("%d:%s:%s", e.TxnID, e.Mode, strconv.FormatFloat(e.Amount, 'f', -1, 64))
+}
+
+func decodeMember(member string) (Event, error) {
+	parts := strings.Split(member, ":")
+	if len(parts) != 3 {
+		return Event{}, fmt.Errorf("malformed velocity member %q", member)
+	}
+
+	id, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil {
+		return Event{}, fmt.Errorf("malformed velocity member %q: %w", member, err)
+	}
+	amount, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return Event{}, fmt.Errorf("malformed velocity member %q: %w", member, err)
+	}
+
+	return Event{
+		TxnID:  int32(id),
+		Mode:   repository.Mode(parts[1]),
+		Amount: amount,
+	}, nil
+}
@@ -0,0 +1,36 @@
+package velocity
+
+import (
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+)
+
+// Replay tracks velocity in memory for a chronological replay such as a bulk
+// upload, where rows carry their own timestamps. Events must be added in
+// ascending created_at order.
+type Replay struct {
+	events []Event
+}
+
+func NewReplay() *Replay {
+	return &Replay{}
+}
+
+// Add records a replayed transaction
+func (r *Replay) Add(e Event) {
+	r.events = append(r.events, e)
+}
+
+// Snapshot returns the velocity before at and forgets the events that fell
+// out of the longest window
+func (r *Replay) Snapshot(mode repository.Mode, at time.Time) []specs.VelocityStats {
+	start := at.Add(-Retention)
+	drop := 0
+	for drop < len(r.events) && !r.events[drop].CreatedAt.After(start) {
+		drop++
+	}
+	r.events = r.events[drop:]
+	return Aggregate(r.events, mode, at)
+}
@@ -0,0 +1,149 @@
+package velocity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Window is a sliding window that activity is counted over
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Windows are the tracked windows, shortest first. Activity older than the
+// last one is forgotten.
+var Windows = []Window{
+	{Name: constants.VelocityWindow1m, Duration: time.Minute},
+	{Name: constants.VelocityWindow10m, Duration: 10 * time.Minute},
+	{Name: constants.VelocityWindow1h, Duration: time.Hour},
+	{Name: constants.VelocityWindow24h, Duration: 24 * time.Hour},
+	{Name: constants.VelocityWindow7d, Duration: 7 * 24 * time.Hour},
+}
+
+// Retention is how long activity is kept, the duration of the longest window
+var Retention = Windows[len(Windows)-1].Duration
+
+// Event is a stored transaction as seen by the velocity windows
+type Event struct {
+	TxnID     int32
+	Mode      repository.Mode
+	Amount    float64
+	CreatedAt time.Time
+}
+
+// Store records transactions and reports a user's velocity before a point in time
+type Store interface {
+	Record(ctx context.Context, userID int32, e Event) error
+	Snapshot(ctx context.Context, userID int32, mode repository.Mode, at time.Time) ([]specs.VelocityStats, error)
+}
+
+// ErrNotCached is reported by a store that does not hold the user's complete
+// activity, so that it is read from the fallback instead
+var ErrNotCached = errors.New("velocity not cached")
+
+// EventLoader lists a user's events of the Retention before at
+type EventLoader interface {
+	Events(ctx context.Context, userID int32, at time.Time) ([]Event, error)
+}
+
+// Seeder stores a user's complete events, e.g. after a cache miss
+type Seeder interface {
+	Seed(ctx context.Context, userID int32, events []Event) error
+}
+
+// New returns a Redis backed store that falls back to the transactions table
+// when Redis is unavailable
+func New(rd *redis.Client, queries *repository.Queries, logger *zap.Logger) Store {
+	return NewFallbackStore(NewRedisStore(rd), NewPostgresStore(queries), logger)
+}
+
+// Aggregate sums the events inside each window ending at at. Events after at
+// are ignored; mode selects the events counted in the per-mode stats.
+func Aggregate(events []Event, mode repository.Mode, at time.Time) []specs.VelocityStats {
+	stats := make([]specs.VelocityStats, len(Windows))
+	for i, w := range Windows {
+		stats[i].Window = w.Name
+	}
+
+	for _, e := range events {
+		if e.CreatedAt.After(at) {
+			continue
+		}
+		age := at.Sub(e.CreatedAt)
+		for i, w := range Windows {
+			if age >= w.Duration {
+				continue
+			}
+			stats[i].Count++
+			stats[i].Amount += e.Amount
+			if e.Mode == mode {
+				stats[i].ModeCount++
+				stats[i].ModeAmount += e.Amount
+			}
+		}
+	}
+	return stats
+}
+
+// FallbackStore reads from the primary store and uses the fallback when the
+// primary fails. Events are only recorded in the primary store; the fallback
+// is expected to see them on its own, like the transactions table does. On a
+// cache miss the primary is seeded from the fallback's events, when the stores
+// support it.
+type FallbackStore struct {
+	primary  Store
+	fallback Store
+	logger   *zap.Logger
+}
+
+func NewFallbackStore(primary Store, fallback Store, logger *zap.Logger) *FallbackStore {
+	return &FallbackStore{
+		primary:  primary,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+func (s *FallbackStore) Record(ctx context.Context, userID int32, e Event) error {
+	return s.primary.Record(ctx, userID, e)
+}
+
+func (s *FallbackStore) Snapshot(ctx context.Context, userID int32, mode repository.Mode, at time.Time) ([]specs.VelocityStats, error) {
+	stats, err := s.primary.Snapshot(ctx, userID, mode, at)
+	switch {
+	case err == nil:
+		return stats, nil
+	case errors.Is(err, ErrNotCached):
+		return s.seed(ctx, userID, mode, at)
+	}
+
+	s.logger.Warn("velocity store unavailable, using fallback", zap.Int32("user_id", userID), zap.Error(err))
+	return s.fallback.Snapshot(ctx, userID, mode, at)
+}
+
+// seed reads the user's velocity from the fallback and fills the primary with
+// the same events. Failing to seed only costs another miss.
+func (s *FallbackStore) seed(ctx context.Context, userID int32, mode repository.Mode, at time.Time) ([]specs.VelocityStats, error) {
+	loader, canLoad := s.fallback.(EventLoader)
+	seeder, canSeed := s.primary.(Seeder)
+	if !canLoad || !canSeed {
+		return s.fallback.Snapshot(ctx, userID, mode, at)
+	}
+
+	events, err := loader.Events(ctx, userID, at)
+	if err != nil {
+		return nil, err
+	}
+	if err := seeder.Seed(ctx, userID, events); err != nil {
+		s.logger.Warn("failed to seed velocity store", zap.Int32("user_id", userID), zap.Error(err))
+	}
+	return Aggregate(events, mode, at), nil
+}
@@ -0,0 +1,150 @@
+package velocity
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func window(stats []specs.VelocityStats, name string) specs.VelocityStats {
+	return specs.TransactionHistory{Velocity: stats}.VelocityWindow(name)
+}
+
+func TestAggregate(t *testing.T) {
+	at := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{TxnID: 1, Mode: repository.ModeUPI, Amount: 100, CreatedAt: at.Add(-30 * time.Second)},
+		{TxnID: 2, Mode: repository.ModeCARD, Amount: 200, CreatedAt: at.Add(-5 * time.Minute)},
+		{TxnID: 3, Mode: repository.ModeUPI, Amount: 300, CreatedAt: at.Add(-2 * time.Hour)},
+		{TxnID: 4, Mode: repository.ModeUPI, Amount: 400, CreatedAt: at.Add(-3 * 24 * time.Hour)},
+		{TxnID: 5, Mode: repository.ModeUPI, Amount: 500, CreatedAt: at.Add(-7 * 24 * time.Hour)},
+		{TxnID: 6, Mode: repository.ModeUPI, Amount: 600, CreatedAt: at.Add(time.Minute)},
+	}
+
+	stats := Aggregate(events, repository.ModeUPI, at)
+	assert.Len(t, stats, len(Windows))
+
+	assert.Equal(t, specs.VelocityStats{Window: constants.VelocityWindow1m, Count: 1, Amount: 100, ModeCount: 1, ModeAmount: 100},
+		window(stats, constants.VelocityWindow1m))
+	assert.Equal(t, specs.VelocityStats{Window: constants.VelocityWindow10m, Count: 2, Amount: 300, ModeCount: 1, ModeAmount: 100},
+		window(stats, constants.VelocityWindow10m))
+	assert.Equal(t, 2, window(stats, constants.VelocityWindow1h).Count)
+	assert.Equal(t, 3, window(stats, constants.VelocityWindow24h).Count)
+	assert.Equal(t, specs.VelocityStats{Window: constants.VelocityWindow7d, Count: 4, Amount: 1000, ModeCount: 3, ModeAmount: 800},
+		window(stats, constants.VelocityWindow7d))
+}
+
+func TestReplay(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	r := NewReplay()
+
+	assert.Equal(t, 0, window(r.Snapshot(repository.ModeUPI, base), constants.VelocityWindow1h).Count)
+	r.Add(Event{Mode: repository.ModeUPI, Amount: 100, CreatedAt: base})
+	r.Add(Event{Mode: repository.ModeCARD, Amount: 100, CreatedAt: base.Add(10 * time.Minute)})
+	r.Add(Event{Mode: repository.ModeUPI, Amount: 100, CreatedAt: base.Add(50 * time.Minute)})
+
+	hour := window(r.Snapshot(repository.ModeUPI, base.Add(55*time.Minute)), constants.VelocityWindow1h)
+	assert.Equal(t, 3, hour.Count)
+	assert.Equal(t, 2, hour.ModeCount)
+	assert.Equal(t, 2, window(r.Snapshot(repository.ModeUPI, base.Add(time.Hour)), constants.VelocityWindow1h).Count)
+	assert.Equal(t, 1, window(r.Snapshot(repository.ModeUPI, base.Add(90*time.Minute)), constants.VelocityWindow1h).Count)
+	assert.Equal(t, 3, window(r.Snapshot(repository.ModeUPI, base.Add(3*time.Hour)), constants.VelocityWindow24h).Count)
+
+	r.Snapshot(repository.ModeUPI, base.Add(8*24*time.Hour))
+	assert.Empty(t, r.events)
+}
+
+func TestMemberEncoding(t *testing.T) {
+	e := Event{TxnID: 42, Mode: repository.ModeNETBANKING, Amount: 1234.5}
+
+	decoded, err := decodeMember(encodeMember(e))
+	assert.NoError(t, err)
+	assert.Equal(t, e, decoded)
+
+	_, err = decodeMember("42:UPI")
+	assert.Error(t, err)
+}
+
+type stubStore struct {
+	stats    []specs.VelocityStats
+	err      error
+	recorded []Event
+}
+
+func (s *stubStore) Record(_ context.Context, _ int32, e Event) error {
+	s.recorded = append(s.recorded, e)
+	return s.err
+}
+
+func (s *stubStore) Snapshot(context.Context, int32, repository.Mode, time.Time) ([]specs.VelocityStats, error) {
+	return s.stats, s.err
+}
+
+// seedingStore is a stubStore that can list and be seeded with events
+type seedingStore struct {
+	stubStore
+	events []Event
+	seeded []Event
+}
+
+func (s *seedingStore) Events(context.Context, int32, time.Time) ([]Event, error) {
+	return s.events, nil
+}
+
+func (s *seedingStore) Seed(_ context.Context, _ int32, events []Event) error {
+	s.seeded = events
+	return nil
+}
+
+func TestFallbackStore(t *testing.T) {
+	ctx := context.Background()
+	primaryStats := []specs.VelocityStats{{Window: constants.VelocityWindow1h, Count: 1}}
+	fallbackStats := []specs.VelocityStats{{Window: constants.VelocityWindow1h, Count: 2}}
+
+	t.Run("primary available", func(t *testing.T) {
+		primary, fallback := &stubStore{stats: primaryStats}, &stubStore{stats: fallbackStats}
+		store := NewFallbackStore(primary, fallback, zap.NewNop())
+
+		stats, err := store.Snapshot(ctx, 1, repository.ModeUPI, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, primaryStats, stats)
+
+		assert.NoError(t, store.Record(ctx, 1, Event{TxnID: 1}))
+		assert.Len(t, primary.recorded, 1)
+		assert.Empty(t, fallback.recorded)
+	})
+
+	t.Run("primary unavailable", func(t *testing.T) {
+		primary := &stubStore{err: errors.New("connection refused")}
+		store := NewFallbackStore(primary, &stubStore{stats: fallbackStats}, zap.NewNop())
+
+		stats, err := store.Snapshot(ctx, 1, repository.ModeUPI, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, fallbackStats, stats)
+	})
+
+	t.Run("primary not seeded", func(t *testing.T) {
+		at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		events := []Event{
+			{TxnID: 1, Mode: repository.ModeUPI, Amount: 100, CreatedAt: at.Add(-30 * time.Minute)},
+			{TxnID: 2, Mode: repository.ModeCARD, Amount: 200, CreatedAt: at.Add(-2 * time.Hour)},
+		}
+		primary := &seedingStore{stubStore: stubStore{err: ErrNotCached}}
+		fallback := &seedingStore{events: events}
+		store := NewFallbackStore(primary, fallback, zap.NewNop())
+
+		// a flushed cache is read from the fallback, not as zero activity
+		stats, err := store.Snapshot(ctx, 1, repository.ModeUPI, at)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, window(stats, constants.VelocityWindow1h).Count)
+		assert.Equal(t, 2, window(stats, constants.VelocityWindow24h).Count)
+		assert.Equal(t, events, primary.seeded)
+	})
+}
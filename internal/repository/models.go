@@ -6,11 +6,274 @@ package repository
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"net/netip"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type BulkJobStatus string
+
+const (
+	BulkJobStatusPENDING    BulkJobStatus = "PENDING"
+	BulkJobStatusPROCESSING BulkJobStatus = "PROCESSING"
+	BulkJobStatusCOMPLETED  BulkJobStatus = "COMPLETED"
+	BulkJobStatusFAILED     BulkJobStatus = "FAILED"
+)
+
+func (e *BulkJobStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = BulkJobStatus(s)
+	case string:
+		*e = BulkJobStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for BulkJobStatus: %T", src)
+	}
+	return nil
+}
+
+type NullBulkJobStatus struct {
+	BulkJobStatus BulkJobStatus `json:"bulk_job_status"`
+	Valid         bool          `json:"valid"` // Valid is true if BulkJobStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullBulkJobStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.BulkJobStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.BulkJobStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullBulkJobStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.BulkJobStatus), nil
+}
+
+type BulkRowErrorReason string
+
+const (
+	BulkRowErrorReasonMALFORMEDROW       BulkRowErrorReason = "MALFORMED_ROW"
+	BulkRowErrorReasonINVALIDAMOUNT      BulkRowErrorReason = "INVALID_AMOUNT"
+	BulkRowErrorReasonINVALIDMODE        BulkRowErrorReason = "INVALID_MODE"
+	BulkRowErrorReasonINVALIDTIMESTAMP   BulkRowErrorReason = "INVALID_TIMESTAMP"
+	BulkRowErrorReasonINSERTFAILED       BulkRowErrorReason = "INSERT_FAILED"
+	BulkRowErrorReasonDUPLICATEREFERENCE BulkRowErrorReason = "DUPLICATE_REFERENCE"
+)
+
+func (e *BulkRowErrorReason) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = BulkRowErrorReason(s)
+	case string:
+		*e = BulkRowErrorReason(s)
+	default:
+		return fmt.Errorf("unsupported scan type for BulkRowErrorReason: %T", src)
+	}
+	return nil
+}
+
+type NullBulkRowErrorReason struct {
+	BulkRowErrorReason BulkRowErrorReason `json:"bulk_row_error_reason"`
+	Valid              bool               `json:"valid"` // Valid is true if BulkRowErrorReason is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullBulkRowErrorReason) Scan(value interface{}) error {
+	if value == nil {
+		ns.BulkRowErrorReason, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.BulkRowErrorReason.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullBulkRowErrorReason) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.BulkRowErrorReason), nil
+}
+
+type CaseStatus string
+
+const (
+	CaseStatusOPEN     CaseStatus = "OPEN"
+	CaseStatusASSIGNED CaseStatus = "ASSIGNED"
+	CaseStatusRESOLVED CaseStatus = "RESOLVED"
+)
+
+func (e *CaseStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CaseStatus(s)
+	case string:
+		*e = CaseStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CaseStatus: %T", src)
+	}
+	return nil
+}
+
+type NullCaseStatus struct {
+	CaseStatus CaseStatus `json:"case_status"`
+	Valid      bool       `json:"valid"` // Valid is true if CaseStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCaseStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.CaseStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CaseStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCaseStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CaseStatus), nil
+}
+
+type FraudLabel string
+
+const (
+	FraudLabelCONFIRMEDFRAUD FraudLabel = "CONFIRMED_FRAUD"
+	FraudLabelFALSEPOSITIVE  FraudLabel = "FALSE_POSITIVE"
+)
+
+func (e *FraudLabel) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FraudLabel(s)
+	case string:
+		*e = FraudLabel(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FraudLabel: %T", src)
+	}
+	return nil
+}
+
+type NullFraudLabel struct {
+	FraudLabel FraudLabel `json:"fraud_label"`
+	Valid      bool       `json:"valid"` // Valid is true if FraudLabel is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullFraudLabel) Scan(value interface{}) error {
+	if value == nil {
+		ns.FraudLabel, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.FraudLabel.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullFraudLabel) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.FraudLabel), nil
+}
+
+type ListEntity string
+
+const (
+	ListEntityUSER   ListEntity = "USER"
+	ListEntityPAYEE  ListEntity = "PAYEE"
+	ListEntityDEVICE ListEntity = "DEVICE"
+	ListEntityIP     ListEntity = "IP"
+)
+
+func (e *ListEntity) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ListEntity(s)
+	case string:
+		*e = ListEntity(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ListEntity: %T", src)
+	}
+	return nil
+}
+
+type NullListEntity struct {
+	ListEntity ListEntity `json:"list_entity"`
+	Valid      bool       `json:"valid"` // Valid is true if ListEntity is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullListEntity) Scan(value interface{}) error {
+	if value == nil {
+		ns.ListEntity, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ListEntity.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullListEntity) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ListEntity), nil
+}
+
+type ListType string
+
+const (
+	ListTypeALLOW ListType = "ALLOW"
+	ListTypeDENY  ListType = "DENY"
+)
+
+func (e *ListType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ListType(s)
+	case string:
+		*e = ListType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ListType: %T", src)
+	}
+	return nil
+}
+
+type NullListType struct {
+	ListType ListType `json:"list_type"`
+	Valid    bool     `json:"valid"` // Valid is true if ListType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullListType) Scan(value interface{}) error {
+	if value == nil {
+		ns.ListType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ListType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullListType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ListType), nil
+}
+
 type Mode string
 
 const (
@@ -54,6 +317,49 @@ func (ns NullMode) Value() (driver.Value, error) {
 	return string(ns.Mode), nil
 }
 
+type RuleAction string
+
+const (
+	RuleActionSETDECISION RuleAction = "SET_DECISION"
+	RuleActionADDSCORE    RuleAction = "ADD_SCORE"
+	RuleActionADDTAG      RuleAction = "ADD_TAG"
+)
+
+func (e *RuleAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RuleAction(s)
+	case string:
+		*e = RuleAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RuleAction: %T", src)
+	}
+	return nil
+}
+
+type NullRuleAction struct {
+	RuleAction RuleAction `json:"rule_action"`
+	Valid      bool       `json:"valid"` // Valid is true if RuleAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRuleAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.RuleAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RuleAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRuleAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RuleAction), nil
+}
+
 type TransactionDecision string
 
 const (
@@ -98,64 +404,201 @@ func (ns NullTransactionDecision) Value() (driver.Value, error) {
 	return string(ns.TransactionDecision), nil
 }
 
-type TriggerFactors string
+type UserRole string
 
 const (
-	TriggerFactorsAMOUNTDEVIATION TriggerFactors = "AMOUNT_DEVIATION"
-	TriggerFactorsFREQUENCYSPIKE  TriggerFactors = "FREQUENCY_SPIKE"
-	TriggerFactorsNEWMODE         TriggerFactors = "NEW_MODE"
-	TriggerFactorsTIMEANOMALY     TriggerFactors = "TIME_ANOMALY"
+	UserRoleCUSTOMER UserRole = "CUSTOMER"
+	UserRoleANALYST  UserRole = "ANALYST"
+	UserRoleADMIN    UserRole = "ADMIN"
 )
 
-func (e *TriggerFactors) Scan(src interface{}) error {
+func (e *UserRole) Scan(src interface{}) error {
 	switch s := src.(type) {
 	case []byte:
-		*e = TriggerFactors(s)
+		*e = UserRole(s)
 	case string:
-		*e = TriggerFactors(s)
+		*e = UserRole(s)
 	default:
-		return fmt.Errorf("unsupported scan type for TriggerFactors: %T", src)
+		return fmt.Errorf("unsupported scan type for UserRole: %T", src)
 	}
 	return nil
 }
 
-type NullTriggerFactors struct {
-	TriggerFactors TriggerFactors `json:"trigger_factors"`
-	Valid          bool           `json:"valid"` // Valid is true if TriggerFactors is not NULL
+type NullUserRole struct {
+	UserRole UserRole `json:"user_role"`
+	Valid    bool     `json:"valid"` // Valid is true if UserRole is not NULL
 }
 
 // Scan implements the Scanner interface.
-func (ns *NullTriggerFactors) Scan(value interface{}) error {
+func (ns *NullUserRole) Scan(value interface{}) error {
 	if value == nil {
-		ns.TriggerFactors, ns.Valid = "", false
+		ns.UserRole, ns.Valid = "", false
 		return nil
 	}
 	ns.Valid = true
-	return ns.TriggerFactors.Scan(value)
+	return ns.UserRole.Scan(value)
 }
 
 // Value implements the driver Valuer interface.
-func (ns NullTriggerFactors) Value() (driver.Value, error) {
+func (ns NullUserRole) Value() (driver.Value, error) {
 	if !ns.Valid {
 		return nil, nil
 	}
-	return string(ns.TriggerFactors), nil
+	return string(ns.UserRole), nil
+}
+
+type BulkJob struct {
+	ID            pgtype.UUID      `json:"id"`
+	UserID        int32            `json:"user_id"`
+	Filename      string           `json:"filename"`
+	File          []byte           `json:"file"`
+	Status        BulkJobStatus    `json:"status"`
+	TotalRows     int32            `json:"total_rows"`
+	ProcessedRows int32            `json:"processed_rows"`
+	SuccessRows   int32            `json:"success_rows"`
+	FailedRows    int32            `json:"failed_rows"`
+	Error         pgtype.Text      `json:"error"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	StartedAt     pgtype.Timestamp `json:"started_at"`
+	HeartbeatAt   pgtype.Timestamp `json:"heartbeat_at"`
+	FinishedAt    pgtype.Timestamp `json:"finished_at"`
+	Strict        bool             `json:"strict"`
+	Attempts      int32            `json:"attempts"`
+}
+
+type BulkJobError struct {
+	ID         int32              `json:"id"`
+	JobID      pgtype.UUID        `json:"job_id"`
+	LineNumber int32              `json:"line_number"`
+	RawValues  []string           `json:"raw_values"`
+	Reason     BulkRowErrorReason `json:"reason"`
+	Message    string             `json:"message"`
+	CreatedAt  pgtype.Timestamp   `json:"created_at"`
+}
+
+type FraudCase struct {
+	ID            int32            `json:"id"`
+	TransactionID int32            `json:"transaction_id"`
+	UserID        int32            `json:"user_id"`
+	Status        CaseStatus       `json:"status"`
+	AssignedTo    pgtype.Int4      `json:"assigned_to"`
+	Disposition   NullFraudLabel   `json:"disposition"`
+	Notes         string           `json:"notes"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+	ResolvedAt    pgtype.Timestamp `json:"resolved_at"`
+}
+
+type FraudCaseEvent struct {
+	ID          int32            `json:"id"`
+	CaseID      int32            `json:"case_id"`
+	ActorID     pgtype.Int4      `json:"actor_id"`
+	FromStatus  NullCaseStatus   `json:"from_status"`
+	ToStatus    CaseStatus       `json:"to_status"`
+	AssignedTo  pgtype.Int4      `json:"assigned_to"`
+	Disposition NullFraudLabel   `json:"disposition"`
+	Notes       string           `json:"notes"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type KnownDevice struct {
+	UserID           int32            `json:"user_id"`
+	DeviceID         string           `json:"device_id"`
+	FirstSeenAt      pgtype.Timestamp `json:"first_seen_at"`
+	LastSeenAt       pgtype.Timestamp `json:"last_seen_at"`
+	LastIpAddress    *netip.Addr      `json:"last_ip_address"`
+	LastUserAgent    pgtype.Text      `json:"last_user_agent"`
+	TransactionCount int32            `json:"transaction_count"`
+}
+
+type KnownPayee struct {
+	UserID           int32            `json:"user_id"`
+	PayeeID          string           `json:"payee_id"`
+	FirstSeenAt      pgtype.Timestamp `json:"first_seen_at"`
+	LastSeenAt       pgtype.Timestamp `json:"last_seen_at"`
+	TransactionCount int32            `json:"transaction_count"`
+}
+
+type ListEntry struct {
+	ID        int32            `json:"id"`
+	ListType  ListType         `json:"list_type"`
+	Entity    ListEntity       `json:"entity"`
+	Value     string           `json:"value"`
+	Reason    string           `json:"reason"`
+	CreatedBy pgtype.Int4      `json:"created_by"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	RemovedBy pgtype.Int4      `json:"removed_by"`
+	RemovedAt pgtype.Timestamp `json:"removed_at"`
+}
+
+type Rule struct {
+	ID         int32                   `json:"id"`
+	Name       string                  `json:"name"`
+	Expression string                  `json:"expression"`
+	Action     RuleAction              `json:"action"`
+	Decision   NullTransactionDecision `json:"decision"`
+	ScoreDelta float64                 `json:"score_delta"`
+	Tag        string                  `json:"tag"`
+	Priority   int32                   `json:"priority"`
+	Enabled    bool                    `json:"enabled"`
+	CreatedBy  pgtype.Int4             `json:"created_by"`
+	CreatedAt  pgtype.Timestamp        `json:"created_at"`
+	UpdatedAt  pgtype.Timestamp        `json:"updated_at"`
+}
+
+type ScoringConfig struct {
+	Version     int32            `json:"version"`
+	Description string           `json:"description"`
+	Config      json.RawMessage  `json:"config"`
+	IsActive    bool             `json:"is_active"`
+	CreatedBy   pgtype.Int4      `json:"created_by"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	ActivatedAt pgtype.Timestamp `json:"activated_at"`
+	IsShadow    bool             `json:"is_shadow"`
+}
+
+type ShadowDecision struct {
+	ID                    int32               `json:"id"`
+	TransactionID         int32               `json:"transaction_id"`
+	ChampionConfigVersion pgtype.Int4         `json:"champion_config_version"`
+	ChampionRiskScore     int32               `json:"champion_risk_score"`
+	ChampionDecision      TransactionDecision `json:"champion_decision"`
+	ConfigVersion         int32               `json:"config_version"`
+	RiskScore             int32               `json:"risk_score"`
+	Decision              TransactionDecision `json:"decision"`
+	TriggeredFactors      []string            `json:"triggered_factors"`
+	FactorScores          json.RawMessage     `json:"factor_scores"`
+	LatencyMicros         int32               `json:"latency_micros"`
+	CreatedAt             pgtype.Timestamp    `json:"created_at"`
 }
 
 type Transaction struct {
-	ID                      int32               `json:"id"`
-	UserID                  int32               `json:"user_id"`
-	Amount                  float64             `json:"amount"`
-	Mode                    Mode                `json:"mode"`
-	RiskScore               int32               `json:"risk_score"`
-	TriggeredFactors        []string            `json:"triggered_factors"`
-	Decision                TransactionDecision `json:"decision"`
-	AmountDeviationScore    int32               `json:"amount_deviation_score"`
-	FrequencyDeviationScore int32               `json:"frequency_deviation_score"`
-	ModeDeviationScore      int32               `json:"mode_deviation_score"`
-	TimeDeviationScore      int32               `json:"time_deviation_score"`
-	CreatedAt               pgtype.Timestamp    `json:"created_at"`
-	UpdatedAt               pgtype.Timestamp    `json:"updated_at"`
+	ID                int32               `json:"id"`
+	UserID            int32               `json:"user_id"`
+	Amount            float64             `json:"amount"`
+	Mode              Mode                `json:"mode"`
+	RiskScore         int32               `json:"risk_score"`
+	TriggeredFactors  []string            `json:"triggered_factors"`
+	Decision          TransactionDecision `json:"decision"`
+	CreatedAt         pgtype.Timestamp    `json:"created_at"`
+	UpdatedAt         pgtype.Timestamp    `json:"updated_at"`
+	FactorScores      json.RawMessage     `json:"factor_scores"`
+	ConfigVersion     pgtype.Int4         `json:"config_version"`
+	FraudLabel        NullFraudLabel      `json:"fraud_label"`
+	IsLegitimate      bool                `json:"is_legitimate"`
+	ExternalReference pgtype.Text         `json:"external_reference"`
+	UtcOffsetMinutes  int32               `json:"utc_offset_minutes"`
+	DeviceID          pgtype.Text         `json:"device_id"`
+	IpAddress         *netip.Addr         `json:"ip_address"`
+	UserAgent         pgtype.Text         `json:"user_agent"`
+	Latitude          pgtype.Float8       `json:"latitude"`
+	Longitude         pgtype.Float8       `json:"longitude"`
+	PayeeID           pgtype.Text         `json:"payee_id"`
+	ListEntryID       pgtype.Int4         `json:"list_entry_id"`
+	Explanation       json.RawMessage     `json:"explanation"`
+	MatchedRules      []int32             `json:"matched_rules"`
+	Tags              []string            `json:"tags"`
 }
 
 type User struct {
@@ -165,6 +608,8 @@ type User struct {
 	HashedPass string           `json:"hashed_pass"`
 	CreatedAt  pgtype.Timestamp `json:"created_at"`
 	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
+	Role       UserRole         `json:"role"`
+	Timezone   string           `json:"timezone"`
 }
 
 type UserProfileBehavior struct {
@@ -173,10 +618,13 @@ type UserProfileBehavior struct {
 	MaxTransactionAmountSeen          pgtype.Float8    `json:"max_transaction_amount_seen"`
 	AverageNumberOfTransactionsPerDay pgtype.Int4      `json:"average_number_of_transactions_per_day"`
 	RegisteredPaymentModes            []Mode           `json:"registered_payment_modes"`
-	UsualTransactionStartHour         pgtype.Timestamp `json:"usual_transaction_start_hour"`
-	UsualTransactionEndHour           pgtype.Timestamp `json:"usual_transaction_end_hour"`
 	TotalTransactions                 int32            `json:"total_transactions"`
 	AllowedTransactions               int32            `json:"allowed_transactions"`
 	UpdatedAt                         pgtype.Timestamp `json:"updated_at"`
-	StdDevTransactionAmount           pgtype.Int4      `json:"std_dev_transaction_amount"`
+	StdDevTransactionAmount           pgtype.Float8    `json:"std_dev_transaction_amount"`
+	HourHistogram                     []float64        `json:"hour_histogram"`
+	WeekdayHourHistogram              []float64        `json:"weekday_hour_histogram"`
+	HistogramDecayedAt                pgtype.Timestamp `json:"histogram_decayed_at"`
+	AmountStats                       json.RawMessage  `json:"amount_stats"`
+	ModeAmountStats                   json.RawMessage  `json:"mode_amount_stats"`
 }
@@ -7,29 +7,12 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"net/netip"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const countRecentTransactions = `-- name: CountRecentTransactions :one
-SELECT COUNT(*)
-FROM transactions
-WHERE user_id = $1
-AND created_at > NOW() - make_interval(secs => $2)
-`
-
-type CountRecentTransactionsParams struct {
-	UserID int32   `json:"user_id"`
-	Secs   float64 `json:"secs"`
-}
-
-func (q *Queries) CountRecentTransactions(ctx context.Context, arg CountRecentTransactionsParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countRecentTransactions, arg.UserID, arg.Secs)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
-}
-
 const countTodaysTransactions = `-- name: CountTodaysTransactions :one
 SELECT COUNT(*)
 FROM transactions
@@ -52,50 +35,86 @@ INSERT INTO transactions (
     risk_score,
     triggered_factors,
     decision,
-    amount_deviation_score,
-    frequency_deviation_score,
-    mode_deviation_score,
-    time_deviation_score,
+    factor_scores,
+    config_version,
     created_at,
+    external_reference,
+    utc_offset_minutes,
+    device_id,
+    ip_address,
+    user_agent,
+    latitude,
+    longitude,
+    payee_id,
+    list_entry_id,
+    explanation,
+    matched_rules,
+    tags,
     updated_at
 ) VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5::text[]::trigger_factors[],   
+    $5,
     $6,
     $7,
     $8,
     $9,
     $10,
     $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    $18,
+    $19,
+    $20,
+    $21,
     NOW()
 )
+ON CONFLICT (user_id, external_reference) DO NOTHING
 RETURNING
     id,
     user_id,
     amount,
     mode,
     risk_score,
-    triggered_factors::text[] AS triggered_factors, 
+    triggered_factors,
     decision,
+    config_version,
     created_at,
-    updated_at
+    updated_at,
+    device_id,
+    ip_address,
+    user_agent,
+    payee_id
 `
 
 type CreateTransactionParams struct {
-	UserID                  int32               `json:"user_id"`
-	Amount                  float64             `json:"amount"`
-	Mode                    Mode                `json:"mode"`
-	RiskScore               int32               `json:"risk_score"`
-	Column5                 []string            `json:"column_5"`
-	Decision                TransactionDecision `json:"decision"`
-	AmountDeviationScore    int32               `json:"amount_deviation_score"`
-	FrequencyDeviationScore int32               `json:"frequency_deviation_score"`
-	ModeDeviationScore      int32               `json:"mode_deviation_score"`
-	TimeDeviationScore      int32               `json:"time_deviation_score"`
-	CreatedAt               pgtype.Timestamp    `json:"created_at"`
+	UserID            int32               `json:"user_id"`
+	Amount            float64             `json:"amount"`
+	Mode              Mode                `json:"mode"`
+	RiskScore         int32               `json:"risk_score"`
+	TriggeredFactors  []string            `json:"triggered_factors"`
+	Decision          TransactionDecision `json:"decision"`
+	FactorScores      json.RawMessage     `json:"factor_scores"`
+	ConfigVersion     pgtype.Int4         `json:"config_version"`
+	CreatedAt         pgtype.Timestamp    `json:"created_at"`
+	ExternalReference pgtype.Text         `json:"external_reference"`
+	UtcOffsetMinutes  int32               `json:"utc_offset_minutes"`
+	DeviceID          pgtype.Text         `json:"device_id"`
+	IpAddress         *netip.Addr         `json:"ip_address"`
+	UserAgent         pgtype.Text         `json:"user_agent"`
+	Latitude          pgtype.Float8       `json:"latitude"`
+	Longitude         pgtype.Float8       `json:"longitude"`
+	PayeeID           pgtype.Text         `json:"payee_id"`
+	ListEntryID       pgtype.Int4         `json:"list_entry_id"`
+	Explanation       json.RawMessage     `json:"explanation"`
+	MatchedRules      []int32             `json:"matched_rules"`
+	Tags              []string            `json:"tags"`
 }
 
 type CreateTransactionRow struct {
@@ -106,8 +125,13 @@ type CreateTransactionRow struct {
 	RiskScore        int32               `json:"risk_score"`
 	TriggeredFactors []string            `json:"triggered_factors"`
 	Decision         TransactionDecision `json:"decision"`
+	ConfigVersion    pgtype.Int4         `json:"config_version"`
 	CreatedAt        pgtype.Timestamp    `json:"created_at"`
 	UpdatedAt        pgtype.Timestamp    `json:"updated_at"`
+	DeviceID         pgtype.Text         `json:"device_id"`
+	IpAddress        *netip.Addr         `json:"ip_address"`
+	UserAgent        pgtype.Text         `json:"user_agent"`
+	PayeeID          pgtype.Text         `json:"payee_id"`
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (CreateTransactionRow, error) {
@@ -116,13 +140,23 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		arg.Amount,
 		arg.Mode,
 		arg.RiskScore,
-		arg.Column5,
+		arg.TriggeredFactors,
 		arg.Decision,
-		arg.AmountDeviationScore,
-		arg.FrequencyDeviationScore,
-		arg.ModeDeviationScore,
-		arg.TimeDeviationScore,
+		arg.FactorScores,
+		arg.ConfigVersion,
 		arg.CreatedAt,
+		arg.ExternalReference,
+		arg.UtcOffsetMinutes,
+		arg.DeviceID,
+		arg.IpAddress,
+		arg.UserAgent,
+		arg.Latitude,
+		arg.Longitude,
+		arg.PayeeID,
+		arg.ListEntryID,
+		arg.Explanation,
+		arg.MatchedRules,
+		arg.Tags,
 	)
 	var i CreateTransactionRow
 	err := row.Scan(
@@ -133,14 +167,19 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		&i.RiskScore,
 		&i.TriggeredFactors,
 		&i.Decision,
+		&i.ConfigVersion,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeviceID,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.PayeeID,
 	)
 	return i, err
 }
 
 const getAllTransactionsByUserID = `-- name: GetAllTransactionsByUserID :many
-SELECT id, user_id, amount, mode, risk_score, triggered_factors, decision, amount_deviation_score, frequency_deviation_score, mode_deviation_score, time_deviation_score, created_at, updated_at FROM transactions
+SELECT id, user_id, amount, mode, risk_score, triggered_factors, decision, created_at, updated_at, factor_scores, config_version, fraud_label, is_legitimate, external_reference, utc_offset_minutes, device_id, ip_address, user_agent, latitude, longitude, payee_id, list_entry_id, explanation, matched_rules, tags FROM transactions
 WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
@@ -169,12 +208,24 @@ func (q *Queries) GetAllTransactionsByUserID(ctx context.Context, arg GetAllTran
 			&i.RiskScore,
 			&i.TriggeredFactors,
 			&i.Decision,
-			&i.AmountDeviationScore,
-			&i.FrequencyDeviationScore,
-			&i.ModeDeviationScore,
-			&i.TimeDeviationScore,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.FactorScores,
+			&i.ConfigVersion,
+			&i.FraudLabel,
+			&i.IsLegitimate,
+			&i.ExternalReference,
+			&i.UtcOffsetMinutes,
+			&i.DeviceID,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PayeeID,
+			&i.ListEntryID,
+			&i.Explanation,
+			&i.MatchedRules,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -216,8 +267,113 @@ func (q *Queries) GetDailyTransactionStats(ctx context.Context, userID int32) (G
 	return i, err
 }
 
+const getLastLocatedTransaction = `-- name: GetLastLocatedTransaction :one
+SELECT latitude::DOUBLE PRECISION AS latitude, longitude::DOUBLE PRECISION AS longitude, created_at
+FROM transactions
+WHERE user_id = $1
+AND latitude IS NOT NULL
+AND longitude IS NOT NULL
+AND is_legitimate
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetLastLocatedTransactionRow struct {
+	Latitude  float64          `json:"latitude"`
+	Longitude float64          `json:"longitude"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) GetLastLocatedTransaction(ctx context.Context, userID int32) (GetLastLocatedTransactionRow, error) {
+	row := q.db.QueryRow(ctx, getLastLocatedTransaction, userID)
+	var i GetLastLocatedTransactionRow
+	err := row.Scan(&i.Latitude, &i.Longitude, &i.CreatedAt)
+	return i, err
+}
+
+const getTransactionByExternalReference = `-- name: GetTransactionByExternalReference :one
+SELECT id, user_id, amount, mode, risk_score, triggered_factors, decision, created_at, updated_at, factor_scores, config_version, fraud_label, is_legitimate, external_reference, utc_offset_minutes, device_id, ip_address, user_agent, latitude, longitude, payee_id, list_entry_id, explanation, matched_rules, tags FROM transactions
+WHERE user_id = $1 AND external_reference = $2
+`
+
+type GetTransactionByExternalReferenceParams struct {
+	UserID            int32       `json:"user_id"`
+	ExternalReference pgtype.Text `json:"external_reference"`
+}
+
+func (q *Queries) GetTransactionByExternalReference(ctx context.Context, arg GetTransactionByExternalReferenceParams) (Transaction, error) {
+	row := q.db.QueryRow(ctx, getTransactionByExternalReference, arg.UserID, arg.ExternalReference)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Amount,
+		&i.Mode,
+		&i.RiskScore,
+		&i.TriggeredFactors,
+		&i.Decision,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FactorScores,
+		&i.ConfigVersion,
+		&i.FraudLabel,
+		&i.IsLegitimate,
+		&i.ExternalReference,
+		&i.UtcOffsetMinutes,
+		&i.DeviceID,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.Latitude,
+		&i.Longitude,
+		&i.PayeeID,
+		&i.ListEntryID,
+		&i.Explanation,
+		&i.MatchedRules,
+		&i.Tags,
+	)
+	return i, err
+}
+
+const getTransactionByID = `-- name: GetTransactionByID :one
+SELECT id, user_id, amount, mode, risk_score, triggered_factors, decision, created_at, updated_at, factor_scores, config_version, fraud_label, is_legitimate, external_reference, utc_offset_minutes, device_id, ip_address, user_agent, latitude, longitude, payee_id, list_entry_id, explanation, matched_rules, tags FROM transactions
+WHERE id = $1
+`
+
+func (q *Queries) GetTransactionByID(ctx context.Context, id int32) (Transaction, error) {
+	row := q.db.QueryRow(ctx, getTransactionByID, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Amount,
+		&i.Mode,
+		&i.RiskScore,
+		&i.TriggeredFactors,
+		&i.Decision,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FactorScores,
+		&i.ConfigVersion,
+		&i.FraudLabel,
+		&i.IsLegitimate,
+		&i.ExternalReference,
+		&i.UtcOffsetMinutes,
+		&i.DeviceID,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.Latitude,
+		&i.Longitude,
+		&i.PayeeID,
+		&i.ListEntryID,
+		&i.Explanation,
+		&i.MatchedRules,
+		&i.Tags,
+	)
+	return i, err
+}
+
 const getTransactionByTxnID = `-- name: GetTransactionByTxnID :one
-SELECT id, user_id, amount, mode, risk_score, triggered_factors, decision, amount_deviation_score, frequency_deviation_score, mode_deviation_score, time_deviation_score, created_at, updated_at FROM transactions
+SELECT id, user_id, amount, mode, risk_score, triggered_factors, decision, created_at, updated_at, factor_scores, config_version, fraud_label, is_legitimate, external_reference, utc_offset_minutes, device_id, ip_address, user_agent, latitude, longitude, payee_id, list_entry_id, explanation, matched_rules, tags FROM transactions
 WHERE id = $1 AND user_id = $2
 `
 
@@ -237,12 +393,192 @@ func (q *Queries) GetTransactionByTxnID(ctx context.Context, arg GetTransactionB
 		&i.RiskScore,
 		&i.TriggeredFactors,
 		&i.Decision,
-		&i.AmountDeviationScore,
-		&i.FrequencyDeviationScore,
-		&i.ModeDeviationScore,
-		&i.TimeDeviationScore,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FactorScores,
+		&i.ConfigVersion,
+		&i.FraudLabel,
+		&i.IsLegitimate,
+		&i.ExternalReference,
+		&i.UtcOffsetMinutes,
+		&i.DeviceID,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.Latitude,
+		&i.Longitude,
+		&i.PayeeID,
+		&i.ListEntryID,
+		&i.Explanation,
+		&i.MatchedRules,
+		&i.Tags,
+	)
+	return i, err
+}
+
+const listTransactionActivity = `-- name: ListTransactionActivity :many
+SELECT id, mode, amount, created_at
+FROM transactions
+WHERE user_id = $1
+AND created_at > $2::timestamp
+AND created_at <= $3::timestamp
+ORDER BY created_at
+`
+
+type ListTransactionActivityParams struct {
+	UserID int32            `json:"user_id"`
+	Since  pgtype.Timestamp `json:"since"`
+	Until  pgtype.Timestamp `json:"until"`
+}
+
+type ListTransactionActivityRow struct {
+	ID        int32            `json:"id"`
+	Mode      Mode             `json:"mode"`
+	Amount    float64          `json:"amount"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) ListTransactionActivity(ctx context.Context, arg ListTransactionActivityParams) ([]ListTransactionActivityRow, error) {
+	rows, err := q.db.Query(ctx, listTransactionActivity, arg.UserID, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTransactionActivityRow
+	for rows.Next() {
+		var i ListTransactionActivityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Mode,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionsForReplay = `-- name: ListTransactionsForReplay :many
+SELECT id, user_id, amount, mode, risk_score, triggered_factors, decision, created_at, updated_at, factor_scores, config_version, fraud_label, is_legitimate, external_reference, utc_offset_minutes, device_id, ip_address, user_agent, latitude, longitude, payee_id, list_entry_id, explanation, matched_rules, tags FROM transactions
+WHERE created_at >= $1::timestamp
+AND created_at < $2::timestamp
+ORDER BY created_at, id
+`
+
+type ListTransactionsForReplayParams struct {
+	Since pgtype.Timestamp `json:"since"`
+	Until pgtype.Timestamp `json:"until"`
+}
+
+// ListTransactionsForReplay returns every transaction created in [since, until) in scoring order
+func (q *Queries) ListTransactionsForReplay(ctx context.Context, arg ListTransactionsForReplayParams) ([]Transaction, error) {
+	rows, err := q.db.Query(ctx, listTransactionsForReplay, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Amount,
+			&i.Mode,
+			&i.RiskScore,
+			&i.TriggeredFactors,
+			&i.Decision,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FactorScores,
+			&i.ConfigVersion,
+			&i.FraudLabel,
+			&i.IsLegitimate,
+			&i.ExternalReference,
+			&i.UtcOffsetMinutes,
+			&i.DeviceID,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.Latitude,
+			&i.Longitude,
+			&i.PayeeID,
+			&i.ListEntryID,
+			&i.Explanation,
+			&i.MatchedRules,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setTransactionFraudLabel = `-- name: SetTransactionFraudLabel :exec
+UPDATE transactions
+SET fraud_label = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetTransactionFraudLabelParams struct {
+	ID         int32          `json:"id"`
+	FraudLabel NullFraudLabel `json:"fraud_label"`
+}
+
+func (q *Queries) SetTransactionFraudLabel(ctx context.Context, arg SetTransactionFraudLabelParams) error {
+	_, err := q.db.Exec(ctx, setTransactionFraudLabel, arg.ID, arg.FraudLabel)
+	return err
+}
+
+const updatePendingMFADecision = `-- name: UpdatePendingMFADecision :one
+UPDATE transactions
+SET decision = $3, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND decision = 'MFA_REQUIRED'
+RETURNING id, user_id, amount, mode, risk_score, triggered_factors, decision, created_at, updated_at, factor_scores, config_version, fraud_label, is_legitimate, external_reference, utc_offset_minutes, device_id, ip_address, user_agent, latitude, longitude, payee_id, list_entry_id, explanation, matched_rules, tags
+`
+
+type UpdatePendingMFADecisionParams struct {
+	ID       int32               `json:"id"`
+	UserID   int32               `json:"user_id"`
+	Decision TransactionDecision `json:"decision"`
+}
+
+func (q *Queries) UpdatePendingMFADecision(ctx context.Context, arg UpdatePendingMFADecisionParams) (Transaction, error) {
+	row := q.db.QueryRow(ctx, updatePendingMFADecision, arg.ID, arg.UserID, arg.Decision)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Amount,
+		&i.Mode,
+		&i.RiskScore,
+		&i.TriggeredFactors,
+		&i.Decision,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FactorScores,
+		&i.ConfigVersion,
+		&i.FraudLabel,
+		&i.IsLegitimate,
+		&i.ExternalReference,
+		&i.UtcOffsetMinutes,
+		&i.DeviceID,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.Latitude,
+		&i.Longitude,
+		&i.PayeeID,
+		&i.ListEntryID,
+		&i.Explanation,
+		&i.MatchedRules,
+		&i.Tags,
 	)
 	return i, err
 }
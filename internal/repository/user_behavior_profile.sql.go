@@ -7,6 +7,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -19,8 +20,11 @@ SELECT
     max_transaction_amount_seen,
     average_number_of_transactions_per_day,
     registered_payment_modes::text[] AS registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
+    hour_histogram,
+    weekday_hour_histogram,
+    histogram_decayed_at,
+    amount_stats,
+    mode_amount_stats,
     total_transactions,
     allowed_transactions,
     updated_at
@@ -31,12 +35,15 @@ WHERE user_id = $1
 type GetUserProfileByUserIDRow struct {
 	UserID                            int32            `json:"user_id"`
 	AverageTransactionAmount          pgtype.Float8    `json:"average_transaction_amount"`
-	StdDevTransactionAmount           pgtype.Int4      `json:"std_dev_transaction_amount"`
+	StdDevTransactionAmount           pgtype.Float8    `json:"std_dev_transaction_amount"`
 	MaxTransactionAmountSeen          pgtype.Float8    `json:"max_transaction_amount_seen"`
 	AverageNumberOfTransactionsPerDay pgtype.Int4      `json:"average_number_of_transactions_per_day"`
 	RegisteredPaymentModes            []string         `json:"registered_payment_modes"`
-	UsualTransactionStartHour         pgtype.Timestamp `json:"usual_transaction_start_hour"`
-	UsualTransactionEndHour           pgtype.Timestamp `json:"usual_transaction_end_hour"`
+	HourHistogram                     []float64        `json:"hour_histogram"`
+	WeekdayHourHistogram              []float64        `json:"weekday_hour_histogram"`
+	HistogramDecayedAt                pgtype.Timestamp `json:"histogram_decayed_at"`
+	AmountStats                       json.RawMessage  `json:"amount_stats"`
+	ModeAmountStats                   json.RawMessage  `json:"mode_amount_stats"`
 	TotalTransactions                 int32            `json:"total_transactions"`
 	AllowedTransactions               int32            `json:"allowed_transactions"`
 	UpdatedAt                         pgtype.Timestamp `json:"updated_at"`
@@ -52,8 +59,11 @@ func (q *Queries) GetUserProfileByUserID(ctx context.Context, userID int32) (Get
 		&i.MaxTransactionAmountSeen,
 		&i.AverageNumberOfTransactionsPerDay,
 		&i.RegisteredPaymentModes,
-		&i.UsualTransactionStartHour,
-		&i.UsualTransactionEndHour,
+		&i.HourHistogram,
+		&i.WeekdayHourHistogram,
+		&i.HistogramDecayedAt,
+		&i.AmountStats,
+		&i.ModeAmountStats,
 		&i.TotalTransactions,
 		&i.AllowedTransactions,
 		&i.UpdatedAt,
@@ -61,69 +71,100 @@ func (q *Queries) GetUserProfileByUserID(ctx context.Context, userID int32) (Get
 	return i, err
 }
 
-const rebuildAllUserProfiles = `-- name: RebuildAllUserProfiles :exec
-INSERT INTO user_profile_behavior (
+const getUserProfileByUserIDForUpdate = `-- name: GetUserProfileByUserIDForUpdate :one
+SELECT
     user_id,
     average_transaction_amount,
     std_dev_transaction_amount,
     max_transaction_amount_seen,
     average_number_of_transactions_per_day,
-    registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
+    registered_payment_modes::text[] AS registered_payment_modes,
+    hour_histogram,
+    weekday_hour_histogram,
+    histogram_decayed_at,
+    amount_stats,
+    mode_amount_stats,
+    total_transactions,
+    allowed_transactions,
     updated_at
-)
-SELECT
-    t.user_id,
-
-    COALESCE(
-        AVG(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        0
-    )::INTEGER AS average_transaction_amount,
-
-    COALESCE(
-        STDDEV(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        0
-    )::INTEGER AS std_dev_transaction_amount,
-
-    COALESCE(MAX(t.amount), 0)::INTEGER AS max_transaction_amount_seen,
-
-    LEAST(
-        COUNT(*) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        50
-    )::INTEGER AS average_number_of_transactions_per_day,
-
-    COALESCE(
-        (ARRAY_AGG(DISTINCT t.mode) 
-            FILTER (WHERE t.decision IN ('ALLOW', 'FLAG'))),
-        ARRAY[]::mode[]
-    ) AS registered_payment_modes,
+FROM user_profile_behavior
+WHERE user_id = $1
+FOR UPDATE
+`
 
-    MIN(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_start_hour,
+type GetUserProfileByUserIDForUpdateRow struct {
+	UserID                            int32            `json:"user_id"`
+	AverageTransactionAmount          pgtype.Float8    `json:"average_transaction_amount"`
+	StdDevTransactionAmount           pgtype.Float8    `json:"std_dev_transaction_amount"`
+	MaxTransactionAmountSeen          pgtype.Float8    `json:"max_transaction_amount_seen"`
+	AverageNumberOfTransactionsPerDay pgtype.Int4      `json:"average_number_of_transactions_per_day"`
+	RegisteredPaymentModes            []string         `json:"registered_payment_modes"`
+	HourHistogram                     []float64        `json:"hour_histogram"`
+	WeekdayHourHistogram              []float64        `json:"weekday_hour_histogram"`
+	HistogramDecayedAt                pgtype.Timestamp `json:"histogram_decayed_at"`
+	AmountStats                       json.RawMessage  `json:"amount_stats"`
+	ModeAmountStats                   json.RawMessage  `json:"mode_amount_stats"`
+	TotalTransactions                 int32            `json:"total_transactions"`
+	AllowedTransactions               int32            `json:"allowed_transactions"`
+	UpdatedAt                         pgtype.Timestamp `json:"updated_at"`
+}
 
-    MAX(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_end_hour,
+func (q *Queries) GetUserProfileByUserIDForUpdate(ctx context.Context, userID int32) (GetUserProfileByUserIDForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, getUserProfileByUserIDForUpdate, userID)
+	var i GetUserProfileByUserIDForUpdateRow
+	err := row.Scan(
+		&i.UserID,
+		&i.AverageTransactionAmount,
+		&i.StdDevTransactionAmount,
+		&i.MaxTransactionAmountSeen,
+		&i.AverageNumberOfTransactionsPerDay,
+		&i.RegisteredPaymentModes,
+		&i.HourHistogram,
+		&i.WeekdayHourHistogram,
+		&i.HistogramDecayedAt,
+		&i.AmountStats,
+		&i.ModeAmountStats,
+		&i.TotalTransactions,
+		&i.AllowedTransactions,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
 
-    NOW() AS updated_at
+const listProfiledUserIDs = `-- name: ListProfiledUserIDs :many
+SELECT DISTINCT user_id FROM transactions
+ORDER BY user_id
+`
 
-FROM transactions t
-WHERE t.created_at < CURRENT_DATE
-GROUP BY t.user_id
+// users with a transaction history, whose profiles are rebuilt nightly
+func (q *Queries) ListProfiledUserIDs(ctx context.Context) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listProfiledUserIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
 
-ON CONFLICT (user_id) DO UPDATE SET
-    average_transaction_amount = EXCLUDED.average_transaction_amount,
-    std_dev_transaction_amount = EXCLUDED.std_dev_transaction_amount,
-    max_transaction_amount_seen = EXCLUDED.max_transaction_amount_seen,
-    average_number_of_transactions_per_day = EXCLUDED.average_number_of_transactions_per_day,
-    registered_payment_modes = EXCLUDED.registered_payment_modes,
-    usual_transaction_start_hour = EXCLUDED.usual_transaction_start_hour,
-    usual_transaction_end_hour = EXCLUDED.usual_transaction_end_hour,
-    updated_at = EXCLUDED.updated_at
+const lockUserProfile = `-- name: LockUserProfile :exec
+SELECT pg_advisory_xact_lock('user_profile_behavior'::regclass::oid::int, $1::int)
 `
 
-func (q *Queries) RebuildAllUserProfiles(ctx context.Context) error {
-	_, err := q.db.Exec(ctx, rebuildAllUserProfiles)
+// serializes the scoring and profile updates of a user until the end of the
+// transaction; the lock is taken even when the user has no profile row yet
+func (q *Queries) LockUserProfile(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, lockUserProfile, userID)
 	return err
 }
 
@@ -135,8 +176,11 @@ INSERT INTO user_profile_behavior (
     max_transaction_amount_seen,
     average_number_of_transactions_per_day,
     registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
+    hour_histogram,
+    weekday_hour_histogram,
+    histogram_decayed_at,
+    amount_stats,
+    mode_amount_stats,
     total_transactions,
     allowed_transactions,
     updated_at
@@ -145,116 +189,45 @@ SELECT
     u.id AS user_id,
 
     COALESCE(
-        AVG(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
+        AVG(t.amount) FILTER (WHERE t.is_legitimate),
         0
-    )::INTEGER AS average_transaction_amount,
+    ) AS average_transaction_amount,
 
     COALESCE(
-        STDDEV(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
+        STDDEV(t.amount) FILTER (WHERE t.is_legitimate),
         0
-    )::INTEGER AS std_dev_transaction_amount,
+    ) AS std_dev_transaction_amount,
 
-    COALESCE(MAX(t.amount), 0)::INTEGER AS max_transaction_amount_seen,
+    COALESCE(MAX(t.amount), 0) AS max_transaction_amount_seen,
 
     LEAST(
-        COUNT(*) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) / GREATEST(DATE_PART('day', NOW() - MIN(t.created_at)), 1),
+        COUNT(*) FILTER (WHERE t.is_legitimate) / GREATEST(DATE_PART('day', NOW() - MIN(t.created_at)), 1),
         50
     )::INTEGER AS average_number_of_transactions_per_day,
 
     COALESCE(
-        ARRAY_AGG(DISTINCT t.mode) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
+        ARRAY_AGG(DISTINCT t.mode) FILTER (WHERE t.is_legitimate),
         ARRAY[]::mode[]
     ) AS registered_payment_modes,
 
-    MIN(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_start_hour,
+    profile_hour_histogram(u.id, (NOW() AT TIME ZONE 'UTC'), 24) AS hour_histogram,
 
-    MAX(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_end_hour,
+    profile_hour_histogram(u.id, (NOW() AT TIME ZONE 'UTC'), 168) AS weekday_hour_histogram,
 
-    COUNT(t.id) AS total_transactions,
+    (NOW() AT TIME ZONE 'UTC') AS histogram_decayed_at,
 
-    COUNT(t.id) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS allowed_transactions,
+    profile_amount_stats(u.id, NULL, 'infinity') AS amount_stats,
 
-    NOW() AS updated_at
-FROM users u
-LEFT JOIN transactions t
-    ON t.user_id = u.id
-WHERE u.id = $1
-GROUP BY u.id
-
-ON CONFLICT (user_id) DO UPDATE SET
-    average_transaction_amount = EXCLUDED.average_transaction_amount,
-    std_dev_transaction_amount = EXCLUDED.std_dev_transaction_amount,
-    max_transaction_amount_seen = EXCLUDED.max_transaction_amount_seen,
-    average_number_of_transactions_per_day = EXCLUDED.average_number_of_transactions_per_day,
-    registered_payment_modes = EXCLUDED.registered_payment_modes,
-    usual_transaction_start_hour = EXCLUDED.usual_transaction_start_hour,
-    usual_transaction_end_hour = EXCLUDED.usual_transaction_end_hour,
-    total_transactions = EXCLUDED.total_transactions,
-    allowed_transactions = EXCLUDED.allowed_transactions,
-    updated_at = EXCLUDED.updated_at
-`
-
-func (q *Queries) RecalculateUserProfile(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, recalculateUserProfile, id)
-	return err
-}
-
-const upsertUserProfileByUserID = `-- name: UpsertUserProfileByUserID :exec
-INSERT INTO user_profile_behavior (
-    user_id,
-    average_transaction_amount,
-    std_dev_transaction_amount,
-    max_transaction_amount_seen,
-    average_number_of_transactions_per_day,
-    registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
-    total_transactions,
-    allowed_transactions,
-    updated_at
-)
-SELECT
-    u.id AS user_id,
-
-    COALESCE(
-        AVG(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        0
-    )::INTEGER AS average_transaction_amount,
-
-    COALESCE(
-        STDDEV(t.amount) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        0
-    )::INTEGER AS std_dev_transaction_amount,
-
-    COALESCE(MAX(t.amount), 0)::INTEGER AS max_transaction_amount_seen,
-
-    LEAST(
-        COUNT(*) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        50
-    )::INTEGER AS average_number_of_transactions_per_day,
-
-    COALESCE(
-        ARRAY_AGG(DISTINCT t.mode) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')),
-        ARRAY[]::mode[]
-    ) AS registered_payment_modes,
-
-    MIN(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_start_hour,
-
-    MAX(t.created_at)
-        FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS usual_transaction_end_hour,
+    profile_mode_amount_stats(u.id, 'infinity') AS mode_amount_stats,
 
     COUNT(t.id) AS total_transactions,
 
-    COUNT(t.id) FILTER (WHERE t.decision IN ('ALLOW', 'FLAG')) AS allowed_transactions,
+    COUNT(t.id) FILTER (WHERE t.is_legitimate) AS allowed_transactions,
 
     NOW() AS updated_at
 FROM users u
 LEFT JOIN transactions t
     ON t.user_id = u.id
-    AND t.created_at < CURRENT_DATE
 WHERE u.id = $1
 GROUP BY u.id
 
@@ -264,15 +237,18 @@ ON CONFLICT (user_id) DO UPDATE SET
     max_transaction_amount_seen = EXCLUDED.max_transaction_amount_seen,
     average_number_of_transactions_per_day = EXCLUDED.average_number_of_transactions_per_day,
     registered_payment_modes = EXCLUDED.registered_payment_modes,
-    usual_transaction_start_hour = EXCLUDED.usual_transaction_start_hour,
-    usual_transaction_end_hour = EXCLUDED.usual_transaction_end_hour,
+    hour_histogram = EXCLUDED.hour_histogram,
+    weekday_hour_histogram = EXCLUDED.weekday_hour_histogram,
+    histogram_decayed_at = EXCLUDED.histogram_decayed_at,
+    amount_stats = EXCLUDED.amount_stats,
+    mode_amount_stats = EXCLUDED.mode_amount_stats,
     total_transactions = EXCLUDED.total_transactions,
     allowed_transactions = EXCLUDED.allowed_transactions,
     updated_at = EXCLUDED.updated_at
 `
 
-func (q *Queries) UpsertUserProfileByUserID(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, upsertUserProfileByUserID, id)
+func (q *Queries) RecalculateUserProfile(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, recalculateUserProfile, id)
 	return err
 }
 
@@ -284,8 +260,11 @@ INSERT INTO user_profile_behavior (
     max_transaction_amount_seen,
     average_number_of_transactions_per_day,
     registered_payment_modes,
-    usual_transaction_start_hour,
-    usual_transaction_end_hour,
+    hour_histogram,
+    weekday_hour_histogram,
+    histogram_decayed_at,
+    amount_stats,
+    mode_amount_stats,
     total_transactions,
     allowed_transactions,
     updated_at
@@ -296,11 +275,14 @@ VALUES (
     $3,  -- std_dev_transaction_amount
     $4,  -- max_transaction_amount_seen
     $5,  -- average_number_of_transactions_per_day
-    $6,  -- registered_payment_modes
-    $7,  -- usual_transaction_start_hour
-    $8,  -- usual_transaction_end_hour
-    $9,  -- total_transactions
-    $10, -- allowed_transactions
+    $6::text[]::mode[],  -- registered_payment_modes
+    $7,  -- hour_histogram
+    $8,  -- weekday_hour_histogram
+    $9,  -- histogram_decayed_at
+    $10, -- amount_stats
+    $11, -- mode_amount_stats
+    $12, -- total_transactions
+    $13, -- allowed_transactions
     NOW()
 )
 ON CONFLICT (user_id) DO UPDATE SET
@@ -309,8 +291,11 @@ ON CONFLICT (user_id) DO UPDATE SET
     max_transaction_amount_seen = EXCLUDED.max_transaction_amount_seen,
     average_number_of_transactions_per_day = EXCLUDED.average_number_of_transactions_per_day,
     registered_payment_modes = EXCLUDED.registered_payment_modes,
-    usual_transaction_start_hour = EXCLUDED.usual_transaction_start_hour,
-    usual_transaction_end_hour = EXCLUDED.usual_transaction_end_hour,
+    hour_histogram = EXCLUDED.hour_histogram,
+    weekday_hour_histogram = EXCLUDED.weekday_hour_histogram,
+    histogram_decayed_at = EXCLUDED.histogram_decayed_at,
+    amount_stats = EXCLUDED.amount_stats,
+    mode_amount_stats = EXCLUDED.mode_amount_stats,
     total_transactions = EXCLUDED.total_transactions,
     allowed_transactions = EXCLUDED.allowed_transactions,
     updated_at = NOW()
@@ -319,12 +304,15 @@ ON CONFLICT (user_id) DO UPDATE SET
 type UpsertUserProfileFromProfileParams struct {
 	UserID                            int32            `json:"user_id"`
 	AverageTransactionAmount          pgtype.Float8    `json:"average_transaction_amount"`
-	StdDevTransactionAmount           pgtype.Int4      `json:"std_dev_transaction_amount"`
+	StdDevTransactionAmount           pgtype.Float8    `json:"std_dev_transaction_amount"`
 	MaxTransactionAmountSeen          pgtype.Float8    `json:"max_transaction_amount_seen"`
 	AverageNumberOfTransactionsPerDay pgtype.Int4      `json:"average_number_of_transactions_per_day"`
-	RegisteredPaymentModes            []Mode           `json:"registered_payment_modes"`
-	UsualTransactionStartHour         pgtype.Timestamp `json:"usual_transaction_start_hour"`
-	UsualTransactionEndHour           pgtype.Timestamp `json:"usual_transaction_end_hour"`
+	RegisteredPaymentModes            []string         `json:"registered_payment_modes"`
+	HourHistogram                     []float64        `json:"hour_histogram"`
+	WeekdayHourHistogram              []float64        `json:"weekday_hour_histogram"`
+	HistogramDecayedAt                pgtype.Timestamp `json:"histogram_decayed_at"`
+	AmountStats                       json.RawMessage  `json:"amount_stats"`
+	ModeAmountStats                   json.RawMessage  `json:"mode_amount_stats"`
 	TotalTransactions                 int32            `json:"total_transactions"`
 	AllowedTransactions               int32            `json:"allowed_transactions"`
 }
@@ -337,8 +325,11 @@ func (q *Queries) UpsertUserProfileFromProfile(ctx context.Context, arg UpsertUs
 		arg.MaxTransactionAmountSeen,
 		arg.AverageNumberOfTransactionsPerDay,
 		arg.RegisteredPaymentModes,
-		arg.UsualTransactionStartHour,
-		arg.UsualTransactionEndHour,
+		arg.HourHistogram,
+		arg.WeekdayHourHistogram,
+		arg.HistogramDecayedAt,
+		arg.AmountStats,
+		arg.ModeAmountStats,
 		arg.TotalTransactions,
 		arg.AllowedTransactions,
 	)
@@ -10,25 +10,32 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users(name, email, hashed_pass, created_at, updated_at)
+INSERT INTO users(name, email, hashed_pass, timezone, created_at, updated_at)
 VALUES (
     $1,
     $2,
     $3,
+    $4,
     NOW(),
     NOW()
 )
-RETURNING id, name, email, hashed_pass, created_at, updated_at
+RETURNING id, name, email, hashed_pass, created_at, updated_at, role, timezone
 `
 
 type CreateUserParams struct {
 	Name       string `json:"name"`
 	Email      string `json:"email"`
 	HashedPass string `json:"hashed_pass"`
+	Timezone   string `json:"timezone"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.Name, arg.Email, arg.HashedPass)
+	row := q.db.QueryRow(ctx, createUser,
+		arg.Name,
+		arg.Email,
+		arg.HashedPass,
+		arg.Timezone,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -37,12 +44,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.HashedPass,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, hashed_pass, created_at, updated_at FROM users 
+SELECT id, name, email, hashed_pass, created_at, updated_at, role, timezone FROM users 
 WHERE email = $1
 `
 
@@ -56,12 +65,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.HashedPass,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, hashed_pass, created_at, updated_at FROM users
+SELECT id, name, email, hashed_pass, created_at, updated_at, role, timezone FROM users
 WHERE id = $1
 `
 
@@ -75,6 +86,76 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
 		&i.HashedPass,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const getUserTimezone = `-- name: GetUserTimezone :one
+SELECT timezone FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserTimezone(ctx context.Context, id int32) (string, error) {
+	row := q.db.QueryRow(ctx, getUserTimezone, id)
+	var timezone string
+	err := row.Scan(&timezone)
+	return timezone, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, email, hashed_pass, created_at, updated_at, role, timezone
+`
+
+type UpdateUserRoleParams struct {
+	ID   int32    `json:"id"`
+	Role UserRole `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.HashedPass,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const updateUserTimezone = `-- name: UpdateUserTimezone :one
+UPDATE users
+SET timezone = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, email, hashed_pass, created_at, updated_at, role, timezone
+`
+
+type UpdateUserTimezoneParams struct {
+	ID       int32  `json:"id"`
+	Timezone string `json:"timezone"`
+}
+
+func (q *Queries) UpdateUserTimezone(ctx context.Context, arg UpdateUserTimezoneParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserTimezone, arg.ID, arg.Timezone)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.HashedPass,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.Timezone,
 	)
 	return i, err
 }
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/velocity"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// ProcessBulkTransactions validates an uploaded CSV/XLSX file and stores it as
+// a PENDING bulk job. The rows are scored in the background by the job workers
+// (see ProcessNextBulkJob); progress is read back with GetBulkJob. Strict jobs
+// reject rows with an invalid created_at instead of defaulting it.
+func (s *TransactionService) ProcessBulkTransactions(ctx context.Context, userID int32, reader io.Reader, filename string, strict bool) (specs.BulkJobResponse, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return specs.BulkJobResponse{}, err
+	}
+
+	total, err := countBulkRows(data, filename)
+	if err != nil {
+		return specs.BulkJobResponse{}, err
+	}
+
+	jobID := uuid.New()
+	err = s.queries.CreateBulkJob(ctx, repository.CreateBulkJobParams{
+		ID:        pgtype.UUID{Bytes: jobID, Valid: true},
+		UserID:    userID,
+		Filename:  filename,
+		File:      data,
+		TotalRows: total,
+		Strict:    strict,
+	})
+	if err != nil {
+		return specs.BulkJobResponse{}, err
+	}
+
+	return s.GetBulkJob(ctx, userID, jobID.String())
+}
+
+// GetBulkJob reports the status and progress of one of the user's bulk jobs
+func (s *TransactionService) GetBulkJob(ctx context.Context, userID int32, jobID string) (specs.BulkJobResponse, error) {
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		return specs.BulkJobResponse{}, pkgerrors.ErrBulkJobNotFound
+	}
+
+	job, err := s.queries.GetBulkJobByID(ctx, repository.GetBulkJobByIDParams{
+		ID:     pgtype.UUID{Bytes: id, Valid: true},
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.BulkJobResponse{}, pkgerrors.ErrBulkJobNotFound
+		}
+		return specs.BulkJobResponse{}, err
+	}
+
+	return mapBulkJobToResponse(job), nil
+}
+
+// ListBulkJobErrors returns the rows rejected by one of the user's bulk jobs,
+// in file order
+func (s *TransactionService) ListBulkJobErrors(ctx context.Context, userID int32, jobID string) ([]specs.BulkRowErrorResponse, error) {
+	job, err := s.GetBulkJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.queries.ListBulkJobErrors(ctx, pgtype.UUID{Bytes: uuid.MustParse(job.JobID), Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []specs.BulkRowErrorResponse{}
+	for _, row := range rows {
+		res = append(res, specs.BulkRowErrorResponse{
+			Line:    row.LineNumber,
+			Values:  row.RawValues,
+			Reason:  row.Reason,
+			Message: row.Message,
+		})
+	}
+	return res, nil
+}
+
+// ProcessNextBulkJob claims the oldest pending job, or a PROCESSING job whose
+// worker stopped sending heartbeats, and runs it to completion. A job that
+// still fails after BulkJobMaxAttempts claims is marked FAILED instead of
+// being reclaimed again. It reports false when there was no job to claim.
+func (s *TransactionService) ProcessNextBulkJob(ctx context.Context) (bool, error) {
+	job, err := s.queries.ClaimBulkJob(ctx, int32(constants.BulkJobStaleAfter.Seconds()))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	jobID := uuid.UUID(job.ID.Bytes).String()
+	if job.Attempts > constants.BulkJobMaxAttempts {
+		// the last attempt was interrupted before it could fail the job
+		s.logger.Error("giving up bulk job", zap.String("job_id", jobID), zap.Int32("attempts", job.Attempts-1))
+		return true, s.queries.FailBulkJob(ctx, repository.FailBulkJobParams{
+			ID:    job.ID,
+			Error: pgtype.Text{String: pkgerrors.ErrBulkJobAttemptsExceeded.Error(), Valid: true},
+		})
+	}
+
+	s.logger.Info("processing bulk job",
+		zap.String("job_id", jobID),
+		zap.Int32("user_id", job.UserID),
+		zap.Int32("resume_from_row", job.ProcessedRows),
+		zap.Int32("attempt", job.Attempts),
+	)
+	err = s.runBulkJob(ctx, job)
+	if err != nil && ctx.Err() == nil && job.Attempts >= constants.BulkJobMaxAttempts {
+		s.logger.Error("giving up bulk job", zap.String("job_id", jobID), zap.Int32("attempts", job.Attempts), zap.Error(err))
+		return true, s.queries.FailBulkJob(ctx, repository.FailBulkJobParams{
+			ID:    job.ID,
+			Error: pgtype.Text{String: fmt.Sprintf("%s: %s", pkgerrors.ErrBulkJobAttemptsExceeded, err), Valid: true},
+		})
+	}
+	return true, err
+}
+
+// bulkRow is a data row of an uploaded file. line is its position in the
+// file, with the header as line 1.
+type bulkRow struct {
+	line    int32
+	record  []string
+	readErr error
+}
+
+// runBulkJob replays the rows of a claimed job in created_at order, scoring
+// each one against the profile built from the rows before it, as if it had
+// arrived live. Each row is stored in the same DB transaction that updates the
+// profile and advances the job's cursor, so an interrupted job resumes at the
+// first row that was not stored.
+func (s *TransactionService) runBulkJob(ctx context.Context, job repository.BulkJob) error {
+	rows, err := readBulkRows(job.File, job.Filename)
+	if err != nil {
+		return s.queries.FailBulkJob(ctx, repository.FailBulkJobParams{
+			ID:    job.ID,
+			Error: pgtype.Text{String: err.Error(), Valid: true},
+		})
+	}
+
+	profile := s.loadBulkProfile(ctx, job.UserID)
+	cfg := s.configs.ActiveConfig(ctx)
+	compiled := s.rules.ActiveRules(ctx)
+	loc := s.userLocation(ctx, job.UserID)
+	replay := velocity.NewReplay()
+	// rows carry no payee, device or IP address, so only the user can match
+	listEntry := s.lists.MatchEntry(ctx, job.UserID, specs.CreateTransactionRequest{})
+
+	// rows stored before the job was interrupted are already in the profile,
+	// but still count towards the velocity windows. Rejected rows never
+	// counted and are skipped. Rows without a valid created_at were stored at
+	// processing time and sort last, so they are left out too.
+	done := min(int(job.ProcessedRows), len(rows))
+	rejected := map[int32]bool{}
+	if done > 0 {
+		rowErrors, err := s.queries.ListBulkJobErrors(ctx, job.ID)
+		if err != nil {
+			return err
+		}
+		for _, e := range rowErrors {
+			rejected[e.LineNumber] = true
+		}
+	}
+	for _, row := range rows[:done] {
+		if row.readErr != nil || rejected[row.line] {
+			continue
+		}
+		if bulkReq, err := helpers.ParseTransactionCSVRow(row.record, true); err == nil {
+			replay.Add(velocity.Event{
+				Mode:      repository.Mode(bulkReq.Mode),
+				Amount:    bulkReq.Amount,
+				CreatedAt: bulkReq.CreatedAt,
+			})
+		}
+	}
+
+	for _, row := range rows[done:] {
+		if err := s.processBulkRow(ctx, job, row, cfg, compiled, listEntry, loc, profile, replay); err != nil {
+			return err
+		}
+	}
+
+	// refresh the robust amount statistics, which are only computed by a rebuild
+	if err := s.RebuildUserProfile(ctx, job.UserID); err != nil {
+		s.logger.Error("failed to recalculate user profile", zap.Error(err))
+	}
+
+	return s.queries.CompleteBulkJob(ctx, job.ID)
+}
+
+// processBulkRow scores and stores a single row, or decides it by the user's
+// list entry, then picks up the updated profile and applies the row to the
+// velocity windows. The row is scored in its own UTC
+// offset, or in loc when its timestamp is in UTC. Rows that cannot be parsed or
+// stored are recorded in bulk_job_errors; only errors that prevent recording
+// progress are returned, leaving the job to be resumed later.
+func (s *TransactionService) processBulkRow(ctx context.Context, job repository.BulkJob, row bulkRow, cfg *specs.ScoringConfig, compiled []specs.CompiledRule, listEntry *specs.ListEntryResponse, loc *time.Location, profile *repository.UserProfileBehavior, replay *velocity.Replay) error {
+	if row.readErr != nil {
+		return s.rejectBulkRow(ctx, job, row, repository.BulkRowErrorReasonMALFORMEDROW, row.readErr.Error())
+	}
+
+	bulkReq, err := helpers.ParseTransactionCSVRow(row.record, job.Strict)
+	if err != nil {
+		return s.rejectBulkRow(ctx, job, row, bulkRowErrorReason(err), err.Error())
+	}
+	bulkReq.CreatedAt = helpers.BulkRowLocalTime(bulkReq.CreatedAt, loc)
+
+	stats := replay.Snapshot(repository.Mode(bulkReq.Mode), bulkReq.CreatedAt)
+	result := helpers.AnalyzeBulkTransactions(ctx, cfg, compiled, &bulkReq, profile, stats, listEntry)
+
+	factorScores, err := json.Marshal(result.FactorScores())
+	if err != nil {
+		return s.rejectBulkRow(ctx, job, row, repository.BulkRowErrorReasonINSERTFAILED, err.Error())
+	}
+	explanation, err := json.Marshal(result.Explain())
+	if err != nil {
+		return s.rejectBulkRow(ctx, job, row, repository.BulkRowErrorReasonINSERTFAILED, err.Error())
+	}
+
+	txn, updated, err := s.storeBulkRow(ctx, job, repository.CreateTransactionParams{
+		UserID:            job.UserID,
+		Amount:            bulkReq.Amount,
+		Mode:              repository.Mode(bulkReq.Mode),
+		RiskScore:         result.FinalRiskScore,
+		TriggeredFactors:  result.TriggeredFactors,
+		Decision:          result.Decision,
+		FactorScores:      factorScores,
+		ConfigVersion:     configVersionParam(result.ConfigVersion),
+		CreatedAt:         pgtype.Timestamp{Time: bulkReq.CreatedAt.UTC(), Valid: true},
+		ExternalReference: pgtype.Text{String: bulkReq.ExternalReference, Valid: bulkReq.ExternalReference != ""},
+		UtcOffsetMinutes:  helpers.UTCOffsetMinutes(bulkReq.CreatedAt),
+		ListEntryID:       listEntryParam(result.ListEntry),
+		Explanation:       explanation,
+		MatchedRules:      result.MatchedRuleIDs(),
+		Tags:              result.Tags,
+	}, bulkReq.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// the external_reference is already stored for this user
+			return s.rejectBulkRow(ctx, job, row, repository.BulkRowErrorReasonDUPLICATEREFERENCE, pkgerrors.ErrDuplicateReference.Error())
+		}
+		s.logger.Error("failed to create bulk transaction", zap.Error(err))
+		return s.rejectBulkRow(ctx, job, row, repository.BulkRowErrorReasonINSERTFAILED, "failed to store transaction")
+	}
+
+	*profile = *updated
+	event := velocity.Event{
+		TxnID:     txn.ID,
+		Mode:      txn.Mode,
+		Amount:    txn.Amount,
+		CreatedAt: bulkReq.CreatedAt,
+	}
+	replay.Add(event)
+	s.recordVelocity(ctx, job.UserID, event)
+
+	if needsReview(txn.Decision) {
+		openFraudCase(ctx, s.queries, s.logger, job.UserID, txn.ID, reviewNotes(result))
+	}
+	return nil
+}
+
+// storeBulkRow inserts the transaction, applies it to the profile and advances
+// the job cursor atomically. It returns the updated profile, which also holds
+// any live transactions stored while the job runs.
+func (s *TransactionService) storeBulkRow(ctx context.Context, job repository.BulkJob, params repository.CreateTransactionParams, createdAt time.Time) (repository.CreateTransactionRow, *repository.UserProfileBehavior, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return repository.CreateTransactionRow{}, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.LockUserProfile(ctx, job.UserID); err != nil {
+		return repository.CreateTransactionRow{}, nil, err
+	}
+
+	txn, err := qtx.CreateTransaction(ctx, params)
+	if err != nil {
+		return repository.CreateTransactionRow{}, nil, err
+	}
+
+	profile, err := applyToProfile(ctx, qtx, txn, createdAt)
+	if err != nil {
+		return repository.CreateTransactionRow{}, nil, err
+	}
+
+	err = qtx.AdvanceBulkJob(ctx, repository.AdvanceBulkJobParams{ID: job.ID, Success: 1})
+	if err != nil {
+		return repository.CreateTransactionRow{}, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.CreateTransactionRow{}, nil, err
+	}
+	return txn, profile, nil
+}
+
+// rejectBulkRow records why a row failed and advances the job cursor atomically
+func (s *TransactionService) rejectBulkRow(ctx context.Context, job repository.BulkJob, row bulkRow, reason repository.BulkRowErrorReason, message string) error {
+	record := row.record
+	if record == nil {
+		record = []string{}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+	err = qtx.CreateBulkJobError(ctx, repository.CreateBulkJobErrorParams{
+		JobID:      job.ID,
+		LineNumber: row.line,
+		RawValues:  record,
+		Reason:     reason,
+		Message:    message,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = qtx.AdvanceBulkJob(ctx, repository.AdvanceBulkJobParams{ID: job.ID, Failed: 1})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// bulkRowErrorReason maps a row parsing error to the reason stored for the row
+func bulkRowErrorReason(err error) repository.BulkRowErrorReason {
+	switch {
+	case errors.Is(err, pkgerrors.ErrBulkRowInvalidAmount):
+		return repository.BulkRowErrorReasonINVALIDAMOUNT
+	case errors.Is(err, pkgerrors.ErrInvalidPaymentMode):
+		return repository.BulkRowErrorReasonINVALIDMODE
+	case errors.Is(err, pkgerrors.ErrBulkRowInvalidTimestamp):
+		return repository.BulkRowErrorReasonINVALIDTIMESTAMP
+	default:
+		return repository.BulkRowErrorReasonMALFORMEDROW
+	}
+}
+
+// jsonObject defaults the JSONB statistics of a profile that was never
+// rebuilt to an empty object
+func jsonObject(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage("{}")
+	}
+	return raw
+}
+
+// loadBulkProfile reads the user's current profile, falling back to an empty
+// one for users without history
+func (s *TransactionService) loadBulkProfile(ctx context.Context, userID int32) *repository.UserProfileBehavior {
+	profile, err := s.queries.GetUserProfileByUserID(ctx, userID)
+	if err != nil {
+		profile = repository.GetUserProfileByUserIDRow{UserID: userID}
+	}
+
+	domainProfile := &repository.UserProfileBehavior{
+		UserID:                            profile.UserID,
+		AverageTransactionAmount:          profile.AverageTransactionAmount,
+		StdDevTransactionAmount:           profile.StdDevTransactionAmount,
+		MaxTransactionAmountSeen:          profile.MaxTransactionAmountSeen,
+		AverageNumberOfTransactionsPerDay: profile.AverageNumberOfTransactionsPerDay,
+		HourHistogram:                     profile.HourHistogram,
+		WeekdayHourHistogram:              profile.WeekdayHourHistogram,
+		HistogramDecayedAt:                profile.HistogramDecayedAt,
+		AmountStats:                       profile.AmountStats,
+		ModeAmountStats:                   profile.ModeAmountStats,
+		TotalTransactions:                 profile.TotalTransactions,
+		AllowedTransactions:               profile.AllowedTransactions,
+		UpdatedAt:                         profile.UpdatedAt,
+	}
+	for _, m := range profile.RegisteredPaymentModes {
+		domainProfile.RegisteredPaymentModes = append(domainProfile.RegisteredPaymentModes, repository.Mode(m))
+	}
+	return domainProfile
+}
+
+// countBulkRows validates the file header and counts its data rows
+func countBulkRows(data []byte, filename string) (int32, error) {
+	rows, err := readBulkRows(data, filename)
+	if err != nil {
+		return 0, err
+	}
+	return int32(len(rows)), nil
+}
+
+// readBulkRows reads every data row of a file and sorts them by created_at so
+// they can be replayed in order. Rows without a valid created_at go last in
+// file order; the order is deterministic, so the job cursor stays valid across
+// restarts.
+func readBulkRows(data []byte, filename string) ([]bulkRow, error) {
+	next, closeFunc, err := helpers.OpenBulkFile(bytes.NewReader(data), filename)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	rows := []bulkRow{}
+	// the header is line 1
+	line := int32(1)
+	for {
+		record, err := next()
+		if err == io.EOF {
+			break
+		}
+		line++
+		rows = append(rows, bulkRow{line: line, record: record, readErr: err})
+	}
+
+	slices.SortStableFunc(rows, func(a, b bulkRow) int {
+		aTime, aOK := helpers.BulkRowTimestamp(a.record)
+		bTime, bOK := helpers.BulkRowTimestamp(b.record)
+		switch {
+		case aOK && bOK:
+			return aTime.Compare(bTime)
+		case aOK:
+			return -1
+		case bOK:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return rows, nil
+}
+
+func mapBulkJobToResponse(job repository.GetBulkJobByIDRow) specs.BulkJobResponse {
+	res := specs.BulkJobResponse{
+		JobID:    uuid.UUID(job.ID.Bytes).String(),
+		Filename: job.Filename,
+		Status:   job.Status,
+		Strict:   job.Strict,
+		Progress: specs.BulkJobProgress{
+			Total:     job.TotalRows,
+			Processed: job.ProcessedRows,
+			Success:   job.SuccessRows,
+			Failed:    job.FailedRows,
+		},
+		Error:     job.Error.String,
+		CreatedAt: job.CreatedAt.Time,
+	}
+	if job.TotalRows > 0 {
+		res.Progress.Percent = job.ProcessedRows * 100 / job.TotalRows
+	} else if job.Status == repository.BulkJobStatusCOMPLETED {
+		res.Progress.Percent = 100
+	}
+	if job.StartedAt.Valid {
+		res.StartedAt = &job.StartedAt.Time
+	}
+	if job.FinishedAt.Valid {
+		res.FinishedAt = &job.FinishedAt.Time
+	}
+	return res
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// CaseService runs the analyst review queue for FLAG and BLOCK transactions.
+// Every state change is recorded in fraud_case_events.
+type CaseService struct {
+	queries *repository.Queries
+	db      *pgxpool.Pool
+	logger  *zap.Logger
+}
+
+func NewCaseService(queries *repository.Queries, db *pgxpool.Pool, logger *zap.Logger) *CaseService {
+	return &CaseService{
+		queries: queries,
+		db:      db,
+		logger:  logger,
+	}
+}
+
+// ListCases returns the cases matching the filter, oldest first
+func (s *CaseService) ListCases(ctx context.Context, req specs.ListFraudCasesRequest) ([]specs.FraudCaseResponse, error) {
+	params := repository.ListFraudCasesParams{
+		Status: repository.NullCaseStatus{
+			CaseStatus: repository.CaseStatus(req.Status),
+			Valid:      req.Status != "",
+		},
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+	if req.AssignedTo != nil {
+		params.AssignedTo = pgtype.Int4{Int32: *req.AssignedTo, Valid: true}
+	}
+
+	cases, err := s.queries.ListFraudCases(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []specs.FraudCaseResponse{}
+	for _, c := range cases {
+		res = append(res, mapFraudCaseToResponse(c))
+	}
+	return res, nil
+}
+
+// GetCase returns a case together with its transaction and full history
+func (s *CaseService) GetCase(ctx context.Context, caseID int32) (specs.FraudCaseDetailResponse, error) {
+	c, err := s.queries.GetFraudCaseByID(ctx, caseID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.FraudCaseDetailResponse{}, pkgerrors.ErrCaseNotFound
+		}
+		return specs.FraudCaseDetailResponse{}, err
+	}
+
+	txn, err := s.queries.GetTransactionByID(ctx, c.TransactionID)
+	if err != nil {
+		return specs.FraudCaseDetailResponse{}, err
+	}
+
+	events, err := s.queries.ListFraudCaseEvents(ctx, caseID)
+	if err != nil {
+		return specs.FraudCaseDetailResponse{}, err
+	}
+
+	res := specs.FraudCaseDetailResponse{
+		FraudCaseResponse: mapFraudCaseToResponse(c),
+		Transaction:       txn,
+		Events:            []specs.FraudCaseEventResponse{},
+	}
+	for _, e := range events {
+		res.Events = append(res.Events, mapFraudCaseEventToResponse(e))
+	}
+	return res, nil
+}
+
+// AssignCase hands an unresolved case to an analyst or admin
+func (s *CaseService) AssignCase(ctx context.Context, actorID int32, caseID int32, req specs.AssignFraudCaseRequest) (specs.FraudCaseResponse, error) {
+	analystID := req.AnalystID
+	if analystID == 0 {
+		analystID = actorID
+	}
+
+	analyst, err := s.queries.GetUserByID(ctx, analystID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.FraudCaseResponse{}, pkgerrors.ErrUserNotFound
+		}
+		return specs.FraudCaseResponse{}, err
+	}
+	if !helpers.CanAccessAllUsers(analyst.Role) {
+		return specs.FraudCaseResponse{}, pkgerrors.ErrInvalidAssignee
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+	current, err := lockOpenFraudCase(ctx, qtx, caseID)
+	if err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	assignee := pgtype.Int4{Int32: analystID, Valid: true}
+	updated, err := qtx.AssignFraudCase(ctx, repository.AssignFraudCaseParams{
+		ID:         caseID,
+		AssignedTo: assignee,
+	})
+	if err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	err = qtx.CreateFraudCaseEvent(ctx, repository.CreateFraudCaseEventParams{
+		CaseID:     caseID,
+		ActorID:    pgtype.Int4{Int32: actorID, Valid: true},
+		FromStatus: repository.NullCaseStatus{CaseStatus: current.Status, Valid: true},
+		ToStatus:   updated.Status,
+		AssignedTo: assignee,
+		Notes:      req.Notes,
+	})
+	if err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	return mapFraudCaseToResponse(updated), nil
+}
+
+// ResolveCase records the analyst's disposition, labels the transaction with it
+// and rebuilds the user's profile so confirmed fraud stops counting as normal
+// behaviour and cleared false positives start counting. The device of confirmed
+// fraud is no longer known.
+func (s *CaseService) ResolveCase(ctx context.Context, actorID int32, caseID int32, req specs.ResolveFraudCaseRequest) (specs.FraudCaseResponse, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+	current, err := lockOpenFraudCase(ctx, qtx, caseID)
+	if err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	label := repository.NullFraudLabel{FraudLabel: repository.FraudLabel(req.Disposition), Valid: true}
+	updated, err := qtx.ResolveFraudCase(ctx, repository.ResolveFraudCaseParams{
+		ID:          caseID,
+		Disposition: label,
+		Notes:       req.Notes,
+	})
+	if err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	err = qtx.CreateFraudCaseEvent(ctx, repository.CreateFraudCaseEventParams{
+		CaseID:      caseID,
+		ActorID:     pgtype.Int4{Int32: actorID, Valid: true},
+		FromStatus:  repository.NullCaseStatus{CaseStatus: current.Status, Valid: true},
+		ToStatus:    updated.Status,
+		AssignedTo:  updated.AssignedTo,
+		Disposition: label,
+		Notes:       req.Notes,
+	})
+	if err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	err = qtx.SetTransactionFraudLabel(ctx, repository.SetTransactionFraudLabelParams{
+		ID:         updated.TransactionID,
+		FraudLabel: label,
+	})
+	if err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	// rebuilt under the user's lock, so no transaction being stored is left out
+	if err := qtx.LockUserProfile(ctx, updated.UserID); err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	if err := qtx.RecalculateUserProfile(ctx, updated.UserID); err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	if label.FraudLabel == repository.FraudLabelCONFIRMEDFRAUD {
+		if err := forgetConfirmedFraud(ctx, qtx, updated.TransactionID); err != nil {
+			return specs.FraudCaseResponse{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return specs.FraudCaseResponse{}, err
+	}
+
+	s.logger.Info("resolved fraud case",
+		zap.Int32("case_id", caseID),
+		zap.Int32("actor_id", actorID),
+		zap.String("disposition", req.Disposition),
+	)
+	return mapFraudCaseToResponse(updated), nil
+}
+
+// forgetConfirmedFraud stops trusting the device and payee of a transaction
+// confirmed as fraud, so NEW_DEVICE and NEW_PAYEE fire for them again
+func forgetConfirmedFraud(ctx context.Context, qtx *repository.Queries, txnID int32) error {
+	txn, err := qtx.GetTransactionByID(ctx, txnID)
+	if err != nil {
+		return err
+	}
+
+	if txn.DeviceID.Valid {
+		err := qtx.DeleteKnownDevice(ctx, repository.DeleteKnownDeviceParams{
+			UserID:   txn.UserID,
+			DeviceID: txn.DeviceID.String,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if txn.PayeeID.Valid {
+		err := qtx.DeleteKnownPayee(ctx, repository.DeleteKnownPayeeParams{
+			UserID:  txn.UserID,
+			PayeeID: txn.PayeeID.String,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openFraudCase queues a transaction for analyst review. Failures are only
+// logged so that scoring never fails because the queue is unavailable.
+func openFraudCase(ctx context.Context, queries *repository.Queries, logger *zap.Logger, userID int32, txnID int32, notes string) {
+	err := queries.OpenFraudCase(ctx, repository.OpenFraudCaseParams{
+		TransactionID: txnID,
+		UserID:        userID,
+		Notes:         notes,
+	})
+	if err != nil {
+		logger.Error("failed to open fraud case", zap.Int32("txn_id", txnID), zap.Error(err))
+	}
+}
+
+// reviewNotes summarises why a transaction was queued for review
+func reviewNotes(result specs.FraudAnalysisResult) string {
+	return result.Explain().Summary
+}
+
+// needsReview reports whether transactions with the decision go to the review queue
+func needsReview(decision repository.TransactionDecision) bool {
+	return decision == repository.TransactionDecisionFLAG || decision == repository.TransactionDecisionBLOCK
+}
+
+// lockOpenFraudCase locks a case row for the rest of the DB transaction,
+// failing if the case does not exist or is already resolved
+func lockOpenFraudCase(ctx context.Context, qtx *repository.Queries, caseID int32) (repository.FraudCase, error) {
+	c, err := qtx.GetFraudCaseForUpdate(ctx, caseID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.FraudCase{}, pkgerrors.ErrCaseNotFound
+		}
+		return repository.FraudCase{}, err
+	}
+
+	if c.Status == repository.CaseStatusRESOLVED {
+		return repository.FraudCase{}, pkgerrors.ErrCaseAlreadyResolved
+	}
+	return c, nil
+}
+
+func mapFraudCaseToResponse(c repository.FraudCase) specs.FraudCaseResponse {
+	res := specs.FraudCaseResponse{
+		ID:            c.ID,
+		TransactionID: c.TransactionID,
+		UserID:        c.UserID,
+		Status:        c.Status,
+		Notes:         c.Notes,
+		CreatedAt:     c.CreatedAt.Time,
+		UpdatedAt:     c.UpdatedAt.Time,
+	}
+	if c.AssignedTo.Valid {
+		res.AssignedTo = &c.AssignedTo.Int32
+	}
+	if c.Disposition.Valid {
+		res.Disposition = c.Disposition.FraudLabel
+	}
+	if c.ResolvedAt.Valid {
+		res.ResolvedAt = &c.ResolvedAt.Time
+	}
+	return res
+}
+
+func mapFraudCaseEventToResponse(e repository.FraudCaseEvent) specs.FraudCaseEventResponse {
+	res := specs.FraudCaseEventResponse{
+		ToStatus:  e.ToStatus,
+		Notes:     e.Notes,
+		CreatedAt: e.CreatedAt.Time,
+	}
+	if e.ActorID.Valid {
+		res.ActorID = &e.ActorID.Int32
+	}
+	if e.FromStatus.Valid {
+		res.FromStatus = e.FromStatus.CaseStatus
+	}
+	if e.AssignedTo.Valid {
+		res.AssignedTo = &e.AssignedTo.Int32
+	}
+	if e.Disposition.Valid {
+		res.Disposition = e.Disposition.FraudLabel
+	}
+	return res
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// ScoringConfigService manages versioned scoring configs and serves the active
+// and shadow ones from an in-memory cache that is refreshed from the DB after
+// constants.ScoringConfigCacheTTL, so changes on any instance are picked up
+// without a restart.
+type ScoringConfigService struct {
+	queries *repository.Queries
+	db      *pgxpool.Pool
+	logger  *zap.Logger
+
+	mu             sync.RWMutex
+	active         *specs.ScoringConfig
+	loadedAt       time.Time
+	shadow         *specs.ScoringConfig
+	shadowLoadedAt time.Time
+}
+
+func NewScoringConfigService(queries *repository.Queries, db *pgxpool.Pool, logger *zap.Logger) *ScoringConfigService {
+	return &ScoringConfigService{
+		queries: queries,
+		db:      db,
+		logger:  logger,
+	}
+}
+
+// ActiveConfig returns the config transactions should currently be scored with.
+// If the DB cannot be reached the last loaded config (or the built-in default)
+// is served instead. The returned config must not be modified.
+func (s *ScoringConfigService) ActiveConfig(ctx context.Context) *specs.ScoringConfig {
+	s.mu.RLock()
+	cached, loadedAt := s.active, s.loadedAt
+	s.mu.RUnlock()
+
+	if cached != nil && time.Since(loadedAt) < constants.ScoringConfigCacheTTL {
+		return cached
+	}
+
+	cfg, err := s.loadActiveConfig(ctx)
+	if err != nil {
+		s.logger.Error("failed to load active scoring config", zap.Error(err))
+		if cached != nil {
+			return cached
+		}
+		return helpers.DefaultScoringConfig()
+	}
+
+	s.setActive(cfg)
+	return cfg
+}
+
+func (s *ScoringConfigService) loadActiveConfig(ctx context.Context) (*specs.ScoringConfig, error) {
+	row, err := s.queries.GetActiveScoringConfig(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return helpers.DefaultScoringConfig(), nil
+		}
+		return nil, err
+	}
+	return decodeScoringConfig(row)
+}
+
+func (s *ScoringConfigService) setActive(cfg *specs.ScoringConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active = cfg
+	s.loadedAt = time.Now()
+}
+
+// ShadowConfig returns the config live transactions are shadow scored with,
+// or nil when none is shadowed. If the DB cannot be reached the last loaded
+// one is served instead. The returned config must not be modified.
+func (s *ScoringConfigService) ShadowConfig(ctx context.Context) *specs.ScoringConfig {
+	s.mu.RLock()
+	cached, loadedAt := s.shadow, s.shadowLoadedAt
+	s.mu.RUnlock()
+
+	if !loadedAt.IsZero() && time.Since(loadedAt) < constants.ScoringConfigCacheTTL {
+		return cached
+	}
+
+	var cfg *specs.ScoringConfig
+	row, err := s.queries.GetShadowScoringConfig(ctx)
+	if err == nil {
+		cfg, err = decodeScoringConfig(row)
+	} else if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		s.logger.Error("failed to load shadow scoring config", zap.Error(err))
+		return cached
+	}
+
+	s.setShadow(cfg)
+	return cfg
+}
+
+func (s *ScoringConfigService) setShadow(cfg *specs.ScoringConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shadow = cfg
+	s.shadowLoadedAt = time.Now()
+}
+
+// CreateConfig stores a new, inactive config version
+func (s *ScoringConfigService) CreateConfig(ctx context.Context, userID int32, req specs.CreateScoringConfigRequest) (specs.ScoringConfigResponse, error) {
+	if err := helpers.ValidateScoringConfig(req.Config); err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	// version is assigned by the DB
+	req.Config.Version = 0
+	raw, err := json.Marshal(req.Config)
+	if err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	row, err := s.queries.CreateScoringConfig(ctx, repository.CreateScoringConfigParams{
+		Description: req.Description,
+		Config:      raw,
+		CreatedBy:   pgtype.Int4{Int32: userID, Valid: true},
+	})
+	if err != nil {
+		s.logger.Error("failed to create scoring config", zap.Error(err))
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	return mapScoringConfigToResponse(row)
+}
+
+// ActivateConfig makes the given version the only active one and reloads the cache
+func (s *ScoringConfigService) ActivateConfig(ctx context.Context, version int32) (specs.ScoringConfigResponse, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.DeactivateScoringConfigs(ctx); err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	row, err := qtx.ActivateScoringConfig(ctx, version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.ScoringConfigResponse{}, pkgerrors.ErrScoringConfigNotFound
+		}
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	res, err := mapScoringConfigToResponse(row)
+	if err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	s.setActive(&res.Config)
+	// activating the shadow version promotes it out of the shadow
+	s.mu.Lock()
+	s.shadowLoadedAt = time.Time{}
+	s.mu.Unlock()
+	s.logger.Info("activated scoring config", zap.Int32("version", version))
+	return res, nil
+}
+
+// StartShadow makes the given version the only shadow one, scoring live
+// transactions next to the active version without affecting their decision
+func (s *ScoringConfigService) StartShadow(ctx context.Context, version int32) (specs.ScoringConfigResponse, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+	row, err := qtx.GetScoringConfigByVersion(ctx, version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.ScoringConfigResponse{}, pkgerrors.ErrScoringConfigNotFound
+		}
+		return specs.ScoringConfigResponse{}, err
+	}
+	if row.IsActive {
+		return specs.ScoringConfigResponse{}, pkgerrors.ErrShadowConfigActive
+	}
+
+	if err := qtx.ClearShadowScoringConfig(ctx); err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+	row, err = qtx.ShadowScoringConfig(ctx, version)
+	if err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	res, err := mapScoringConfigToResponse(row)
+	if err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	s.setShadow(&res.Config)
+	s.logger.Info("shadowing scoring config", zap.Int32("version", version))
+	return res, nil
+}
+
+// StopShadow stops shadow scoring. Stored shadow decisions are kept.
+func (s *ScoringConfigService) StopShadow(ctx context.Context) error {
+	if err := s.queries.ClearShadowScoringConfig(ctx); err != nil {
+		return err
+	}
+
+	s.setShadow(nil)
+	s.logger.Info("stopped shadow scoring")
+	return nil
+}
+
+// ShadowSummary compares the decisions a version made in the shadow with the
+// ones that were returned for the same transactions
+func (s *ScoringConfigService) ShadowSummary(ctx context.Context, version int32) (specs.ShadowSummaryResponse, error) {
+	if _, err := s.queries.GetScoringConfigByVersion(ctx, version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.ShadowSummaryResponse{}, pkgerrors.ErrScoringConfigNotFound
+		}
+		return specs.ShadowSummaryResponse{}, err
+	}
+
+	rows, err := s.queries.SummarizeShadowDecisions(ctx, version)
+	if err != nil {
+		return specs.ShadowSummaryResponse{}, err
+	}
+
+	res := specs.ShadowSummaryResponse{
+		Version: version,
+		Deltas:  map[repository.TransactionDecision]map[repository.TransactionDecision]int{},
+	}
+	var champion, challenger specs.LabelMetrics
+	var latency int64
+	for _, row := range rows {
+		n := int(row.Transactions)
+		res.Transactions += n
+		if row.Decision != row.ChampionDecision {
+			res.Changed += n
+		}
+		if res.Deltas[row.ChampionDecision] == nil {
+			res.Deltas[row.ChampionDecision] = map[repository.TransactionDecision]int{}
+		}
+		res.Deltas[row.ChampionDecision][row.Decision] += n
+		latency += row.LatencyMicros
+
+		if row.FraudLabel.Valid {
+			champion.Add(row.FraudLabel.FraudLabel, row.ChampionDecision, n)
+			challenger.Add(row.FraudLabel.FraudLabel, row.Decision, n)
+		}
+	}
+	if res.Transactions > 0 {
+		res.AverageLatencyMicros = latency / int64(res.Transactions)
+	}
+	res.Champion = champion.Finish()
+	res.Challenger = challenger.Finish()
+	return res, nil
+}
+
+// ListConfigs returns every stored config version, newest first
+func (s *ScoringConfigService) ListConfigs(ctx context.Context) ([]specs.ScoringConfigResponse, error) {
+	rows, err := s.queries.ListScoringConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []specs.ScoringConfigResponse{}
+	for _, row := range rows {
+		cfg, err := mapScoringConfigToResponse(row)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cfg)
+	}
+	return res, nil
+}
+
+// decodeScoringConfig overlays a stored config on the built-in default so that
+// factors registered after the version was created keep their default weights
+func decodeScoringConfig(row repository.ScoringConfig) (*specs.ScoringConfig, error) {
+	cfg := helpers.DefaultScoringConfig()
+	if err := json.Unmarshal(row.Config, cfg); err != nil {
+		return nil, err
+	}
+	cfg.Version = row.Version
+	return cfg, nil
+}
+
+func mapScoringConfigToResponse(row repository.ScoringConfig) (specs.ScoringConfigResponse, error) {
+	cfg, err := decodeScoringConfig(row)
+	if err != nil {
+		return specs.ScoringConfigResponse{}, err
+	}
+
+	res := specs.ScoringConfigResponse{
+		Version:     row.Version,
+		Description: row.Description,
+		IsActive:    row.IsActive,
+		IsShadow:    row.IsShadow,
+		Config:      *cfg,
+		CreatedAt:   row.CreatedAt.Time,
+	}
+	if row.CreatedBy.Valid {
+		res.CreatedBy = &row.CreatedBy.Int32
+	}
+	if row.ActivatedAt.Valid {
+		res.ActivatedAt = &row.ActivatedAt.Time
+	}
+	return res, nil
+}
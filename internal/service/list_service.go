@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ListService manages the allow and deny lists. Entries live in Postgres; the
+// active entry of each entity value, or its absence, is cached in Redis for
+// constants.ListEntryCacheTTL and dropped whenever the value's entries change.
+type ListService struct {
+	queries *repository.Queries
+	rd      *redis.Client
+	logger  *zap.Logger
+}
+
+func NewListService(queries *repository.Queries, rd *redis.Client, logger *zap.Logger) *ListService {
+	return &ListService{
+		queries: queries,
+		rd:      rd,
+		logger:  logger,
+	}
+}
+
+func listEntryCacheKey(entity repository.ListEntity, value string) string {
+	return constants.ListEntryCacheKeyPrefix + string(entity) + ":" + value
+}
+
+// CreateEntry adds a validated entry to its list
+func (s *ListService) CreateEntry(ctx context.Context, actorID int32, req specs.CreateListEntryRequest) (specs.ListEntryResponse, error) {
+	entity := repository.ListEntity(req.Entity)
+	value := normalizeListValue(entity, req.Value)
+
+	expiresAt := pgtype.Timestamp{}
+	if req.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamp{Time: req.ExpiresAt.UTC(), Valid: true}
+	}
+
+	row, err := s.queries.CreateListEntry(ctx, repository.CreateListEntryParams{
+		ListType:  repository.ListType(req.ListType),
+		Entity:    entity,
+		Value:     value,
+		Reason:    req.Reason,
+		CreatedBy: pgtype.Int4{Int32: actorID, Valid: true},
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		s.logger.Error("failed to create list entry", zap.Error(err))
+		return specs.ListEntryResponse{}, err
+	}
+	s.invalidate(ctx, entity, value)
+
+	s.logger.Info("added list entry",
+		zap.Int32("entry_id", row.ID),
+		zap.Int32("actor_id", actorID),
+		zap.String("list_type", string(row.ListType)),
+		zap.String("entity", string(row.Entity)),
+	)
+	return mapListEntryToResponse(row), nil
+}
+
+// ListEntries returns every active entry of both lists, newest first
+func (s *ListService) ListEntries(ctx context.Context) ([]specs.ListEntryResponse, error) {
+	rows, err := s.queries.ListActiveListEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []specs.ListEntryResponse{}
+	for _, row := range rows {
+		res = append(res, mapListEntryToResponse(row))
+	}
+	return res, nil
+}
+
+// RemoveEntry takes an entry off its list. The row is kept so that the
+// transactions it decided still point at it.
+func (s *ListService) RemoveEntry(ctx context.Context, actorID int32, entryID int32) (specs.ListEntryResponse, error) {
+	row, err := s.queries.RemoveListEntry(ctx, repository.RemoveListEntryParams{
+		ID:        entryID,
+		RemovedBy: pgtype.Int4{Int32: actorID, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.ListEntryResponse{}, pkgerrors.ErrListEntryNotFound
+		}
+		return specs.ListEntryResponse{}, err
+	}
+	s.invalidate(ctx, row.Entity, row.Value)
+
+	s.logger.Info("removed list entry", zap.Int32("entry_id", row.ID), zap.Int32("actor_id", actorID))
+	return mapListEntryToResponse(row), nil
+}
+
+// MatchEntry returns the list entry deciding a transaction of the user, or nil
+// when neither list holds the user, payee, device or IP address. Deny entries
+// win over allow entries. Lookup failures are logged and skip the value.
+func (s *ListService) MatchEntry(ctx context.Context, userID int32, req specs.CreateTransactionRequest) *specs.ListEntryResponse {
+	candidates := []struct {
+		entity repository.ListEntity
+		value  string
+	}{
+		{repository.ListEntityUSER, strconv.Itoa(int(userID))},
+		{repository.ListEntityPAYEE, req.PayeeID},
+		{repository.ListEntityDEVICE, req.DeviceID},
+		{repository.ListEntityIP, normalizeListValue(repository.ListEntityIP, req.IPAddress)},
+	}
+
+	var allowed *specs.ListEntryResponse
+	for _, c := range candidates {
+		if c.value == "" {
+			continue
+		}
+
+		entry, err := s.lookup(ctx, c.entity, c.value)
+		if err != nil {
+			s.logger.Error("failed to look up list entry", zap.String("entity", string(c.entity)), zap.Error(err))
+			continue
+		}
+		switch {
+		case entry == nil:
+		case entry.ListType == repository.ListTypeDENY:
+			return entry
+		case allowed == nil:
+			allowed = entry
+		}
+	}
+	return allowed
+}
+
+// lookup returns the active entry of an entity value from the cache, or from
+// the DB on a cache miss
+func (s *ListService) lookup(ctx context.Context, entity repository.ListEntity, value string) (*specs.ListEntryResponse, error) {
+	key := listEntryCacheKey(entity, value)
+
+	cached, err := s.rd.Get(ctx, key).Result()
+	switch {
+	case err == nil:
+		if cached == "" {
+			return nil, nil
+		}
+		var entry specs.ListEntryResponse
+		if err := json.Unmarshal([]byte(cached), &entry); err == nil {
+			return &entry, nil
+		}
+	case !errors.Is(err, redis.Nil):
+		s.logger.Error("failed to read list entry cache", zap.Error(err))
+	}
+
+	var entry *specs.ListEntryResponse
+	row, err := s.queries.FindActiveListEntry(ctx, repository.FindActiveListEntryParams{Entity: entity, Value: value})
+	if err == nil {
+		res := mapListEntryToResponse(row)
+		entry = &res
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	// an empty value caches the absence of an entry
+	payload, ttl := []byte{}, constants.ListEntryCacheTTL
+	if entry != nil {
+		if payload, err = json.Marshal(entry); err != nil {
+			return entry, nil
+		}
+		if entry.ExpiresAt != nil {
+			ttl = min(ttl, time.Until(*entry.ExpiresAt))
+		}
+	}
+	if ttl > 0 {
+		if err := s.rd.Set(ctx, key, payload, ttl).Err(); err != nil {
+			s.logger.Error("failed to cache list entry", zap.Error(err))
+		}
+	}
+	return entry, nil
+}
+
+// invalidate drops the cached entry of an entity value after its entries changed
+func (s *ListService) invalidate(ctx context.Context, entity repository.ListEntity, value string) {
+	if err := s.rd.Del(ctx, listEntryCacheKey(entity, value)).Err(); err != nil {
+		s.logger.Error("failed to invalidate list entry cache", zap.String("entity", string(entity)), zap.Error(err))
+	}
+}
+
+// normalizeListValue gives IP addresses a single spelling, so that entries
+// match however the client formats the address
+func normalizeListValue(entity repository.ListEntity, value string) string {
+	if entity != repository.ListEntityIP {
+		return value
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return ""
+	}
+	return addr.Unmap().String()
+}
+
+func mapListEntryToResponse(row repository.ListEntry) specs.ListEntryResponse {
+	res := specs.ListEntryResponse{
+		ID:        row.ID,
+		ListType:  row.ListType,
+		Entity:    row.Entity,
+		Value:     row.Value,
+		Reason:    row.Reason,
+		CreatedAt: row.CreatedAt.Time,
+	}
+	if row.CreatedBy.Valid {
+		res.CreatedBy = &row.CreatedBy.Int32
+	}
+	if row.ExpiresAt.Valid {
+		res.ExpiresAt = &row.ExpiresAt.Time
+	}
+	return res
+}
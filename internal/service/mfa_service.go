@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/notifier"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// MFAService issues and verifies OTP challenges for MFA_REQUIRED transactions.
+// Challenges live in Redis as a hash holding the OTP hash and the number of
+// failed attempts, and expire after constants.MFAChallengeTTL.
+type MFAService struct {
+	queries  *repository.Queries
+	db       *pgxpool.Pool
+	rd       *redis.Client
+	notifier notifier.Notifier
+	logger   *zap.Logger
+}
+
+func NewMFAService(queries *repository.Queries, db *pgxpool.Pool, rd *redis.Client, n notifier.Notifier, logger *zap.Logger) *MFAService {
+	return &MFAService{
+		queries:  queries,
+		db:       db,
+		rd:       rd,
+		notifier: n,
+		logger:   logger,
+	}
+}
+
+func mfaChallengeKey(txnID int32) string {
+	return fmt.Sprintf("%s%d", constants.MFAChallengeKeyPrefix, txnID)
+}
+
+// IssueChallenge generates an OTP for the transaction, stores its hash and
+// sends the code to the user. It returns when the challenge expires.
+func (s *MFAService) IssueChallenge(ctx context.Context, userID int32, txnID int32) (time.Time, error) {
+	otp, err := helpers.GenerateOTP(constants.MFAOTPLength)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	key := mfaChallengeKey(txnID)
+	_, err = s.rd.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, map[string]any{
+			"otp_hash": helpers.HashOTP(txnID, otp),
+			"user_id":  userID,
+			"attempts": 0,
+		})
+		pipe.Expire(ctx, key, constants.MFAChallengeTTL)
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	expiresAt := time.Now().Add(constants.MFAChallengeTTL)
+
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	err = s.notifier.Notify(ctx, notifier.Message{
+		UserID:  userID,
+		Email:   user.Email,
+		Subject: "Verify your transaction",
+		Body: fmt.Sprintf("Your verification code for transaction %d is %s. It expires in %s.",
+			txnID, otp, constants.MFAChallengeTTL),
+	})
+	if err != nil {
+		s.rd.Del(ctx, key)
+		return time.Time{}, err
+	}
+
+	return expiresAt, nil
+}
+
+// VerifyChallenge checks the OTP for an MFA_REQUIRED transaction. A correct code
+// moves the transaction to ALLOW; an expired challenge or too many wrong codes
+// move it to BLOCK. A wrong code with attempts left returns ErrInvalidOTP.
+func (s *MFAService) VerifyChallenge(ctx context.Context, userID int32, txnID int32, req specs.VerifyMFARequest) (specs.VerifyMFAResponse, error) {
+	txn, err := s.queries.GetTransactionByTxnID(ctx, repository.GetTransactionByTxnIDParams{
+		ID:     txnID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.VerifyMFAResponse{}, pkgerrors.ErrTransactionNotFound
+		}
+		return specs.VerifyMFAResponse{}, err
+	}
+
+	if txn.Decision != repository.TransactionDecisionMFAREQUIRED {
+		return specs.VerifyMFAResponse{}, pkgerrors.ErrMFANotPending
+	}
+
+	// The attempt is counted before the code is compared, so concurrent guesses
+	// cannot all be checked before the limit trips
+	key := mfaChallengeKey(txnID)
+	var attempts *redis.IntCmd
+	var otpHash *redis.StringCmd
+	_, err = s.rd.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		attempts = pipe.HIncrBy(ctx, key, "attempts", 1)
+		otpHash = pipe.HGet(ctx, key, "otp_hash")
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return specs.VerifyMFAResponse{}, err
+	}
+
+	// only the counter just created is left of an expired challenge
+	if otpHash.Val() == "" {
+		s.rd.Del(ctx, key)
+		return s.resolveChallenge(ctx, userID, txnID, repository.TransactionDecisionBLOCK, "MFA challenge expired")
+	}
+
+	if attempts.Val() > constants.MFAMaxAttempts {
+		return s.resolveChallenge(ctx, userID, txnID, repository.TransactionDecisionBLOCK, "too many failed MFA attempts")
+	}
+
+	if helpers.CheckOTP(txnID, req.OTP, otpHash.Val()) {
+		return s.resolveChallenge(ctx, userID, txnID, repository.TransactionDecisionALLOW, "MFA verified")
+	}
+
+	if attempts.Val() >= constants.MFAMaxAttempts {
+		return s.resolveChallenge(ctx, userID, txnID, repository.TransactionDecisionBLOCK, "too many failed MFA attempts")
+	}
+
+	return specs.VerifyMFAResponse{}, pkgerrors.ErrInvalidOTP
+}
+
+// resolveChallenge records the final decision of a challenged transaction and
+// applies allowed ones to the user's profile, known devices and known payees.
+// It holds the user's lock, like scoring, so the update is not lost to a
+// concurrent one.
+func (s *MFAService) resolveChallenge(ctx context.Context, userID int32, txnID int32, decision repository.TransactionDecision, message string) (specs.VerifyMFAResponse, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return specs.VerifyMFAResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.LockUserProfile(ctx, userID); err != nil {
+		return specs.VerifyMFAResponse{}, err
+	}
+
+	txn, err := qtx.UpdatePendingMFADecision(ctx, repository.UpdatePendingMFADecisionParams{
+		ID:       txnID,
+		UserID:   userID,
+		Decision: decision,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// resolved concurrently by another request
+			return specs.VerifyMFAResponse{}, pkgerrors.ErrMFANotPending
+		}
+		return specs.VerifyMFAResponse{}, err
+	}
+
+	if decision == repository.TransactionDecisionALLOW {
+		if err := applyVerifiedToProfile(ctx, qtx, txn); err != nil {
+			return specs.VerifyMFAResponse{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return specs.VerifyMFAResponse{}, err
+	}
+
+	if err := s.rd.Del(ctx, mfaChallengeKey(txnID)).Err(); err != nil {
+		s.logger.Error("failed to delete mfa challenge", zap.Int32("txn_id", txnID), zap.Error(err))
+	}
+
+	if needsReview(txn.Decision) {
+		openFraudCase(ctx, s.queries, s.logger, userID, txn.ID, message)
+	}
+
+	return specs.VerifyMFAResponse{
+		TransactionID: txn.ID,
+		Decision:      txn.Decision,
+		Message:       message,
+	}, nil
+}
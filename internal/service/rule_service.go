@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// RuleService manages fraud rules and serves the enabled ones, compiled, from
+// an in-memory cache that is refreshed from the DB after constants.RulesCacheTTL
+// and dropped on every change made through this instance.
+type RuleService struct {
+	queries *repository.Queries
+	logger  *zap.Logger
+
+	mu       sync.RWMutex
+	active   []specs.CompiledRule
+	loadedAt time.Time
+}
+
+func NewRuleService(queries *repository.Queries, logger *zap.Logger) *RuleService {
+	return &RuleService{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// ActiveRules returns the enabled rules in evaluation order. If the DB cannot
+// be reached the last loaded rules are served instead. The returned slice must
+// not be modified.
+func (s *RuleService) ActiveRules(ctx context.Context) []specs.CompiledRule {
+	s.mu.RLock()
+	cached, loadedAt := s.active, s.loadedAt
+	s.mu.RUnlock()
+
+	if cached != nil && time.Since(loadedAt) < constants.RulesCacheTTL {
+		return cached
+	}
+
+	rows, err := s.queries.ListEnabledRules(ctx)
+	if err != nil {
+		s.logger.Error("failed to load fraud rules", zap.Error(err))
+		return cached
+	}
+
+	compiled := []specs.CompiledRule{}
+	for _, row := range rows {
+		// expressions are checked when saved, but a factor they use may
+		// have been unregistered since
+		e, err := helpers.CompileRule(row.Expression)
+		if err != nil {
+			s.logger.Error("skipping fraud rule", zap.Int32("rule_id", row.ID), zap.Error(err))
+			continue
+		}
+		compiled = append(compiled, specs.CompiledRule{
+			MatchedRule: mapRuleToMatchedRule(row),
+			Expression:  e,
+		})
+	}
+
+	s.mu.Lock()
+	s.active, s.loadedAt = compiled, time.Now()
+	s.mu.Unlock()
+	return compiled
+}
+
+// invalidate makes the next ActiveRules call reload the rules
+func (s *RuleService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loadedAt = time.Time{}
+}
+
+// CreateRule stores a validated rule once its expression compiles
+func (s *RuleService) CreateRule(ctx context.Context, actorID int32, req specs.RuleRequest) (specs.RuleResponse, error) {
+	if _, err := helpers.CompileRule(req.Expression); err != nil {
+		return specs.RuleResponse{}, err
+	}
+
+	fields := ruleFields(req)
+	row, err := s.queries.CreateRule(ctx, repository.CreateRuleParams{
+		Name:       req.Name,
+		Expression: req.Expression,
+		Action:     fields.Action,
+		Decision:   fields.Decision,
+		ScoreDelta: fields.ScoreDelta,
+		Tag:        fields.Tag,
+		Priority:   req.Priority,
+		Enabled:    fields.Enabled,
+		CreatedBy:  pgtype.Int4{Int32: actorID, Valid: true},
+	})
+	if err != nil {
+		s.logger.Error("failed to create fraud rule", zap.Error(err))
+		return specs.RuleResponse{}, err
+	}
+	s.invalidate()
+
+	s.logger.Info("created fraud rule", zap.Int32("rule_id", row.ID), zap.Int32("actor_id", actorID))
+	return mapRuleToResponse(row), nil
+}
+
+// ListRules returns every rule, enabled or not, in evaluation order
+func (s *RuleService) ListRules(ctx context.Context) ([]specs.RuleResponse, error) {
+	rows, err := s.queries.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []specs.RuleResponse{}
+	for _, row := range rows {
+		res = append(res, mapRuleToResponse(row))
+	}
+	return res, nil
+}
+
+// UpdateRule replaces a rule. Transactions it matched keep its id.
+func (s *RuleService) UpdateRule(ctx context.Context, actorID int32, ruleID int32, req specs.RuleRequest) (specs.RuleResponse, error) {
+	if _, err := helpers.CompileRule(req.Expression); err != nil {
+		return specs.RuleResponse{}, err
+	}
+
+	fields := ruleFields(req)
+	row, err := s.queries.UpdateRule(ctx, repository.UpdateRuleParams{
+		ID:         ruleID,
+		Name:       req.Name,
+		Expression: req.Expression,
+		Action:     fields.Action,
+		Decision:   fields.Decision,
+		ScoreDelta: fields.ScoreDelta,
+		Tag:        fields.Tag,
+		Priority:   req.Priority,
+		Enabled:    fields.Enabled,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.RuleResponse{}, pkgerrors.ErrRuleNotFound
+		}
+		s.logger.Error("failed to update fraud rule", zap.Error(err))
+		return specs.RuleResponse{}, err
+	}
+	s.invalidate()
+
+	s.logger.Info("updated fraud rule", zap.Int32("rule_id", row.ID), zap.Int32("actor_id", actorID))
+	return mapRuleToResponse(row), nil
+}
+
+// DeleteRule removes a rule. Transactions it matched keep its id.
+func (s *RuleService) DeleteRule(ctx context.Context, actorID int32, ruleID int32) (specs.RuleResponse, error) {
+	row, err := s.queries.DeleteRule(ctx, ruleID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return specs.RuleResponse{}, pkgerrors.ErrRuleNotFound
+		}
+		return specs.RuleResponse{}, err
+	}
+	s.invalidate()
+
+	s.logger.Info("deleted fraud rule", zap.Int32("rule_id", row.ID), zap.Int32("actor_id", actorID))
+	return mapRuleToResponse(row), nil
+}
+
+// ruleFields returns the stored form of a request's action: only the field
+// of its action is kept
+func ruleFields(req specs.RuleRequest) repository.Rule {
+	rule := repository.Rule{
+		Action:  repository.RuleAction(req.Action),
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	switch rule.Action {
+	case repository.RuleActionSETDECISION:
+		rule.Decision = repository.NullTransactionDecision{
+			TransactionDecision: repository.TransactionDecision(req.Decision),
+			Valid:               true,
+		}
+	case repository.RuleActionADDSCORE:
+		rule.ScoreDelta = req.ScoreDelta
+	case repository.RuleActionADDTAG:
+		rule.Tag = req.Tag
+	}
+	return rule
+}
+
+func mapRuleToMatchedRule(row repository.Rule) specs.MatchedRule {
+	return specs.MatchedRule{
+		ID:         row.ID,
+		Name:       row.Name,
+		Action:     row.Action,
+		Decision:   row.Decision.TransactionDecision,
+		ScoreDelta: row.ScoreDelta,
+		Tag:        row.Tag,
+	}
+}
+
+func mapRuleToResponse(row repository.Rule) specs.RuleResponse {
+	res := specs.RuleResponse{
+		ID:         row.ID,
+		Name:       row.Name,
+		Expression: row.Expression,
+		Action:     row.Action,
+		ScoreDelta: row.ScoreDelta,
+		Tag:        row.Tag,
+		Priority:   row.Priority,
+		Enabled:    row.Enabled,
+		CreatedAt:  row.CreatedAt.Time,
+		UpdatedAt:  row.UpdatedAt.Time,
+	}
+	if row.Decision.Valid {
+		res.Decision = &row.Decision.TransactionDecision
+	}
+	if row.CreatedBy.Valid {
+		res.CreatedBy = &row.CreatedBy.Int32
+	}
+	return res
+}
@@ -2,17 +2,28 @@ package service_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/geoip"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/notifier"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/velocity"
 	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
 	"github.com/cheemx5395/fraud-detection-lite/internal/service"
 	"github.com/cheemx5395/fraud-detection-lite/internal/worker"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -32,11 +43,33 @@ func setupTestServices(t *testing.T) (*service.UserService, *service.Transaction
 	redisClient := worker.InitializeRedis()
 
 	userService := service.NewUserService(queries, redisClient, logger)
-	txnService := service.NewTransactionService(queries, pool, logger)
+	configService := service.NewScoringConfigService(queries, pool, logger)
+	mfaService := service.NewMFAService(queries, pool, redisClient, notifier.NewLogNotifier(logger), logger)
+	velocityStore := velocity.New(redisClient, queries, logger)
+	listService := service.NewListService(queries, redisClient, logger)
+	ruleService := service.NewRuleService(queries, logger)
+	txnService := service.NewTransactionService(queries, pool, configService, mfaService, velocityStore, geoip.NopLocator{}, listService, ruleService, logger)
 
 	return userService, txnService, queries
 }
 
+// runBulkJobs drains the bulk job queue the way the background workers do and
+// returns the final state of the given job
+func runBulkJobs(t *testing.T, txnService *service.TransactionService, userID int32, jobID string) specs.BulkJobResponse {
+	ctx := context.Background()
+	for {
+		found, err := txnService.ProcessNextBulkJob(ctx)
+		require.NoError(t, err)
+		if !found {
+			break
+		}
+	}
+
+	job, err := txnService.GetBulkJob(ctx, userID, jobID)
+	require.NoError(t, err)
+	return job
+}
+
 func TestUserFlow(t *testing.T) {
 	userService, _, _ := setupTestServices(t)
 	ctx := context.Background()
@@ -111,11 +144,23 @@ func TestTransactionFlow(t *testing.T) {
 invalid,UPI,2023-10-01T12:00:00Z`
 
 	reader := strings.NewReader(csvContent)
-	bulkRes, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, reader, "test.csv")
+	bulkJob, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, reader, "test.csv", false)
+	require.NoError(t, err)
+	assert.Equal(t, repository.BulkJobStatusPENDING, bulkJob.Status)
+	assert.Equal(t, int32(3), bulkJob.Progress.Total)
+
+	bulkRes := runBulkJobs(t, txnService, signupRes.ID, bulkJob.JobID)
+	assert.Equal(t, repository.BulkJobStatusCOMPLETED, bulkRes.Status)
+	assert.Equal(t, int32(2), bulkRes.Progress.Success) // 2 valid rows
+	assert.Equal(t, int32(1), bulkRes.Progress.Failed)  // 1 invalid amount
+	assert.Equal(t, int32(3), bulkRes.Progress.Processed)
+
+	rowErrors, err := txnService.ListBulkJobErrors(ctx, signupRes.ID, bulkJob.JobID)
 	require.NoError(t, err)
-	assert.Equal(t, 2, bulkRes.Success) // 2 valid rows
-	assert.Equal(t, 1, bulkRes.Failed)  // 1 invalid amount
-	assert.Equal(t, 3, bulkRes.Processed)
+	require.Len(t, rowErrors, 1)
+	assert.Equal(t, int32(4), rowErrors[0].Line)
+	assert.Equal(t, repository.BulkRowErrorReasonINVALIDAMOUNT, rowErrors[0].Reason)
+	assert.Equal(t, []string{"invalid", "UPI", "2023-10-01T12:00:00Z"}, rowErrors[0].Values)
 
 	// 3. Excel Bulk Transaction
 	// Assuming test is run from project root or we can find the file.
@@ -125,10 +170,11 @@ invalid,UPI,2023-10-01T12:00:00Z`
 		t.Log("Skipping Excel test because file not found:", err)
 	} else {
 		defer f.Close()
-		excelRes, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, f, "test.xlsx")
+		excelJob, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, f, "test.xlsx", false)
 		require.NoError(t, err)
-		assert.Greater(t, excelRes.Processed, 0, "Should process Excel rows")
-		t.Logf("Processed Excel rows: %d", excelRes.Processed)
+		excelRes := runBulkJobs(t, txnService, signupRes.ID, excelJob.JobID)
+		assert.Greater(t, excelRes.Progress.Processed, int32(0), "Should process Excel rows")
+		t.Logf("Processed Excel rows: %d", excelRes.Progress.Processed)
 	}
 }
 
@@ -153,8 +199,7 @@ func TestBatchProfileUpdate(t *testing.T) {
 		// Profile may not exist yet, that's okay
 	}
 
-	// Generate 60 transactions to trigger batch update (batch size = 50)
-	// First 50 will have avg ~500, next 10 will have avg ~1500
+	// Generate 60 transactions: the first 50 have avg ~500, the next 10 avg ~1500
 	var csvBuilder strings.Builder
 	csvBuilder.WriteString("amount,mode,created_at\n")
 
@@ -178,11 +223,12 @@ func TestBatchProfileUpdate(t *testing.T) {
 	reader := strings.NewReader(csvContent)
 
 	// Process bulk transactions
-	bulkRes, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, reader, "batch_test.csv")
+	bulkJob, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, reader, "batch_test.csv", false)
 	require.NoError(t, err)
-	assert.Equal(t, 60, bulkRes.Success, "All 60 transactions should succeed")
-	assert.Equal(t, 0, bulkRes.Failed, "No transactions should fail")
-	assert.Equal(t, 60, bulkRes.Processed, "All 60 transactions should be processed")
+	bulkRes := runBulkJobs(t, txnService, signupRes.ID, bulkJob.JobID)
+	assert.Equal(t, int32(60), bulkRes.Progress.Success, "All 60 transactions should succeed")
+	assert.Equal(t, int32(0), bulkRes.Progress.Failed, "No transactions should fail")
+	assert.Equal(t, int32(60), bulkRes.Progress.Processed, "All 60 transactions should be processed")
 
 	// Get profile after batch processing
 	profileAfter, err := queries.GetUserProfileByUserID(ctx, signupRes.ID)
@@ -199,13 +245,23 @@ func TestBatchProfileUpdate(t *testing.T) {
 	assert.Less(t, avgAmount, 900.0, "Average should be less than 900")
 
 	// Std dev should be > 0 since we have variance
-	stdDev := float64(profileAfter.StdDevTransactionAmount.Int32)
+	stdDev := profileAfter.StdDevTransactionAmount.Float64
 	assert.Greater(t, stdDev, 0.0, "Standard deviation should be greater than 0")
 	assert.Greater(t, stdDev, 100.0, "Standard deviation should reflect the variance in amounts")
 
 	// Verify total transactions count
 	assert.Equal(t, int32(60), profileAfter.TotalTransactions, "Should have 60 total transactions")
 
+	// The robust statistics ignore the ~1500 tail, and UPI keeps its own distribution
+	var amountStats specs.AmountStats
+	require.NoError(t, json.Unmarshal(profileAfter.AmountStats, &amountStats))
+	assert.Less(t, amountStats.Median, avgAmount, "Median should resist the high amounts")
+	assert.Greater(t, amountStats.P99, 1500.0)
+
+	var modeStats map[repository.Mode]specs.AmountStats
+	require.NoError(t, json.Unmarshal(profileAfter.ModeAmountStats, &modeStats))
+	assert.InDelta(t, 545.0, modeStats[repository.ModeUPI].Median, 50.0)
+
 	// Log the profile for debugging
 	t.Logf("Profile Before: AvgAmount=%v, StdDev=%v, TotalTxns=%d",
 		profileBefore.AverageTransactionAmount,
@@ -214,15 +270,800 @@ func TestBatchProfileUpdate(t *testing.T) {
 
 	t.Logf("Profile After: AvgAmount=%.2f, StdDev=%.2f, TotalTxns=%d, AllowedTxns=%d",
 		profileAfter.AverageTransactionAmount.Float64,
-		float64(profileAfter.StdDevTransactionAmount.Int32),
+		profileAfter.StdDevTransactionAmount.Float64,
 		profileAfter.TotalTransactions,
 		profileAfter.AllowedTransactions)
 
-	// Additional verification: Check that batch update happened mid-processing
-	// by verifying profile reflects all 60 transactions, not just first 50
-	// If batch update didn't work, profile would only reflect first 50 (avg ~545)
-	// With batch update, it should reflect all 60 (avg ~720)
+	// The profile reflects all 60 transactions, not just the first 50
+	// (which alone would give avg ~545)
+}
+
+func TestBulkChronologicalReplay(t *testing.T) {
+	userService, txnService, queries := setupTestServices(t)
+	ctx := context.Background()
+
+	email := "replayuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Replay Test User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	// 6 transactions ten minutes apart, written newest first
+	baseTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var csvBuilder strings.Builder
+	csvBuilder.WriteString("amount,mode,created_at\n")
+	for i := 5; i >= 0; i-- {
+		timestamp := baseTime.Add(time.Duration(i) * 10 * time.Minute)
+		csvBuilder.WriteString(fmt.Sprintf("500.0,UPI,%s\n", timestamp.Format(time.RFC3339)))
+	}
+
+	bulkJob, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, strings.NewReader(csvBuilder.String()), "replay.csv", false)
+	require.NoError(t, err)
+	bulkRes := runBulkJobs(t, txnService, signupRes.ID, bulkJob.JobID)
+	require.Equal(t, int32(6), bulkRes.Progress.Success)
+
+	txns, err := queries.GetAllTransactionsByUserID(ctx, repository.GetAllTransactionsByUserIDParams{
+		UserID: signupRes.ID,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, txns, 6)
+
+	// rows are replayed oldest first, so only the latest transactions see more
+	// than the threshold of 3 earlier ones inside the frequency window
+	newest, oldest := txns[0], txns[5]
+	assert.Contains(t, newest.TriggeredFactors, "FREQUENCY_SPIKE")
+	assert.NotContains(t, oldest.TriggeredFactors, "FREQUENCY_SPIKE")
+	assert.Less(t, oldest.ID, newest.ID, "transactions should be stored in created_at order")
+}
+
+func TestBulkJobGivesUpAfterMaxAttempts(t *testing.T) {
+	userService, txnService, _ := setupTestServices(t)
+	ctx := context.Background()
+	_, pool, err := repository.InitializeDatabase(ctx)
+	require.NoError(t, err)
+
+	email := "attemptsuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Attempts User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	bulkJob, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, strings.NewReader("amount,mode,created_at\n500.0,UPI,2024-01-01T10:00:00Z\n"), "stuck.csv", false)
+	require.NoError(t, err)
+
+	// as if every earlier claim had been interrupted
+	_, err = pool.Exec(ctx, "UPDATE bulk_jobs SET attempts = $2 WHERE id = $1", bulkJob.JobID, constants.BulkJobMaxAttempts)
+	require.NoError(t, err)
+
+	bulkRes := runBulkJobs(t, txnService, signupRes.ID, bulkJob.JobID)
+	assert.Equal(t, repository.BulkJobStatusFAILED, bulkRes.Status)
+	assert.Equal(t, pkgerrors.ErrBulkJobAttemptsExceeded.Error(), bulkRes.Error)
+	assert.Zero(t, bulkRes.Progress.Processed)
+}
+
+func TestIdempotentTransactions(t *testing.T) {
+	userService, txnService, _ := setupTestServices(t)
+	ctx := context.Background()
+
+	email := "idempotencyuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Idempotency Test User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	txnReq := specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI", IdempotencyKey: "order-1"}
+	first, err := txnService.CreateTransaction(ctx, signupRes.ID, txnReq)
+	require.NoError(t, err)
+
+	// a replay returns the original transaction instead of inserting again
+	replay, err := txnService.CreateTransaction(ctx, signupRes.ID, txnReq)
+	require.NoError(t, err)
+	assert.Equal(t, first.TransactionID, replay.TransactionID)
+	assert.Equal(t, first.Decision, replay.Decision)
+	require.NotNil(t, first.Explanation)
+	assert.Equal(t, first.Explanation, replay.Explanation, "the stored explanation is replayed")
+	assert.Len(t, first.Explanation.Factors, 7)
+
+	// any field differing from the stored transaction is a conflict
+	latitude, longitude := 19.076, 72.8777
+	for name, change := range map[string]func(*specs.CreateTransactionRequest){
+		"amount":   func(r *specs.CreateTransactionRequest) { r.Amount = 900.0 },
+		"payee":    func(r *specs.CreateTransactionRequest) { r.PayeeID = "stranger@upi" },
+		"device":   func(r *specs.CreateTransactionRequest) { r.DeviceID = "phone-2" },
+		"location": func(r *specs.CreateTransactionRequest) { r.Latitude, r.Longitude = &latitude, &longitude },
+	} {
+		changed := txnReq
+		change(&changed)
+		_, err = txnService.CreateTransaction(ctx, signupRes.ID, changed)
+		assert.ErrorIs(t, err, pkgerrors.ErrIdempotencyKeyReused, name)
+	}
+
+	// bulk rows share the same references
+	csvContent := `amount,mode,created_at,external_reference
+500.0,UPI,2023-10-01T10:00:00Z,order-1
+700.0,CARD,2023-10-01T11:00:00Z,order-2
+700.0,CARD,2023-10-01T11:00:00Z,order-2`
+
+	bulkJob, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, strings.NewReader(csvContent), "refs.csv", false)
+	require.NoError(t, err)
+	bulkRes := runBulkJobs(t, txnService, signupRes.ID, bulkJob.JobID)
+	assert.Equal(t, int32(1), bulkRes.Progress.Success)
+	assert.Equal(t, int32(2), bulkRes.Progress.Failed)
+
+	rowErrors, err := txnService.ListBulkJobErrors(ctx, signupRes.ID, bulkJob.JobID)
+	require.NoError(t, err)
+	require.Len(t, rowErrors, 2)
+	for _, rowErr := range rowErrors {
+		assert.Equal(t, repository.BulkRowErrorReasonDUPLICATEREFERENCE, rowErr.Reason)
+	}
+}
+
+func TestUserTimezones(t *testing.T) {
+	userService, txnService, queries := setupTestServices(t)
+	ctx := context.Background()
+
+	email := "timezoneuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Timezone Test User",
+		Email:    email,
+		Password: "password123",
+		Timezone: "Asia/Kolkata",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Kolkata", signupRes.Timezone)
+
+	// live transactions use the client's offset when sent, the user's timezone otherwise
+	offset := -240
+	withOffset, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI", UTCOffsetMinutes: &offset})
+	require.NoError(t, err)
+	withoutOffset, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI"})
+	require.NoError(t, err)
+
+	txn, err := queries.GetTransactionByTxnID(ctx, repository.GetTransactionByTxnIDParams{ID: withOffset.TransactionID, UserID: signupRes.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int32(-240), txn.UtcOffsetMinutes)
+	txn, err = queries.GetTransactionByTxnID(ctx, repository.GetTransactionByTxnIDParams{ID: withoutOffset.TransactionID, UserID: signupRes.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int32(330), txn.UtcOffsetMinutes)
+
+	// 21:00 UTC is 2:30 AM in Kolkata; 21:00 at -04:00 stays 9 PM
+	csvContent := `amount,mode,created_at
+500.0,UPI,2023-10-01T21:00:00Z
+500.0,UPI,2023-10-01T21:00:00-04:00`
+
+	bulkJob, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, strings.NewReader(csvContent), "tz.csv", true)
+	require.NoError(t, err)
+	bulkRes := runBulkJobs(t, txnService, signupRes.ID, bulkJob.JobID)
+	assert.Equal(t, int32(2), bulkRes.Progress.Success)
+
+	profile, err := queries.GetUserProfileByUserID(ctx, signupRes.ID)
+	require.NoError(t, err)
+	require.Len(t, profile.HourHistogram, 24)
+	assert.Greater(t, profile.HourHistogram[2], 0.0)
+	assert.Greater(t, profile.HourHistogram[21], 0.0)
+}
+
+func TestOnlineProfileUpdates(t *testing.T) {
+	userService, txnService, queries := setupTestServices(t)
+	ctx := context.Background()
+
+	email := "onlineuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Online Profile User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	for _, amount := range []float64{100, 200, 300} {
+		_, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: amount, Mode: "UPI"})
+		require.NoError(t, err)
+	}
+
+	// the profile is current without waiting for the nightly rebuild
+	profile, err := queries.GetUserProfileByUserID(ctx, signupRes.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), profile.TotalTransactions)
+	assert.Equal(t, int32(3), profile.AllowedTransactions)
+	assert.InDelta(t, 200.0, profile.AverageTransactionAmount.Float64, 0.001)
+	assert.InDelta(t, 100.0, profile.StdDevTransactionAmount.Float64, 0.001)
+	assert.Equal(t, []string{"UPI"}, profile.RegisteredPaymentModes)
+
+	// the reconciliation pass agrees with the online updates
+	require.NoError(t, txnService.RebuildUserProfile(ctx, signupRes.ID))
+	reconciled, err := queries.GetUserProfileByUserID(ctx, signupRes.ID)
+	require.NoError(t, err)
+	assert.Equal(t, profile.TotalTransactions, reconciled.TotalTransactions)
+	assert.InDelta(t, profile.AverageTransactionAmount.Float64, reconciled.AverageTransactionAmount.Float64, 0.001)
+	assert.InDelta(t, profile.StdDevTransactionAmount.Float64, reconciled.StdDevTransactionAmount.Float64, 0.001)
+}
+
+// TestConcurrentScoring fires a burst of simultaneous payments from one user.
+// Scoring is serialized per user, so the frequency factor sees every earlier
+// payment of the burst and yields the same scores as sequential requests.
+func TestConcurrentScoring(t *testing.T) {
+	userService, txnService, queries := setupTestServices(t)
+	ctx := context.Background()
+
+	email := "concurrentuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Concurrent User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	const burst = 20
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, burst)
+	for range burst {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 100, Mode: "UPI"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	t.Logf("scored %d concurrent transactions in %v", burst, time.Since(start))
+
+	txns, err := queries.GetAllTransactionsByUserID(ctx, repository.GetAllTransactionsByUserIDParams{UserID: signupRes.ID, Limit: burst})
+	require.NoError(t, err)
+	require.Len(t, txns, burst)
+
+	var got []float64
+	for _, txn := range txns {
+		var scores map[string]float64
+		require.NoError(t, json.Unmarshal(txn.FactorScores, &scores))
+		got = append(got, scores[constants.TriggerFactorsFREQUENCYSPIKE])
+	}
+
+	// the k-th payment sees the k before it
+	cfg := helpers.DefaultScoringConfig()
+	var events []velocity.Event
+	var want []float64
+	for range burst {
+		risk, _ := helpers.CalculateFrequencySpikeRisk(100, velocity.Aggregate(events, repository.ModeUPI, start), cfg)
+		want = append(want, risk)
+		events = append(events, velocity.Event{Mode: repository.ModeUPI, Amount: 100, CreatedAt: start})
+	}
+
+	slices.Sort(got)
+	assert.Equal(t, want, got)
 
-	// The fact that average > 600 proves batch update occurred,
-	// because first 50 transactions alone would give avg ~545
+	profile, err := queries.GetUserProfileByUserID(ctx, signupRes.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(burst), profile.TotalTransactions, "no profile update is lost")
+}
+
+func TestNewDeviceFactor(t *testing.T) {
+	userService, txnService, _ := setupTestServices(t)
+	ctx := context.Background()
+
+	email := "deviceuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Device User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	// the first device has nothing to be compared against
+	first, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{
+		Amount:    500.0,
+		Mode:      "UPI",
+		DeviceID:  "phone-1",
+		IPAddress: "203.0.113.7",
+		UserAgent: "FraudLiteApp/1.0",
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, first.TriggeredFactors, constants.TriggerFactorsNEWDEVICE)
+
+	known, err := txnService.EvaluateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI", DeviceID: "phone-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, known.FactorScores()[constants.TriggerFactorsNEWDEVICE])
+
+	unknown, err := txnService.EvaluateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI", DeviceID: "laptop-1"})
+	require.NoError(t, err)
+	assert.Greater(t, unknown.FactorScores()[constants.TriggerFactorsNEWDEVICE], constants.ThresholdNewDevice)
+	assert.Contains(t, unknown.TriggeredFactors, constants.TriggerFactorsNEWDEVICE)
+}
+
+func TestImpossibleTravelFactor(t *testing.T) {
+	userService, txnService, _ := setupTestServices(t)
+	ctx := context.Background()
+
+	email := "traveluser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Travel User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	coordinates := func(lat, lon float64) specs.CreateTransactionRequest {
+		return specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI", Latitude: &lat, Longitude: &lon}
+	}
+
+	// Mumbai, with nothing to be compared against
+	first, err := txnService.CreateTransaction(ctx, signupRes.ID, coordinates(19.076, 72.8777))
+	require.NoError(t, err)
+	assert.NotContains(t, first.TriggeredFactors, constants.TriggerFactorsIMPOSSIBLETRAVEL)
+
+	// Thane is within the same metro area
+	nearby, err := txnService.EvaluateTransaction(ctx, signupRes.ID, coordinates(19.2183, 72.9781))
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, nearby.FactorScores()[constants.TriggerFactorsIMPOSSIBLETRAVEL])
+
+	// Delhi moments later
+	far, err := txnService.EvaluateTransaction(ctx, signupRes.ID, coordinates(28.6139, 77.209))
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, far.FactorScores()[constants.TriggerFactorsIMPOSSIBLETRAVEL])
+	assert.Contains(t, far.TriggeredFactors, constants.TriggerFactorsIMPOSSIBLETRAVEL)
+}
+
+func TestNewPayeeFactor(t *testing.T) {
+	userService, txnService, _ := setupTestServices(t)
+	ctx := context.Background()
+
+	email := "payeeuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Payee User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	pay := func(payee string) specs.CreateTransactionResponse {
+		res, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI", PayeeID: payee})
+		require.NoError(t, err)
+		return res
+	}
+
+	// the first payee has nothing to be compared against
+	first := pay("grocer@upi")
+	assert.NotContains(t, first.TriggeredFactors, constants.TriggerFactorsNEWPAYEE)
+
+	unknown, err := txnService.EvaluateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 50000.0, Mode: "UPI", PayeeID: "stranger@upi"})
+	require.NoError(t, err)
+	assert.Contains(t, unknown.TriggeredFactors, constants.TriggerFactorsNEWPAYEE)
+
+	// paid repeatedly, the payee becomes trusted
+	for range constants.TrustedPayeeMinPayments - 1 {
+		pay("grocer@upi")
+	}
+	trusted, err := txnService.EvaluateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI", PayeeID: "grocer@upi"})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, trusted.FactorScores()[constants.TriggerFactorsNEWPAYEE])
+}
+
+func TestListEntriesDecideTransactions(t *testing.T) {
+	userService, txnService, queries := setupTestServices(t)
+	listService := service.NewListService(queries, worker.InitializeRedis(), zap.NewNop())
+	ctx := context.Background()
+
+	email := "listuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "List User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	suffix := time.Now().Format("20060102150405.000000")
+	mule := "mule" + suffix + "@upi"
+	payroll := "payroll" + suffix + "@upi"
+
+	deny, err := listService.CreateEntry(ctx, signupRes.ID, specs.CreateListEntryRequest{
+		ListType: "DENY", Entity: "PAYEE", Value: mule, Reason: "confirmed mule account",
+	})
+	require.NoError(t, err)
+	_, err = listService.CreateEntry(ctx, signupRes.ID, specs.CreateListEntryRequest{
+		ListType: "ALLOW", Entity: "PAYEE", Value: payroll,
+	})
+	require.NoError(t, err)
+
+	blocked, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 100.0, Mode: "UPI", PayeeID: mule})
+	require.NoError(t, err)
+	assert.Equal(t, repository.TransactionDecisionBLOCK, blocked.Decision)
+	require.NotNil(t, blocked.ListEntry)
+	assert.Equal(t, deny.ID, blocked.ListEntry.ID)
+
+	stored, err := queries.GetTransactionByID(ctx, blocked.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, deny.ID, stored.ListEntryID.Int32)
+
+	// far above anything the heuristics would allow for a new user
+	allowed, err := txnService.EvaluateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 900000.0, Mode: "CARD", PayeeID: payroll})
+	require.NoError(t, err)
+	assert.Equal(t, repository.TransactionDecisionALLOW, allowed.Decision)
+
+	// removing the entry takes effect despite the cache
+	_, err = listService.RemoveEntry(ctx, signupRes.ID, deny.ID)
+	require.NoError(t, err)
+	res, err := txnService.EvaluateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 100.0, Mode: "UPI", PayeeID: mule})
+	require.NoError(t, err)
+	assert.Nil(t, res.ListEntry)
+}
+
+func TestListEntriesDecideBulkRows(t *testing.T) {
+	userService, txnService, queries := setupTestServices(t)
+	listService := service.NewListService(queries, worker.InitializeRedis(), zap.NewNop())
+	ctx := context.Background()
+
+	email := "bulklistuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Bulk List User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	deny, err := listService.CreateEntry(ctx, signupRes.ID, specs.CreateListEntryRequest{
+		ListType: "DENY", Entity: "USER", Value: strconv.Itoa(int(signupRes.ID)), Reason: "account takeover",
+	})
+	require.NoError(t, err)
+
+	csvContent := `amount,mode,created_at
+100.0,UPI,2024-01-01T10:00:00Z
+200.0,UPI,2024-01-01T11:00:00Z`
+
+	bulkJob, err := txnService.ProcessBulkTransactions(ctx, signupRes.ID, strings.NewReader(csvContent), "denied.csv", false)
+	require.NoError(t, err)
+	bulkRes := runBulkJobs(t, txnService, signupRes.ID, bulkJob.JobID)
+	require.Equal(t, int32(2), bulkRes.Progress.Success)
+
+	txns, err := queries.GetAllTransactionsByUserID(ctx, repository.GetAllTransactionsByUserIDParams{
+		UserID: signupRes.ID,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, txns, 2)
+	for _, txn := range txns {
+		assert.Equal(t, repository.TransactionDecisionBLOCK, txn.Decision)
+		stored, err := queries.GetTransactionByID(ctx, txn.ID)
+		require.NoError(t, err)
+		assert.Equal(t, deny.ID, stored.ListEntryID.Int32)
+	}
+}
+
+func TestRulesDecideTransactions(t *testing.T) {
+	userService, _, queries := setupTestServices(t)
+	ruleService := service.NewRuleService(queries, zap.NewNop())
+	ctx := context.Background()
+
+	email := "ruleuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Rule User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	// rules apply to every user, so they are scoped to payees of this test
+	suffix := time.Now().Format("20060102150405.000000")
+	casino := "casino" + suffix + "@upi"
+	exchange := "exchange" + suffix + "@upi"
+
+	create := func(req specs.RuleRequest) specs.RuleResponse {
+		rule, err := ruleService.CreateRule(ctx, signupRes.ID, req)
+		require.NoError(t, err)
+		t.Cleanup(func() { ruleService.DeleteRule(context.Background(), signupRes.ID, rule.ID) })
+		return rule
+	}
+	block := create(specs.RuleRequest{
+		Name: "block casino", Expression: `txn.payee_id == "` + casino + `" && txn.amount > 1000`,
+		Action: "SET_DECISION", Decision: "BLOCK", Priority: 10,
+	})
+	tag := create(specs.RuleRequest{
+		Name: "tag gambling", Expression: `txn.payee_id in ["` + casino + `", "` + exchange + `"]`,
+		Action: "ADD_TAG", Tag: "gambling",
+	})
+	score := create(specs.RuleRequest{
+		Name: "score exchange", Expression: `txn.payee_id == "` + exchange + `"`,
+		Action: "ADD_SCORE", ScoreDelta: 50,
+	})
+
+	_, err = ruleService.CreateRule(ctx, signupRes.ID, specs.RuleRequest{
+		Name: "broken", Expression: `txn.country == "IN"`, Action: "ADD_TAG", Tag: "x",
+	})
+	assert.ErrorIs(t, err, pkgerrors.ErrInvalidRuleExpression)
+
+	// a fresh service loads the rules created above
+	_, txnService, _ := setupTestServices(t)
+
+	blocked, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 5000.0, Mode: "UPI", PayeeID: casino})
+	require.NoError(t, err)
+	assert.Equal(t, repository.TransactionDecisionBLOCK, blocked.Decision)
+	assert.Equal(t, []string{"gambling"}, blocked.Tags)
+
+	stored, err := queries.GetTransactionByID(ctx, blocked.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{block.ID, tag.ID}, stored.MatchedRules)
+	assert.Equal(t, []string{"gambling"}, stored.Tags)
+
+	unscored, err := txnService.EvaluateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI", PayeeID: exchange})
+	require.NoError(t, err)
+	baseline, err := txnService.EvaluateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI", PayeeID: "other" + suffix + "@upi"})
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, unscored.RuleScoreDelta)
+	assert.Equal(t, []int32{tag.ID, score.ID}, unscored.MatchedRuleIDs())
+	assert.Greater(t, unscored.FinalRiskScore, baseline.FinalRiskScore)
+	assert.Empty(t, baseline.MatchedRules)
+}
+
+func TestShadowScoring(t *testing.T) {
+	userService, _, queries := setupTestServices(t)
+	ctx := context.Background()
+	_, pool, err := repository.InitializeDatabase(ctx)
+	require.NoError(t, err)
+	configService := service.NewScoringConfigService(queries, pool, zap.NewNop())
+
+	email := "shadowuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Shadow User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	challenger := *helpers.DefaultScoringConfig()
+	challenger.RiskThresholdFlag = 40
+	created, err := configService.CreateConfig(ctx, signupRes.ID, specs.CreateScoringConfigRequest{
+		Description: "shadow test challenger",
+		Config:      challenger,
+	})
+	require.NoError(t, err)
+
+	shadowed, err := configService.StartShadow(ctx, created.Version)
+	require.NoError(t, err)
+	assert.True(t, shadowed.IsShadow)
+	t.Cleanup(func() { configService.StopShadow(context.Background()) })
+
+	_, err = configService.StartShadow(ctx, 1<<30)
+	assert.ErrorIs(t, err, pkgerrors.ErrScoringConfigNotFound)
+
+	// a fresh service loads the shadow config set above
+	_, txnService, _ := setupTestServices(t)
+	res, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: 500.0, Mode: "UPI"})
+	require.NoError(t, err)
+
+	// shadow decisions are stored in the background
+	var summary specs.ShadowSummaryResponse
+	require.Eventually(t, func() bool {
+		summary, err = configService.ShadowSummary(ctx, created.Version)
+		return err == nil && summary.Transactions == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	compared := 0
+	for _, n := range summary.Deltas[res.Decision] {
+		compared += n
+	}
+	assert.Equal(t, 1, compared)
+
+	require.NoError(t, configService.StopShadow(ctx))
+	assert.Nil(t, configService.ShadowConfig(ctx))
+}
+
+// otpNotifier keeps the codes sent, as the user reading the message would
+type otpNotifier struct {
+	mu   sync.Mutex
+	otps map[int32]string
+}
+
+func (n *otpNotifier) Notify(_ context.Context, msg notifier.Message) error {
+	var txnID int32
+	var otp string
+	if _, err := fmt.Sscanf(msg.Body, "Your verification code for transaction %d is %6s.", &txnID, &otp); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.otps[txnID] = otp
+	return nil
+}
+
+func TestMFAChallengeResolution(t *testing.T) {
+	userService, _, queries := setupTestServices(t)
+	ctx := context.Background()
+	_, pool, err := repository.InitializeDatabase(ctx)
+	require.NoError(t, err)
+	redisClient := worker.InitializeRedis()
+	otps := &otpNotifier{otps: map[int32]string{}}
+	mfaService := service.NewMFAService(queries, pool, redisClient, otps, zap.NewNop())
+
+	email := "mfauser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "MFA User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	challenged := func(deviceID string) int32 {
+		txn, err := queries.CreateTransaction(ctx, repository.CreateTransactionParams{
+			UserID:           signupRes.ID,
+			Amount:           750.0,
+			Mode:             repository.ModeCARD,
+			RiskScore:        70,
+			TriggeredFactors: []string{constants.TriggerFactorsNEWDEVICE},
+			Decision:         repository.TransactionDecisionMFAREQUIRED,
+			FactorScores:     []byte(`{}`),
+			CreatedAt:        pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+			DeviceID:         pgtype.Text{String: deviceID, Valid: true},
+			PayeeID:          pgtype.Text{String: deviceID + "@upi", Valid: true},
+			MatchedRules:     []int32{},
+			Tags:             []string{},
+		})
+		require.NoError(t, err)
+		_, err = mfaService.IssueChallenge(ctx, signupRes.ID, txn.ID)
+		require.NoError(t, err)
+		return txn.ID
+	}
+
+	t.Run("verified code allows and learns the device", func(t *testing.T) {
+		txnID := challenged("verified-phone")
+
+		res, err := mfaService.VerifyChallenge(ctx, signupRes.ID, txnID, specs.VerifyMFARequest{OTP: otps.otps[txnID]})
+		require.NoError(t, err)
+		assert.Equal(t, repository.TransactionDecisionALLOW, res.Decision)
+
+		devices, err := queries.GetDeviceHistory(ctx, repository.GetDeviceHistoryParams{UserID: signupRes.ID, DeviceID: "verified-phone"})
+		require.NoError(t, err)
+		assert.True(t, devices.Known)
+
+		profile, err := queries.GetUserProfileByUserID(ctx, signupRes.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), profile.AllowedTransactions)
+		assert.Contains(t, profile.RegisteredPaymentModes, string(repository.ModeCARD))
+	})
+
+	t.Run("expired challenge blocks", func(t *testing.T) {
+		txnID := challenged("expired-phone")
+		otp := otps.otps[txnID]
+		require.NoError(t, redisClient.Del(ctx, fmt.Sprintf("%s%d", constants.MFAChallengeKeyPrefix, txnID)).Err())
+
+		res, err := mfaService.VerifyChallenge(ctx, signupRes.ID, txnID, specs.VerifyMFARequest{OTP: otp})
+		require.NoError(t, err)
+		assert.Equal(t, repository.TransactionDecisionBLOCK, res.Decision)
+
+		exists, err := redisClient.Exists(ctx, fmt.Sprintf("%s%d", constants.MFAChallengeKeyPrefix, txnID)).Result()
+		require.NoError(t, err)
+		assert.Zero(t, exists, "no attempt counter is left behind")
+	})
+
+	t.Run("too many wrong codes block", func(t *testing.T) {
+		txnID := challenged("guessed-phone")
+		wrong := "000000"
+		if otps.otps[txnID] == wrong {
+			wrong = "111111"
+		}
+
+		for range constants.MFAMaxAttempts - 1 {
+			_, err := mfaService.VerifyChallenge(ctx, signupRes.ID, txnID, specs.VerifyMFARequest{OTP: wrong})
+			assert.ErrorIs(t, err, pkgerrors.ErrInvalidOTP)
+		}
+		res, err := mfaService.VerifyChallenge(ctx, signupRes.ID, txnID, specs.VerifyMFARequest{OTP: wrong})
+		require.NoError(t, err)
+		assert.Equal(t, repository.TransactionDecisionBLOCK, res.Decision)
+
+		// the right code no longer helps
+		_, err = mfaService.VerifyChallenge(ctx, signupRes.ID, txnID, specs.VerifyMFARequest{OTP: otps.otps[txnID]})
+		assert.ErrorIs(t, err, pkgerrors.ErrMFANotPending)
+
+		devices, err := queries.GetDeviceHistory(ctx, repository.GetDeviceHistoryParams{UserID: signupRes.ID, DeviceID: "guessed-phone"})
+		require.NoError(t, err)
+		assert.False(t, devices.Known)
+	})
+}
+
+func TestCaseLabelsRebuildProfile(t *testing.T) {
+	userService, txnService, queries := setupTestServices(t)
+	ctx := context.Background()
+	_, pool, err := repository.InitializeDatabase(ctx)
+	require.NoError(t, err)
+	caseService := service.NewCaseService(queries, pool, zap.NewNop())
+
+	email := "caseuser_" + time.Now().Format("20060102150405") + "@example.com"
+	signupRes, err := userService.Signup(ctx, specs.UserSignupRequest{
+		Name:     "Case User",
+		Email:    email,
+		Password: "password123",
+	})
+	require.NoError(t, err)
+
+	for _, amount := range []float64{100, 200, 300} {
+		_, err := txnService.CreateTransaction(ctx, signupRes.ID, specs.CreateTransactionRequest{Amount: amount, Mode: "UPI"})
+		require.NoError(t, err)
+	}
+
+	// opens a case for a reviewed transaction and returns the case
+	reviewed := func(amount float64, decision repository.TransactionDecision, deviceID string) specs.FraudCaseResponse {
+		createdAt := pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
+		txn, err := queries.CreateTransaction(ctx, repository.CreateTransactionParams{
+			UserID:           signupRes.ID,
+			Amount:           amount,
+			Mode:             repository.ModeUPI,
+			RiskScore:        50,
+			TriggeredFactors: []string{constants.TriggerFactorsAMOUNTDEVIATION},
+			Decision:         decision,
+			FactorScores:     []byte(`{}`),
+			CreatedAt:        createdAt,
+			DeviceID:         pgtype.Text{String: deviceID, Valid: true},
+			PayeeID:          pgtype.Text{String: deviceID + "@upi", Valid: true},
+			MatchedRules:     []int32{},
+			Tags:             []string{},
+		})
+		require.NoError(t, err)
+		if decision == repository.TransactionDecisionFLAG {
+			require.NoError(t, queries.UpsertKnownDevice(ctx, repository.UpsertKnownDeviceParams{UserID: signupRes.ID, DeviceID: deviceID, FirstSeenAt: createdAt}))
+			require.NoError(t, queries.UpsertKnownPayee(ctx, repository.UpsertKnownPayeeParams{UserID: signupRes.ID, PayeeID: deviceID + "@upi", FirstSeenAt: createdAt}))
+		}
+		require.NoError(t, queries.OpenFraudCase(ctx, repository.OpenFraudCaseParams{TransactionID: txn.ID, UserID: signupRes.ID}))
+
+		cases, err := caseService.ListCases(ctx, specs.ListFraudCasesRequest{Status: string(repository.CaseStatusOPEN), Limit: 1000})
+		require.NoError(t, err)
+		i := slices.IndexFunc(cases, func(c specs.FraudCaseResponse) bool { return c.TransactionID == txn.ID })
+		require.NotEqual(t, -1, i)
+		return cases[i]
+	}
+	flagged := reviewed(900, repository.TransactionDecisionFLAG, "attacker-phone")
+	blocked := reviewed(5000, repository.TransactionDecisionBLOCK, "own-phone")
+
+	knownDevice := func(deviceID string) bool {
+		devices, err := queries.GetDeviceHistory(ctx, repository.GetDeviceHistoryParams{UserID: signupRes.ID, DeviceID: deviceID})
+		require.NoError(t, err)
+		return devices.Known
+	}
+	require.True(t, knownDevice("attacker-phone"))
+
+	payments := func(payeeID string) int32 {
+		payees, err := queries.GetPayeeHistory(ctx, repository.GetPayeeHistoryParams{UserID: signupRes.ID, PayeeID: payeeID})
+		require.NoError(t, err)
+		return payees.Payments
+	}
+	require.Equal(t, int32(1), payments("attacker-phone@upi"))
+
+	profile := func() repository.GetUserProfileByUserIDRow {
+		p, err := queries.GetUserProfileByUserID(ctx, signupRes.ID)
+		require.NoError(t, err)
+		return p
+	}
+
+	// unlabelled, the flagged transaction counts and the blocked one does not
+	require.NoError(t, queries.RecalculateUserProfile(ctx, signupRes.ID))
+	assert.Equal(t, int32(4), profile().AllowedTransactions)
+	assert.InDelta(t, 375.0, profile().AverageTransactionAmount.Float64, 0.001)
+
+	// cases only go to analysts and admins
+	_, err = caseService.AssignCase(ctx, signupRes.ID, flagged.ID, specs.AssignFraudCaseRequest{AnalystID: signupRes.ID})
+	assert.ErrorIs(t, err, pkgerrors.ErrInvalidAssignee)
+
+	// confirmed fraud stops counting as normal behaviour
+	_, err = caseService.ResolveCase(ctx, signupRes.ID, flagged.ID, specs.ResolveFraudCaseRequest{Disposition: string(repository.FraudLabelCONFIRMEDFRAUD)})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), profile().AllowedTransactions)
+	assert.InDelta(t, 200.0, profile().AverageTransactionAmount.Float64, 0.001)
+	assert.False(t, knownDevice("attacker-phone"), "the fraudster's device is no longer trusted")
+	assert.Zero(t, payments("attacker-phone@upi"), "the fraudster's payee is no longer trusted")
+
+	// a cleared false positive starts counting
+	_, err = caseService.ResolveCase(ctx, signupRes.ID, blocked.ID, specs.ResolveFraudCaseRequest{Disposition: string(repository.FraudLabelFALSEPOSITIVE)})
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), profile().AllowedTransactions)
+	assert.InDelta(t, 1400.0, profile().AverageTransactionAmount.Float64, 0.001)
+	assert.Equal(t, int32(5), profile().TotalTransactions)
 }
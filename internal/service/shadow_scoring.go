@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"go.uber.org/zap"
+)
+
+// shadowScore scores a stored transaction again with the shadow config, if
+// one is set, and stores the challenger's outcome next to the champion's. It
+// runs in the background and never delays the caller: when
+// constants.ShadowScoringWorkers scorings are already running the transaction
+// is not shadowed, and a challenger that takes longer than
+// constants.ShadowScoringBudget is dropped. Transactions decided by a list
+// entry are decided the same way by any config and are not shadowed.
+func (s *TransactionService) shadowScore(ctx context.Context, txn repository.CreateTransactionRow, champion specs.FraudAnalysisResult, input scoringInput) {
+	if champion.ListEntry != nil {
+		return
+	}
+
+	select {
+	case s.shadowSlots <- struct{}{}:
+	default:
+		s.logger.Warn("shadow scoring busy, transaction not shadowed", zap.Int32("txn_id", txn.ID))
+		return
+	}
+
+	params := repository.CreateShadowDecisionParams{
+		TransactionID:         txn.ID,
+		ChampionConfigVersion: configVersionParam(champion.ConfigVersion),
+		ChampionRiskScore:     txn.RiskScore,
+		ChampionDecision:      txn.Decision,
+	}
+
+	go func() {
+		defer func() { <-s.shadowSlots }()
+
+		// the request may be over before the shadow decision is stored
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), constants.ShadowStoreTimeout)
+		defer cancel()
+
+		cfg := s.configs.ShadowConfig(ctx)
+		if cfg == nil {
+			return
+		}
+
+		start := time.Now()
+		challenger := input.analyze(ctx, cfg)
+		latency := time.Since(start)
+		if latency > constants.ShadowScoringBudget {
+			s.logger.Warn("shadow scoring over budget, result dropped",
+				zap.Int32("txn_id", txn.ID),
+				zap.Int32("version", cfg.Version),
+				zap.Duration("latency", latency),
+			)
+			return
+		}
+
+		factorScores, err := json.Marshal(challenger.FactorScores())
+		if err != nil {
+			s.logger.Error("failed to encode shadow factor scores", zap.Int32("txn_id", txn.ID), zap.Error(err))
+			return
+		}
+
+		params.ConfigVersion = cfg.Version
+		params.RiskScore = challenger.FinalRiskScore
+		params.Decision = challenger.Decision
+		params.TriggeredFactors = challenger.TriggeredFactors
+		params.FactorScores = factorScores
+		params.LatencyMicros = int32(latency.Microseconds())
+		if err := s.queries.CreateShadowDecision(ctx, params); err != nil {
+			s.logger.Error("failed to store shadow decision", zap.Int32("txn_id", txn.ID), zap.Error(err))
+		}
+	}()
+}
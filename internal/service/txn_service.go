@@ -2,319 +2,602 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"encoding/csv"
+	"encoding/json"
 	"errors"
-	"io"
-	"strconv"
-	"strings"
+	"net/netip"
 	"time"
 
-	"github.com/xuri/excelize/v2"
-
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
 	pkgerrors "github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/geoip"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/velocity"
 	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
+type scoringConfigProvider interface {
+	ActiveConfig(ctx context.Context) *specs.ScoringConfig
+	ShadowConfig(ctx context.Context) *specs.ScoringConfig
+}
+
+type mfaChallenger interface {
+	IssueChallenge(ctx context.Context, userID int32, txnID int32) (time.Time, error)
+}
+
+type listMatcher interface {
+	MatchEntry(ctx context.Context, userID int32, req specs.CreateTransactionRequest) *specs.ListEntryResponse
+}
+
+type ruleProvider interface {
+	ActiveRules(ctx context.Context) []specs.CompiledRule
+}
+
 type TransactionService struct {
-	queries *repository.Queries
-	db      *pgxpool.Pool
-	logger  *zap.Logger
+	queries    *repository.Queries
+	db         *pgxpool.Pool
+	configs    scoringConfigProvider
+	challenges mfaChallenger
+	velocity   velocity.Store
+	locator    geoip.Locator
+	lists      listMatcher
+	rules      ruleProvider
+	logger     *zap.Logger
+
+	// shadowSlots bounds the shadow scorings running at a time
+	shadowSlots chan struct{}
 }
 
-func NewTransactionService(queries *repository.Queries, db *pgxpool.Pool, logger *zap.Logger) *TransactionService {
+func NewTransactionService(queries *repository.Queries, db *pgxpool.Pool, configs scoringConfigProvider, challenges mfaChallenger, velocityStore velocity.Store, locator geoip.Locator, lists listMatcher, rules ruleProvider, logger *zap.Logger) *TransactionService {
 	return &TransactionService{
-		queries: queries,
-		db:      db,
-		logger:  logger,
+		queries:    queries,
+		db:         db,
+		configs:    configs,
+		challenges: challenges,
+		velocity:   velocityStore,
+		locator:    locator,
+		lists:      lists,
+		rules:      rules,
+		logger:     logger,
+
+		shadowSlots: make(chan struct{}, constants.ShadowScoringWorkers),
 	}
 }
 
 func (s *TransactionService) CreateTransaction(ctx context.Context, userID int32, req specs.CreateTransactionRequest) (specs.CreateTransactionResponse, error) {
-	// 1. Get User Profile
-	profile, err := s.queries.GetUserProfileByUserID(ctx, userID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) || err.Error() == "no rows in result set" {
-			// Create a default empty profile wrapped in the struct
-			profile = repository.GetUserProfileByUserIDRow{
-				UserID: userID,
-			}
-		} else {
-			s.logger.Error("failed to get user profile", zap.Error(err))
-			// Continue with empty profile or return error?
-			// For robustness, let's continue with empty profile but log error.
-			// Actually, sqlc with pgx returns pgx.ErrNoRows which might need specific handling or just matching string.
-			// But since we are using pgx via sqlc, better check.
-			// For now, let's assume we proceed with "cold start" if retrieval fails.
-			profile = repository.GetUserProfileByUserIDRow{UserID: userID}
+	loc := s.userLocation(ctx, userID)
+	location := s.locateTransaction(req)
+	reference := pgtype.Text{String: req.IdempotencyKey, Valid: req.IdempotencyKey != ""}
+
+	// 0. Replay the original outcome when the idempotency key was seen before
+	if reference.Valid {
+		res, err := s.replayTransaction(ctx, userID, req, reference, location)
+		if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+			return res, err
 		}
 	}
 
-	// Convert repository.GetUserProfileByUserIDRow to repository.UserProfileBehavior
-	// because helper functions expect *repository.UserProfileBehavior
-	domainProfile := &repository.UserProfileBehavior{
-		UserID:                            profile.UserID,
-		AverageTransactionAmount:          profile.AverageTransactionAmount,
-		StdDevTransactionAmount:           profile.StdDevTransactionAmount,
-		MaxTransactionAmountSeen:          profile.MaxTransactionAmountSeen,
-		AverageNumberOfTransactionsPerDay: profile.AverageNumberOfTransactionsPerDay,
-		UsualTransactionStartHour:         profile.UsualTransactionStartHour,
-		UsualTransactionEndHour:           profile.UsualTransactionEndHour,
-		TotalTransactions:                 profile.TotalTransactions,
-		AllowedTransactions:               profile.AllowedTransactions,
-		UpdatedAt:                         profile.UpdatedAt,
+	// Scoring and storing hold the user's lock, so concurrent transactions of
+	// the user are scored one after the other, each seeing the ones before it
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return specs.CreateTransactionResponse{}, err
 	}
-	// Convert []string to []Mode manually? repository.GetUserProfileByUserIDRow has []string for modes
-	// but repository.UserProfileBehavior has []Mode.
-	for _, m := range profile.RegisteredPaymentModes {
-		domainProfile.RegisteredPaymentModes = append(domainProfile.RegisteredPaymentModes, repository.Mode(m))
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.LockUserProfile(ctx, userID); err != nil {
+		s.logger.Error("failed to lock user profile", zap.Int32("user_id", userID), zap.Error(err))
+		return specs.CreateTransactionResponse{}, err
 	}
+	// taken under the lock, so created_at follows the order of scoring
+	now := helpers.LocalTime(time.Now(), loc, req.UTCOffsetMinutes)
 
-	// 2. Count recent transactions (last 24h)
-	count, err := s.queries.CountRecentTransactions(ctx, repository.CountRecentTransactionsParams{
-		UserID: userID,
-		Secs:   3600,
-	})
+	// 1-3. Score against the live profile
+	result, input, err := s.analyzeTransaction(ctx, qtx, userID, req, now, location)
+	if err != nil {
+		return specs.CreateTransactionResponse{}, err
+	}
+
+	factorScores, err := json.Marshal(result.FactorScores())
 	if err != nil {
-		s.logger.Error("failed to count recent transactions", zap.Error(err))
-		count = 0
-	}
-
-	// 3. Analyze
-	result := helpers.AnalyzeTransaction(&req, domainProfile, int(count), time.Now())
-
-	// 4. Create Transaction in DB
-	txn, err := s.queries.CreateTransaction(ctx, repository.CreateTransactionParams{
-		UserID:                  userID,
-		Amount:                  req.Amount,
-		Mode:                    repository.Mode(req.Mode),
-		RiskScore:               result.FinalRiskScore,
-		Column5:                 result.TriggeredFactors, // database column is triggered_factors, param name might be Column5 due to sqlc naming?
-		Decision:                result.Decision,
-		AmountDeviationScore:    int32(result.AmountRisk),
-		FrequencyDeviationScore: int32(result.FrequencyRisk),
-		ModeDeviationScore:      int32(result.ModeRisk),
-		TimeDeviationScore:      int32(result.TimeRisk),
-		CreatedAt:               pgtype.Timestamp{Time: time.Now(), Valid: true},
+		return specs.CreateTransactionResponse{}, err
+	}
+	explanation := result.Explain()
+	explanationJSON, err := json.Marshal(explanation)
+	if err != nil {
+		return specs.CreateTransactionResponse{}, err
+	}
+
+	// 4. Create Transaction in DB and apply it to the profile
+	latitude, longitude := locationParams(location)
+	txn, err := qtx.CreateTransaction(ctx, repository.CreateTransactionParams{
+		UserID:            userID,
+		Amount:            req.Amount,
+		Mode:              repository.Mode(req.Mode),
+		RiskScore:         result.FinalRiskScore,
+		TriggeredFactors:  result.TriggeredFactors,
+		Decision:          result.Decision,
+		FactorScores:      factorScores,
+		ConfigVersion:     configVersionParam(result.ConfigVersion),
+		CreatedAt:         pgtype.Timestamp{Time: now.UTC(), Valid: true},
+		ExternalReference: reference,
+		UtcOffsetMinutes:  helpers.UTCOffsetMinutes(now),
+		DeviceID:          textParam(req.DeviceID),
+		IpAddress:         ipAddressParam(req.IPAddress),
+		UserAgent:         textParam(req.UserAgent),
+		Latitude:          latitude,
+		Longitude:         longitude,
+		PayeeID:           textParam(req.PayeeID),
+		ListEntryID:       listEntryParam(result.ListEntry),
+		Explanation:       explanationJSON,
+		MatchedRules:      result.MatchedRuleIDs(),
+		Tags:              result.Tags,
 	})
 
 	if err != nil {
+		if reference.Valid && errors.Is(err, pgx.ErrNoRows) {
+			// a concurrent request with the same key inserted first
+			tx.Rollback(ctx)
+			return s.replayTransaction(ctx, userID, req, reference, location)
+		}
 		s.logger.Error("failed to create transaction", zap.Error(err))
 		return specs.CreateTransactionResponse{}, err
 	}
 
-	return specs.CreateTransactionResponse{
+	if _, err := applyToProfile(ctx, qtx, txn, now); err != nil {
+		s.logger.Error("failed to update user profile", zap.Int32("user_id", userID), zap.Error(err))
+		return specs.CreateTransactionResponse{}, err
+	}
+
+	// recorded before the lock is released so the user's next transaction sees
+	// it. Should the commit fail, the event only overstates the velocity until
+	// it leaves the windows.
+	s.recordVelocity(ctx, userID, velocity.Event{
+		TxnID:     txn.ID,
+		Mode:      txn.Mode,
+		Amount:    txn.Amount,
+		CreatedAt: now,
+	})
+
+	if err := tx.Commit(ctx); err != nil {
+		s.logger.Error("failed to commit transaction", zap.Error(err))
+		return specs.CreateTransactionResponse{}, err
+	}
+
+	// Score it with the shadow config too, without waiting for the result
+	s.shadowScore(ctx, txn, result, input)
+
+	res := specs.CreateTransactionResponse{
 		TransactionID:    txn.ID,
 		Decision:         txn.Decision,
 		RiskScore:        txn.RiskScore,
 		TriggeredFactors: txn.TriggeredFactors,
 		CreatedAt:        txn.CreatedAt.Time,
-	}, nil
-}
+		ListEntry:        result.ListEntry,
+		Tags:             result.Tags,
+		Explanation:      &explanation,
+	}
+	if result.ListEntry != nil {
+		s.logger.Info("transaction decided by list entry",
+			zap.Int32("txn_id", txn.ID),
+			zap.Int32("entry_id", result.ListEntry.ID),
+			zap.String("decision", string(txn.Decision)),
+		)
+	}
 
-func (s *TransactionService) ProcessBulkTransactions(ctx context.Context, userID int32, reader io.Reader, filename string) (specs.BulkProcessResponse, error) {
-	var recordIterator func() ([]string, error)
-	var closeFunc func()
+	// 5. Queue suspicious transactions for analyst review
+	if needsReview(txn.Decision) {
+		openFraudCase(ctx, s.queries, s.logger, userID, txn.ID, reviewNotes(result))
+	}
 
-	if strings.HasSuffix(strings.ToLower(filename), ".xlsx") {
-		f, err := excelize.OpenReader(reader)
+	// 6. Challenge the user when the decision asks for MFA. If the challenge
+	// cannot be issued the transaction stays MFA_REQUIRED and is blocked on
+	// verification like an expired challenge.
+	if txn.Decision == repository.TransactionDecisionMFAREQUIRED {
+		expiresAt, err := s.challenges.IssueChallenge(ctx, userID, txn.ID)
 		if err != nil {
-			return specs.BulkProcessResponse{}, pkgerrors.ErrFailureInParsingExcel
+			s.logger.Error("failed to issue mfa challenge", zap.Int32("txn_id", txn.ID), zap.Error(err))
+		} else {
+			res.MFAExpiresAt = &expiresAt
 		}
+	}
+
+	return res, nil
+}
+
+// applyToProfile updates the user's profile, known devices and known payees
+// with a transaction just inserted through qtx and returns the updated
+// profile. The profile row is locked and re-read first, so concurrent updates
+// are applied on top of each other rather than overwritten, also by callers
+// that do not hold the user's lock.
+// createdAt is the transaction's local time.
+func applyToProfile(ctx context.Context, qtx *repository.Queries, txn repository.CreateTransactionRow, createdAt time.Time) (*repository.UserProfileBehavior, error) {
+	return updateProfile(ctx, qtx, txn, func(profile *repository.UserProfileBehavior) {
+		helpers.ApplyTransactionToProfile(profile, txn.Amount, txn.Mode, createdAt, txn.Decision)
+	})
+}
+
+// applyVerifiedToProfile updates the user's profile, known devices and known
+// payees with a transaction allowed after MFA. It was counted when stored as
+// MFA_REQUIRED; its amount, mode, hour, device and payee are learnt now.
+func applyVerifiedToProfile(ctx context.Context, qtx *repository.Queries, txn repository.Transaction) error {
+	createdAt := txn.CreatedAt.Time.In(time.FixedZone("", int(txn.UtcOffsetMinutes)*60))
+	_, err := updateProfile(ctx, qtx, repository.CreateTransactionRow{
+		ID:        txn.ID,
+		UserID:    txn.UserID,
+		Amount:    txn.Amount,
+		Mode:      txn.Mode,
+		Decision:  txn.Decision,
+		CreatedAt: txn.CreatedAt,
+		DeviceID:  txn.DeviceID,
+		IpAddress: txn.IpAddress,
+		UserAgent: txn.UserAgent,
+		PayeeID:   txn.PayeeID,
+	}, func(profile *repository.UserProfileBehavior) {
+		helpers.ApplyLegitimateTransactionToProfile(profile, txn.Amount, txn.Mode, createdAt)
+	})
+	return err
+}
+
+// updateProfile applies a transaction to the locked profile with apply, then
+// stores the profile and, for legitimate transactions, the known device and payee
+func updateProfile(ctx context.Context, qtx *repository.Queries, txn repository.CreateTransactionRow, apply func(profile *repository.UserProfileBehavior)) (*repository.UserProfileBehavior, error) {
+	profile := &repository.UserProfileBehavior{UserID: txn.UserID}
+	row, err := qtx.GetUserProfileByUserIDForUpdate(ctx, txn.UserID)
+	if err == nil {
+		profile = helpers.MapDBProfileToDomain(repository.GetUserProfileByUserIDRow(row))
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
 
-		// assuming first sheet
-		sheetName := f.GetSheetName(0)
-		rows, err := f.Rows(sheetName)
+	apply(profile)
+
+	// only devices and payees with legitimate transactions become known
+	legitimate := txn.Decision == repository.TransactionDecisionALLOW || txn.Decision == repository.TransactionDecisionFLAG
+	if txn.DeviceID.Valid && legitimate {
+		err := qtx.UpsertKnownDevice(ctx, repository.UpsertKnownDeviceParams{
+			UserID:        txn.UserID,
+			DeviceID:      txn.DeviceID.String,
+			FirstSeenAt:   txn.CreatedAt,
+			LastIpAddress: txn.IpAddress,
+			LastUserAgent: txn.UserAgent,
+		})
 		if err != nil {
-			return specs.BulkProcessResponse{}, pkgerrors.ErrFailureInParsingExcel
+			return nil, err
 		}
-
-		headers, err := rows.Columns()
+	}
+	if txn.PayeeID.Valid && legitimate {
+		err := qtx.UpsertKnownPayee(ctx, repository.UpsertKnownPayeeParams{
+			UserID:      txn.UserID,
+			PayeeID:     txn.PayeeID.String,
+			FirstSeenAt: txn.CreatedAt,
+		})
 		if err != nil {
-			return specs.BulkProcessResponse{}, pkgerrors.ErrFailureInParsingExcel
+			return nil, err
 		}
+	}
 
-		if strings.ToLower(headers[0]) != "amount" || strings.ToLower(headers[1]) != "mode" || strings.ToLower(headers[2]) != "created_at" {
-			return specs.BulkProcessResponse{}, pkgerrors.ErrUnexpectedHeadersInFile
-		}
+	err = qtx.UpsertUserProfileFromProfile(ctx, repository.UpsertUserProfileFromProfileParams{
+		UserID:                            profile.UserID,
+		AverageTransactionAmount:          profile.AverageTransactionAmount,
+		StdDevTransactionAmount:           profile.StdDevTransactionAmount,
+		MaxTransactionAmountSeen:          profile.MaxTransactionAmountSeen,
+		AverageNumberOfTransactionsPerDay: profile.AverageNumberOfTransactionsPerDay,
+		RegisteredPaymentModes:            helpers.GetStringSliceFromModeSlice(profile.RegisteredPaymentModes),
+		HourHistogram:                     profile.HourHistogram,
+		WeekdayHourHistogram:              profile.WeekdayHourHistogram,
+		HistogramDecayedAt:                profile.HistogramDecayedAt,
+		AmountStats:                       jsonObject(profile.AmountStats),
+		ModeAmountStats:                   jsonObject(profile.ModeAmountStats),
+		TotalTransactions:                 profile.TotalTransactions,
+		AllowedTransactions:               profile.AllowedTransactions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
 
-		if rows.Next() {
-			_, _ = rows.Columns()
-		}
+// RebuildUserProfile recomputes the user's profile from the full transaction
+// history. It holds the user's lock, so a transaction stored meanwhile is not
+// overwritten by an aggregate computed without it.
+func (s *TransactionService) RebuildUserProfile(ctx context.Context, userID int32) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
 
-		recordIterator = func() ([]string, error) {
-			if !rows.Next() {
-				return nil, io.EOF
-			}
-			return rows.Columns()
-		}
-		closeFunc = func() {
-			f.Close()
-			rows.Close()
-		}
-	} else {
-		// CSV
-		csvReader := csv.NewReader(reader)
-		// Read header
-		headers, err := csvReader.Read()
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.LockUserProfile(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := qtx.RecalculateUserProfile(ctx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// replayTransaction returns the response of the transaction already stored
+// under the idempotency key. It returns pgx.ErrNoRows when the key is unused and
+// ErrIdempotencyKeyReused when the stored transaction differs from the request
+// in any field. MFA challenges are not issued again; the client verifies the
+// original one.
+func (s *TransactionService) replayTransaction(ctx context.Context, userID int32, req specs.CreateTransactionRequest, reference pgtype.Text, location *specs.GeoPoint) (specs.CreateTransactionResponse, error) {
+	txn, err := s.queries.GetTransactionByExternalReference(ctx, repository.GetTransactionByExternalReferenceParams{
+		UserID:            userID,
+		ExternalReference: reference,
+	})
+	if err != nil {
+		return specs.CreateTransactionResponse{}, err
+	}
+
+	if !isSameTransaction(txn, req, location) {
+		return specs.CreateTransactionResponse{}, pkgerrors.ErrIdempotencyKeyReused
+	}
+
+	res := specs.CreateTransactionResponse{
+		TransactionID:    txn.ID,
+		Decision:         txn.Decision,
+		RiskScore:        txn.RiskScore,
+		TriggeredFactors: txn.TriggeredFactors,
+		CreatedAt:        txn.CreatedAt.Time,
+		Tags:             txn.Tags,
+	}
+	if txn.ListEntryID.Valid {
+		entry, err := s.queries.GetListEntry(ctx, txn.ListEntryID.Int32)
 		if err != nil {
-			return specs.BulkProcessResponse{}, pkgerrors.ErrFailureInParsingCSV
+			return specs.CreateTransactionResponse{}, err
 		}
-		if strings.ToLower(headers[0]) != "amount" || strings.ToLower(headers[1]) != "mode" || strings.ToLower(headers[2]) != "created_at" {
-			return specs.BulkProcessResponse{}, pkgerrors.ErrUnexpectedHeadersInFile
+		listEntry := mapListEntryToResponse(entry)
+		res.ListEntry = &listEntry
+	}
+	if len(txn.Explanation) > 0 {
+		var explanation specs.DecisionExplanation
+		if err := json.Unmarshal(txn.Explanation, &explanation); err != nil {
+			return specs.CreateTransactionResponse{}, err
 		}
+		res.Explanation = &explanation
+	}
+	return res, nil
+}
 
-		recordIterator = func() ([]string, error) {
-			return csvReader.Read()
-		}
-		closeFunc = func() {}
+// isSameTransaction reports whether a request repeats every field stored with
+// the transaction. The UTC offset is only compared when the client sent one,
+// as it is otherwise taken from the user's timezone at the time.
+func isSameTransaction(txn repository.Transaction, req specs.CreateTransactionRequest, location *specs.GeoPoint) bool {
+	latitude, longitude := locationParams(location)
+	ip := ipAddressParam(req.IPAddress)
+
+	switch {
+	case txn.Amount != req.Amount, txn.Mode != repository.Mode(req.Mode):
+		return false
+	case txn.DeviceID != textParam(req.DeviceID),
+		txn.UserAgent != textParam(req.UserAgent),
+		txn.PayeeID != textParam(req.PayeeID):
+		return false
+	case (txn.IpAddress == nil) != (ip == nil), ip != nil && *txn.IpAddress != *ip:
+		return false
+	case txn.Latitude != latitude, txn.Longitude != longitude:
+		return false
+	case req.UTCOffsetMinutes != nil && txn.UtcOffsetMinutes != int32(*req.UTCOffsetMinutes):
+		return false
 	}
-	defer closeFunc()
+	return true
+}
 
-	processed := 0
-	success := 0
-	failed := 0
+// EvaluateTransaction scores a transaction exactly like CreateTransaction but
+// persists nothing, so callers can pre-check risk before committing a payment
+func (s *TransactionService) EvaluateTransaction(ctx context.Context, userID int32, req specs.CreateTransactionRequest) (specs.FraudAnalysisResult, error) {
+	now := helpers.LocalTime(time.Now(), s.userLocation(ctx, userID), req.UTCOffsetMinutes)
+	result, _, err := s.analyzeTransaction(ctx, s.queries, userID, req, now, s.locateTransaction(req))
+	return result, err
+}
 
-	// Get profile once
-	profile, err := s.queries.GetUserProfileByUserID(ctx, userID)
-	if err != nil {
+// scoringInput is what a transaction was scored on, kept to score it again
+// with the shadow config
+type scoringInput struct {
+	txn     *specs.TransactionInput
+	profile *repository.UserProfileBehavior
+	history *specs.TransactionHistory
+	rules   []specs.CompiledRule
+}
+
+// analyze scores the transaction with cfg and applies the fraud rules
+func (in scoringInput) analyze(ctx context.Context, cfg *specs.ScoringConfig) specs.FraudAnalysisResult {
+	result := helpers.AnalyzeTransaction(ctx, cfg, in.txn, in.profile, in.history)
+	return helpers.ApplyRules(cfg, in.rules, in.txn, in.profile, in.history, result)
+}
+
+// analyzeTransaction runs the read-only part of the scoring pipeline: it loads
+// the user's profile through q and recent activity, analyzes the transaction
+// with the active config and applies the fraud rules. now is in the client's
+// local time and location is nil when the transaction could not be located.
+// The inputs are returned along with the result. A user without a profile is
+// scored as a cold start; failing to read the profile or the velocity fails
+// the analysis rather than scoring without them.
+func (s *TransactionService) analyzeTransaction(ctx context.Context, q *repository.Queries, userID int32, req specs.CreateTransactionRequest, now time.Time, location *specs.GeoPoint) (specs.FraudAnalysisResult, scoringInput, error) {
+	// 1. Get User Profile
+	profile, err := q.GetUserProfileByUserID(ctx, userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
 		profile = repository.GetUserProfileByUserIDRow{UserID: userID}
+	case err != nil:
+		s.logger.Error("failed to get user profile", zap.Error(err))
+		return specs.FraudAnalysisResult{}, scoringInput{}, err
 	}
 
+	// Convert repository.GetUserProfileByUserIDRow to repository.UserProfileBehavior
+	// because helper functions expect *repository.UserProfileBehavior
 	domainProfile := &repository.UserProfileBehavior{
 		UserID:                            profile.UserID,
 		AverageTransactionAmount:          profile.AverageTransactionAmount,
 		StdDevTransactionAmount:           profile.StdDevTransactionAmount,
 		MaxTransactionAmountSeen:          profile.MaxTransactionAmountSeen,
 		AverageNumberOfTransactionsPerDay: profile.AverageNumberOfTransactionsPerDay,
-		UsualTransactionStartHour:         profile.UsualTransactionStartHour,
-		UsualTransactionEndHour:           profile.UsualTransactionEndHour,
+		HourHistogram:                     profile.HourHistogram,
+		WeekdayHourHistogram:              profile.WeekdayHourHistogram,
+		HistogramDecayedAt:                profile.HistogramDecayedAt,
+		AmountStats:                       profile.AmountStats,
+		ModeAmountStats:                   profile.ModeAmountStats,
 		TotalTransactions:                 profile.TotalTransactions,
 		AllowedTransactions:               profile.AllowedTransactions,
 		UpdatedAt:                         profile.UpdatedAt,
 	}
-	// Convert []string to []Mode manually
+	// Convert []string to []Mode manually? repository.GetUserProfileByUserIDRow has []string for modes
+	// but repository.UserProfileBehavior has []Mode.
 	for _, m := range profile.RegisteredPaymentModes {
 		domainProfile.RegisteredPaymentModes = append(domainProfile.RegisteredPaymentModes, repository.Mode(m))
 	}
 
-	batchSize := 50
-	batchCount := 0
+	// 2. Read the user's velocity in every window
+	stats, err := s.velocity.Snapshot(ctx, userID, repository.Mode(req.Mode), now)
+	if err != nil {
+		s.logger.Error("failed to read transaction velocity", zap.Error(err))
+		return specs.FraudAnalysisResult{}, scoringInput{}, err
+	}
 
-	for {
-		record, err := recordIterator()
-		if err == io.EOF {
-			break
-		}
+	// 2b. Check whether the user paid from this device before
+	devices := specs.DeviceHistory{}
+	if req.DeviceID != "" {
+		row, err := q.GetDeviceHistory(ctx, repository.GetDeviceHistoryParams{UserID: userID, DeviceID: req.DeviceID})
 		if err != nil {
-			failed++
-			continue
+			s.logger.Error("failed to read known devices", zap.Error(err))
+		} else {
+			devices = specs.DeviceHistory{Known: row.Known, KnownDevices: row.KnownDevices}
 		}
+	}
 
-		// Expected format: amount, mode, created_at
-		if len(record) < 3 {
-			failed++
-			continue
+	// 2c. Check how often the user paid this payee before
+	payees := specs.PayeeHistory{}
+	if req.PayeeID != "" {
+		row, err := q.GetPayeeHistory(ctx, repository.GetPayeeHistoryParams{UserID: userID, PayeeID: req.PayeeID})
+		if err != nil {
+			s.logger.Error("failed to read known payees", zap.Error(err))
+		} else {
+			payees = specs.PayeeHistory{Payments: row.Payments, KnownPayees: row.KnownPayees}
 		}
+	}
 
-		processed++
-
-		amount, err := strconv.ParseFloat(record[0], 64)
-		if err != nil {
-			failed++
-			continue
+	// 2d. Find where the user was at their previous transaction
+	var previous *specs.LocatedTransaction
+	if location != nil {
+		row, err := q.GetLastLocatedTransaction(ctx, userID)
+		switch {
+		case err == nil:
+			previous = &specs.LocatedTransaction{
+				GeoPoint:  specs.GeoPoint{Latitude: row.Latitude, Longitude: row.Longitude},
+				CreatedAt: row.CreatedAt.Time,
+			}
+		case !errors.Is(err, pgx.ErrNoRows):
+			s.logger.Error("failed to read previous location", zap.Error(err))
 		}
+	}
 
-		batchCount++
+	// 2e. Check the allow and deny lists; a matching entry decides the
+	// transaction without scoring it
+	listEntry := s.lists.MatchEntry(ctx, userID, req)
+
+	// 3. Analyze with the active scoring config, then apply the fraud rules
+	input := scoringInput{
+		txn: &specs.TransactionInput{
+			Amount:    req.Amount,
+			Mode:      repository.Mode(req.Mode),
+			CreatedAt: now,
+			DeviceID:  req.DeviceID,
+			Location:  location,
+			PayeeID:   req.PayeeID,
+		},
+		profile: domainProfile,
+		history: &specs.TransactionHistory{
+			Velocity:         stats,
+			Devices:          devices,
+			PreviousLocation: previous,
+			Payees:           payees,
+			ListEntry:        listEntry,
+		},
+		rules: s.rules.ActiveRules(ctx),
+	}
+	return input.analyze(ctx, s.configs.ActiveConfig(ctx)), input, nil
+}
 
-		mode := record[1]
-		// clean mode string?
-		mode = strings.ToUpper(strings.TrimSpace(mode))
+// userLocation returns the user's timezone. Lookup failures are logged and
+// score the transaction in UTC rather than failing it.
+func (s *TransactionService) userLocation(ctx context.Context, userID int32) *time.Location {
+	timezone, err := s.queries.GetUserTimezone(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to get user timezone", zap.Int32("user_id", userID), zap.Error(err))
+		return time.UTC
+	}
+	return helpers.UserLocation(timezone)
+}
 
-		createdAt, err := time.Parse(time.RFC3339, record[2])
-		if err != nil {
-			// Try other formats or fallback?
-			// The test data uses ISO8601 like 2025-10-23T22:05:19.312257
-			// RFC3339 should handle it.
-			createdAt = time.Now()
-		}
+// recordVelocity adds a stored transaction to the velocity windows. Failures
+// are only logged; the Postgres fallback still sees the transaction.
+func (s *TransactionService) recordVelocity(ctx context.Context, userID int32, e velocity.Event) {
+	if err := s.velocity.Record(ctx, userID, e); err != nil {
+		s.logger.Error("failed to record transaction velocity", zap.Int32("txn_id", e.TxnID), zap.Error(err))
+	}
+}
 
-		bulkReq := specs.CreateBulkTransactionRequest{
-			Amount:    amount,
-			Mode:      mode,
-			CreatedAt: createdAt,
-		}
+// configVersionParam maps the built-in default config (version 0) to NULL
+func configVersionParam(version int32) pgtype.Int4 {
+	return pgtype.Int4{Int32: version, Valid: version > 0}
+}
 
-		// Count passed as 0 for bulk for simplicity, or we could estimate?
-		result := helpers.AnalyzeBulkTransactions(&bulkReq, domainProfile, 0)
-
-		_, err = s.queries.CreateTransaction(ctx, repository.CreateTransactionParams{
-			UserID:                  userID,
-			Amount:                  bulkReq.Amount,
-			Mode:                    repository.Mode(bulkReq.Mode),
-			RiskScore:               result.FinalRiskScore,
-			Column5:                 result.TriggeredFactors,
-			Decision:                result.Decision,
-			AmountDeviationScore:    int32(result.AmountRisk),
-			FrequencyDeviationScore: int32(result.FrequencyRisk),
-			ModeDeviationScore:      int32(result.ModeRisk),
-			TimeDeviationScore:      int32(result.TimeRisk),
-			CreatedAt:               pgtype.Timestamp{Time: createdAt, Valid: true},
-		})
+// locateTransaction returns the coordinates the client sent, or else the GeoIP
+// location of its IP address. It is nil when neither is available.
+func (s *TransactionService) locateTransaction(req specs.CreateTransactionRequest) *specs.GeoPoint {
+	if req.Latitude != nil && req.Longitude != nil {
+		return &specs.GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude}
+	}
 
-		if err != nil {
-			s.logger.Error("failed to create bulk transaction", zap.Error(err))
-			failed++
-		} else {
-			success++
-		}
+	addr := ipAddressParam(req.IPAddress)
+	if addr == nil {
+		return nil
+	}
+	located, ok := s.locator.Locate(*addr)
+	if !ok {
+		return nil
+	}
+	return &specs.GeoPoint{Latitude: located.Latitude, Longitude: located.Longitude}
+}
 
-		// Batch update profile
-		if batchCount >= batchSize {
-			// Recalculate based on DB state
-			err := s.queries.RecalculateUserProfile(ctx, userID)
-			if err != nil {
-				s.logger.Error("failed to recalculate user profile", zap.Error(err))
-			} else {
-				// Refresh local profile
-				p, err := s.queries.GetUserProfileByUserID(ctx, userID)
-				if err == nil {
-					// Update domainProfile
-					domainProfile = &repository.UserProfileBehavior{
-						UserID:                            p.UserID,
-						AverageTransactionAmount:          p.AverageTransactionAmount,
-						StdDevTransactionAmount:           p.StdDevTransactionAmount,
-						MaxTransactionAmountSeen:          p.MaxTransactionAmountSeen,
-						AverageNumberOfTransactionsPerDay: p.AverageNumberOfTransactionsPerDay,
-						UsualTransactionStartHour:         p.UsualTransactionStartHour,
-						UsualTransactionEndHour:           p.UsualTransactionEndHour,
-						TotalTransactions:                 p.TotalTransactions,
-						AllowedTransactions:               p.AllowedTransactions,
-						UpdatedAt:                         p.UpdatedAt,
-					}
-					domainProfile.RegisteredPaymentModes = nil
-					for _, m := range p.RegisteredPaymentModes {
-						domainProfile.RegisteredPaymentModes = append(domainProfile.RegisteredPaymentModes, repository.Mode(m))
-					}
-				}
-			}
-			batchCount = 0
-		}
+// listEntryParam maps the list entry deciding a transaction to its column,
+// NULL when the transaction was scored
+func listEntryParam(entry *specs.ListEntryResponse) pgtype.Int4 {
+	if entry == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: entry.ID, Valid: true}
+}
+
+// locationParams maps a location to the latitude and longitude columns, NULL
+// when the transaction was not located
+func locationParams(location *specs.GeoPoint) (pgtype.Float8, pgtype.Float8) {
+	if location == nil {
+		return pgtype.Float8{}, pgtype.Float8{}
 	}
+	return pgtype.Float8{Float64: location.Latitude, Valid: true}, pgtype.Float8{Float64: location.Longitude, Valid: true}
+}
 
-	// Final recalculation to ensure consistency
-	_ = s.queries.RecalculateUserProfile(ctx, userID)
+// textParam maps an optional request field to its column, NULL when empty
+func textParam(value string) pgtype.Text {
+	return pgtype.Text{String: value, Valid: value != ""}
+}
 
-	return specs.BulkProcessResponse{
-		JobID:     "sync-job-" + time.Now().Format("20060102150405"),
-		Status:    "COMPLETED",
-		Processed: processed,
-		Success:   success,
-		Failed:    failed,
-	}, nil
+// ipAddressParam maps a validated IP address to the inet column, NULL when empty
+func ipAddressParam(ip string) *netip.Addr {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	return &addr
 }
@@ -2,13 +2,16 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
 	"os"
 	"time"
 
+	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/constants"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/errors"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/helpers"
 	"github.com/cheemx5395/fraud-detection-lite/internal/pkg/specs"
 	"github.com/cheemx5395/fraud-detection-lite/internal/repository"
+	"github.com/jackc/pgx/v5"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
@@ -33,20 +36,27 @@ func (s *UserService) Signup(ctx context.Context, req specs.UserSignupRequest) (
 		return specs.UserSignupResponse{}, err
 	}
 
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = constants.DefaultTimezone
+	}
+
 	user, err := s.db.CreateUser(ctx, repository.CreateUserParams{
 		Name:       req.Name,
 		Email:      req.Email,
 		HashedPass: hashedPass,
+		Timezone:   timezone,
 	})
 	if err != nil {
 		return specs.UserSignupResponse{}, err
 	}
 
 	res := specs.UserSignupResponse{
-		Message: "Signup Success!",
-		ID:      user.ID,
-		Name:    user.Name,
-		Email:   user.Email,
+		Message:  "Signup Success!",
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Timezone: user.Timezone,
 	}
 
 	return res, nil
@@ -69,7 +79,7 @@ func (s *UserService) Login(ctx context.Context, req specs.UserLoginRequest) (sp
 	}
 
 	// 24 hours expiration
-	token, err := helpers.MakeJWT(user.ID, user.Name, user.Email, secret, 24*time.Hour)
+	token, err := helpers.MakeJWT(user.ID, user.Name, user.Email, user.Role, secret, 24*time.Hour)
 	if err != nil {
 		return specs.UserLoginResponse{}, err
 	}
@@ -97,3 +107,63 @@ func (s *UserService) Logout(ctx context.Context, claims *specs.UserTokenClaims)
 
 	return nil
 }
+
+// UpdateUserRole changes a user's role. The user's tokens are revoked first,
+// so none carrying the old role stays valid; they have to log in again.
+func (s *UserService) UpdateUserRole(ctx context.Context, userID int32, req specs.UpdateUserRoleRequest) (specs.UserResponse, error) {
+	// tokens live for TokenExpiryDuration, so the revocation can expire with them
+	err := s.rd.Set(ctx, helpers.RevokedTokensKey(userID), time.Now().Unix(), constants.TokenExpiryDuration).Err()
+	if err != nil {
+		s.logger.Error("failed to revoke user tokens", zap.Int32("user_id", userID), zap.Error(err))
+		return specs.UserResponse{}, errors.ErrAuthServiceUnavailable
+	}
+
+	user, err := s.db.UpdateUserRole(ctx, repository.UpdateUserRoleParams{
+		ID:   userID,
+		Role: repository.UserRole(req.Role),
+	})
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return specs.UserResponse{}, errors.ErrUserNotFound
+		}
+		return specs.UserResponse{}, err
+	}
+
+	s.logger.Info("updated user role", zap.Int32("user_id", user.ID), zap.String("role", string(user.Role)))
+	return specs.UserResponse{
+		Message:   "Role updated",
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		Timezone:  user.Timezone,
+		CreatedAt: user.CreatedAt.Time,
+		UpdatedAt: user.UpdatedAt.Time,
+	}, nil
+}
+
+// UpdateTimezone changes the timezone the user's transactions are scored in.
+// Transactions already stored keep the UTC offset they were made at.
+func (s *UserService) UpdateTimezone(ctx context.Context, userID int32, req specs.UpdateTimezoneRequest) (specs.UserResponse, error) {
+	user, err := s.db.UpdateUserTimezone(ctx, repository.UpdateUserTimezoneParams{
+		ID:       userID,
+		Timezone: req.Timezone,
+	})
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return specs.UserResponse{}, errors.ErrUserNotFound
+		}
+		return specs.UserResponse{}, err
+	}
+
+	return specs.UserResponse{
+		Message:   "Timezone updated",
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		Timezone:  user.Timezone,
+		CreatedAt: user.CreatedAt.Time,
+		UpdatedAt: user.UpdatedAt.Time,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+type bulkJobProcessor interface {
+	ProcessNextBulkJob(ctx context.Context) (bool, error)
+}
+
+// BulkJobPool runs queued bulk upload jobs in the background. Each worker
+// drains the queue, then polls it again every interval until ctx is cancelled.
+// Jobs interrupted by a shutdown are resumed by the next claim.
+type BulkJobPool struct {
+	processor bulkJobProcessor
+	workers   int
+	interval  time.Duration
+	wg        sync.WaitGroup
+}
+
+func NewBulkJobPool(processor bulkJobProcessor, workers int, interval time.Duration) *BulkJobPool {
+	return &BulkJobPool{
+		processor: processor,
+		workers:   workers,
+		interval:  interval,
+	}
+}
+
+func (p *BulkJobPool) Start(ctx context.Context) {
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.run(ctx)
+	}
+}
+
+// Wait blocks until every worker has returned after ctx is cancelled
+func (p *BulkJobPool) Wait() {
+	p.wg.Wait()
+}
+
+func (p *BulkJobPool) run(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain processes jobs until none is left to claim or a job fails
+func (p *BulkJobPool) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		found, err := p.processor.ProcessNextBulkJob(ctx)
+		if err != nil {
+			log.Printf("Bulk job failed: %v\n", err)
+			return
+		}
+		if !found {
+			return
+		}
+	}
+}
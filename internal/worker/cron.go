@@ -2,21 +2,32 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"log"
 
 	"github.com/robfig/cron/v3"
 )
 
 type workerQuerier interface {
-	RebuildAllUserProfiles(ctx context.Context) error
+	ListProfiledUserIDs(ctx context.Context) ([]int32, error)
 }
 
+type profileRebuilder interface {
+	RebuildUserProfile(ctx context.Context, userID int32) error
+}
+
+// ProfileUpdater runs the nightly reconciliation pass. Profiles are updated
+// online with every transaction; the rebuild recomputes them from the full
+// transaction history to pick up analyst labels, verified MFA transactions and
+// the robust amount statistics, and to correct any drift. Each user is rebuilt
+// under the user's lock, one at a time.
 type ProfileUpdater struct {
-	queries workerQuerier
+	queries  workerQuerier
+	profiles profileRebuilder
 }
 
-func NewProfileUpdater(queries workerQuerier) *ProfileUpdater {
-	return &ProfileUpdater{queries: queries}
+func NewProfileUpdater(queries workerQuerier, profiles profileRebuilder) *ProfileUpdater {
+	return &ProfileUpdater{queries: queries, profiles: profiles}
 }
 
 func (p *ProfileUpdater) Start(ctx context.Context) *cron.Cron {
@@ -24,7 +35,7 @@ func (p *ProfileUpdater) Start(ctx context.Context) *cron.Cron {
 
 	c.AddFunc("0 0 * * *", func() {
 		if err := p.updateAllProfiles(ctx); err != nil {
-			log.Printf("Failed to update profiles: %v\n", err)
+			log.Printf("Failed to reconcile profiles: %v\n", err)
 		}
 	})
 
@@ -32,11 +43,24 @@ func (p *ProfileUpdater) Start(ctx context.Context) *cron.Cron {
 	return c
 }
 
+// updateAllProfiles rebuilds every profile, carrying on past users that fail
 func (p *ProfileUpdater) updateAllProfiles(ctx context.Context) error {
-	log.Println("Starting profile update...")
-	if err := p.queries.RebuildAllUserProfiles(ctx); err != nil {
+	log.Println("Starting profile reconciliation...")
+	userIDs, err := p.queries.ListProfiledUserIDs(ctx)
+	if err != nil {
 		return err
 	}
-	log.Println("Profile update completed")
+
+	var errs []error
+	for _, userID := range userIDs {
+		if err := p.profiles.RebuildUserProfile(ctx, userID); err != nil {
+			log.Printf("Failed to rebuild profile of user %d: %v\n", userID, err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	log.Println("Profile reconciliation completed")
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,25 +14,52 @@ type mockWorkerQuerier struct {
 	mock.Mock
 }
 
-func (m *mockWorkerQuerier) RebuildAllUserProfiles(ctx context.Context) error {
+func (m *mockWorkerQuerier) ListProfiledUserIDs(ctx context.Context) ([]int32, error) {
 	args := m.Called(ctx)
+	return args.Get(0).([]int32), args.Error(1)
+}
+
+type mockProfileRebuilder struct {
+	mock.Mock
+}
+
+func (m *mockProfileRebuilder) RebuildUserProfile(ctx context.Context, userID int32) error {
+	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
 
 func TestProfileUpdater_updateAllProfiles(t *testing.T) {
-	mockQueries := new(mockWorkerQuerier)
-	updater := NewProfileUpdater(mockQueries)
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
-		mockQueries.On("RebuildAllUserProfiles", ctx).Return(nil).Once()
+		mockQueries := new(mockWorkerQuerier)
+		mockProfiles := new(mockProfileRebuilder)
+		updater := NewProfileUpdater(mockQueries, mockProfiles)
+		mockQueries.On("ListProfiledUserIDs", ctx).Return([]int32{1, 2}, nil).Once()
+		mockProfiles.On("RebuildUserProfile", ctx, int32(1)).Return(nil).Once()
+		mockProfiles.On("RebuildUserProfile", ctx, int32(2)).Return(nil).Once()
 		err := updater.updateAllProfiles(ctx)
 		assert.NoError(t, err)
 		mockQueries.AssertExpectations(t)
+		mockProfiles.AssertExpectations(t)
+	})
+
+	t.Run("Continues past a failing user", func(t *testing.T) {
+		mockQueries := new(mockWorkerQuerier)
+		mockProfiles := new(mockProfileRebuilder)
+		updater := NewProfileUpdater(mockQueries, mockProfiles)
+		mockQueries.On("ListProfiledUserIDs", ctx).Return([]int32{1, 2}, nil).Once()
+		mockProfiles.On("RebuildUserProfile", ctx, int32(1)).Return(errors.New("db error")).Once()
+		mockProfiles.On("RebuildUserProfile", ctx, int32(2)).Return(nil).Once()
+		err := updater.updateAllProfiles(ctx)
+		assert.Error(t, err)
+		mockProfiles.AssertExpectations(t)
 	})
 
 	t.Run("Error", func(t *testing.T) {
-		mockQueries.On("RebuildAllUserProfiles", ctx).Return(errors.New("db error")).Once()
+		mockQueries := new(mockWorkerQuerier)
+		updater := NewProfileUpdater(mockQueries, new(mockProfileRebuilder))
+		mockQueries.On("ListProfiledUserIDs", ctx).Return([]int32(nil), errors.New("db error")).Once()
 		err := updater.updateAllProfiles(ctx)
 		assert.Error(t, err)
 		mockQueries.AssertExpectations(t)
@@ -44,9 +72,49 @@ func TestInitializeRedis(t *testing.T) {
 }
 
 func TestProfileUpdater_Start(t *testing.T) {
-	mockQueries := new(mockWorkerQuerier)
-	updater := NewProfileUpdater(mockQueries)
+	updater := NewProfileUpdater(new(mockWorkerQuerier), new(mockProfileRebuilder))
 	c := updater.Start(context.Background())
 	assert.NotNil(t, c)
 	c.Stop()
 }
+
+type mockBulkJobProcessor struct {
+	mock.Mock
+}
+
+func (m *mockBulkJobProcessor) ProcessNextBulkJob(ctx context.Context) (bool, error) {
+	args := m.Called(ctx)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestBulkJobPool_drain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Drains queue", func(t *testing.T) {
+		mockProcessor := new(mockBulkJobProcessor)
+		pool := NewBulkJobPool(mockProcessor, 1, time.Second)
+		mockProcessor.On("ProcessNextBulkJob", ctx).Return(true, nil).Twice()
+		mockProcessor.On("ProcessNextBulkJob", ctx).Return(false, nil).Once()
+		pool.drain(ctx)
+		mockProcessor.AssertExpectations(t)
+	})
+
+	t.Run("Stops on error", func(t *testing.T) {
+		mockProcessor := new(mockBulkJobProcessor)
+		pool := NewBulkJobPool(mockProcessor, 1, time.Second)
+		mockProcessor.On("ProcessNextBulkJob", ctx).Return(true, errors.New("db error")).Once()
+		pool.drain(ctx)
+		mockProcessor.AssertExpectations(t)
+	})
+}
+
+func TestBulkJobPool_StartAndWait(t *testing.T) {
+	mockProcessor := new(mockBulkJobProcessor)
+	pool := NewBulkJobPool(mockProcessor, 2, time.Hour)
+	mockProcessor.On("ProcessNextBulkJob", mock.Anything).Return(false, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	cancel()
+	pool.Wait()
+}